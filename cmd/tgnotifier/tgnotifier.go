@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	// IANA time zones for the systems without them, e.g. Windows
+	_ "time/tzdata"
 
 	"github.com/alecthomas/kong"
 	"github.com/religiosa1/tgnotifier/internal/cmd"
@@ -13,6 +15,9 @@ type CLI struct {
 	GenerateKey  cmd.GenerateKey `cmd:"" help:"Generate a key for the app HTTP API"`
 	Serve        cmd.Serve       `cmd:"" default:"withargs" help:"Run HTTP server"`
 	Send         cmd.Send        `cmd:"" help:"Send a message in the CLI mode"`
+	Sign         cmd.Sign        `cmd:"" help:"Print HMAC signature headers for a request body"`
+	Digest       cmd.Digest      `cmd:"" help:"Manage the digest of the running service"`
+	Schedules    cmd.Schedules   `cmd:"" help:"Recurring messages from the config"`
 	Version      cmd.Version     `cmd:"" help:"Show version and additional config information"`
 }
 
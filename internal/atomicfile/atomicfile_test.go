@@ -0,0 +1,32 @@
+package atomicfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/atomicfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "state.json")
+	require.NoError(t, os.WriteFile(file, []byte("old"), 0o600))
+
+	require.NoError(t, atomicfile.WriteFile(file, []byte("new")))
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	// the temporary file isn't left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWriteFile_MissingDir(t *testing.T) {
+	err := atomicfile.WriteFile(filepath.Join(t.TempDir(), "missing", "state.json"), []byte("new"))
+	assert.Error(t, err)
+}
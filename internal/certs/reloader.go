@@ -0,0 +1,149 @@
+// Package certs provides TLS server configuration, which picks up renewed
+// certificates without restarting the server.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader keeps the current server certificate and client CA pool, loaded
+// from the files on disk.
+type Reloader struct {
+	certPath     string
+	keyPath      string
+	clientCaPath string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCas *x509.CertPool
+	modTimes  []time.Time
+}
+
+// NewReloader loads certificate and key files and optionally a client CA
+// bundle (pass an empty clientCaPath to disable client certificate verification).
+func NewReloader(certPath, keyPath, clientCaPath string) (*Reloader, error) {
+	if certPath == "" || keyPath == "" {
+		return nil, errors.New("both certificate and key paths must be provided")
+	}
+	r := &Reloader{certPath: certPath, keyPath: keyPath, clientCaPath: clientCaPath}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload re-reads the files. On error the previously loaded values are kept.
+func (r *Reloader) Reload() error {
+	modTimes, err := r.readModTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certPath, r.keyPath)
+	if err != nil {
+		return fmt.Errorf("error loading TLS key pair: %w", err)
+	}
+	var clientCas *x509.CertPool
+	if r.clientCaPath != "" {
+		pem, err := os.ReadFile(r.clientCaPath)
+		if err != nil {
+			return fmt.Errorf("error reading client CA bundle: %w", err)
+		}
+		clientCas = x509.NewCertPool()
+		if !clientCas.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA bundle %s", r.clientCaPath)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCas = clientCas
+	r.modTimes = modTimes
+	return nil
+}
+
+// Changed reports whether any of the files was modified since the last reload.
+func (r *Reloader) Changed() (bool, error) {
+	modTimes, err := r.readModTimes()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i, t := range modTimes {
+		if !t.Equal(r.modTimes[i]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Watch polls the files with the given interval and reloads them on change,
+// until ctx is cancelled. onReload is called with the outcome of each reload.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration, onReload func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.Changed()
+			if err == nil && !changed {
+				continue
+			}
+			if err == nil {
+				err = r.Reload()
+			}
+			onReload(err)
+		}
+	}
+}
+
+// TLSConfig returns a server config, always serving the latest loaded
+// certificate. If client CA bundle is set, clients must present a certificate
+// signed by it, unless clientCertOptional is true.
+func (r *Reloader) TLSConfig(clientCertOptional bool) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+			}
+			if r.clientCas != nil {
+				cfg.ClientCAs = r.clientCas
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				if clientCertOptional {
+					cfg.ClientAuth = tls.VerifyClientCertIfGiven
+				}
+			}
+			return cfg, nil
+		},
+	}
+}
+
+func (r *Reloader) readModTimes() ([]time.Time, error) {
+	paths := []string{r.certPath, r.keyPath}
+	if r.clientCaPath != "" {
+		paths = append(paths, r.clientCaPath)
+	}
+	modTimes := make([]time.Time, len(paths))
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
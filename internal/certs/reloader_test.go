@@ -0,0 +1,129 @@
+package certs_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/certs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSelfSigned(t *testing.T, dir string, commonName string) (certPath, keyPath string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath = filepath.Join(dir, "cert.pem")
+	keyPath = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return certPath, keyPath
+}
+
+func servedCommonName(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+	clientCfg, err := cfg.GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.Len(t, clientCfg.Certificates, 1)
+	leaf, err := x509.ParseCertificate(clientCfg.Certificates[0].Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestReloader_LoadsCertificate(t *testing.T) {
+	certPath, keyPath := writeSelfSigned(t, t.TempDir(), "first")
+
+	r, err := certs.NewReloader(certPath, keyPath, "")
+	require.NoError(t, err)
+
+	cfg := r.TLSConfig(false)
+	assert.Equal(t, "first", servedCommonName(t, cfg))
+	clientCfg, err := cfg.GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, clientCfg.ClientAuth)
+}
+
+func TestReloader_ReloadPicksUpNewCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeSelfSigned(t, dir, "first")
+	r, err := certs.NewReloader(certPath, keyPath, "")
+	require.NoError(t, err)
+	cfg := r.TLSConfig(false)
+
+	writeSelfSigned(t, dir, "second")
+	// making sure mtime differs even on coarse-grained file systems
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certPath, future, future))
+
+	changed, err := r.Changed()
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	require.NoError(t, r.Reload())
+	assert.Equal(t, "second", servedCommonName(t, cfg))
+
+	changed, err = r.Changed()
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestReloader_FailedReloadKeepsPreviousCertificate(t *testing.T) {
+	certPath, keyPath := writeSelfSigned(t, t.TempDir(), "first")
+	r, err := certs.NewReloader(certPath, keyPath, "")
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(keyPath, []byte("garbage"), 0o600))
+	assert.Error(t, r.Reload())
+	assert.Equal(t, "first", servedCommonName(t, r.TLSConfig(false)))
+}
+
+func TestReloader_ClientCa(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeSelfSigned(t, dir, "server")
+	caDir := t.TempDir()
+	caPath, _ := writeSelfSigned(t, caDir, "ca")
+
+	r, err := certs.NewReloader(certPath, keyPath, caPath)
+	require.NoError(t, err)
+
+	required, err := r.TLSConfig(false).GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, required.ClientAuth)
+	assert.NotNil(t, required.ClientCAs)
+
+	optional, err := r.TLSConfig(true).GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, optional.ClientAuth)
+}
+
+func TestReloader_InvalidPaths(t *testing.T) {
+	_, err := certs.NewReloader("", "", "")
+	assert.Error(t, err)
+
+	_, err = certs.NewReloader("non-existent.pem", "non-existent.key", "")
+	assert.Error(t, err)
+}
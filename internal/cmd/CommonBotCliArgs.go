@@ -13,7 +13,7 @@ type CommandInterface interface {
 
 type CommonBotCliArgs struct {
 	Config     string   `short:"c" help:"Configuration file path ($BOT_CONFIG_PATH)"`
-	Recipients []string `short:"r" help:"Message recipients, comma separated (defaults to value from config or $BOT_RECIPIENTS)"`
+	Recipients []string `short:"r" help:"Message recipients or recipient group names, comma separated (defaults to value from config or $BOT_RECIPIENTS)"`
 	BotToken   string   `yaml:"bot_token" help:"Your bot token as given by botfather (defaults to value from config or $BOT_TOKEN)"`
 }
 
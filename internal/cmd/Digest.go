@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/religiosa1/tgnotifier/internal/http/models"
+)
+
+type Digest struct {
+	Flush DigestFlush `cmd:"" help:"Send the pending digest of the running service now"`
+}
+
+type DigestFlush struct {
+	ServiceClientArgs `embed:""`
+}
+
+func (cmd *DigestFlush) Run() error {
+	var resp models.DigestFlushResponsePayload
+	if err := cmd.call(http.MethodPost, "/digest/flush", nil, &resp); err != nil {
+		return fmt.Errorf("error flushing the digest: %w", err)
+	}
+	fmt.Printf("Digest sent, %d messages\n", resp.Messages)
+	return nil
+}
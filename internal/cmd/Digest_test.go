@@ -0,0 +1,51 @@
+package cmd_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	cfgName := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(cfgName, []byte(content), 0o600))
+	return cfgName
+}
+
+func TestDigestFlush(t *testing.T) {
+	var gotPath, gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotKey = r.Method+" "+r.URL.Path, r.Header.Get("x-api-key")
+		w.Write([]byte(`{"success": true, "messages": 3}`))
+	}))
+	defer srv.Close()
+
+	flush := cmd.DigestFlush{ServiceClientArgs: cmd.ServiceClientArgs{
+		Config: writeTestConfig(t, "api_key: secret\n"),
+		Url:    srv.URL,
+	}}
+	require.NoError(t, flush.Run())
+	assert.Equal(t, "POST /digest/flush", gotPath)
+	assert.Equal(t, "secret", gotKey)
+}
+
+func TestDigestFlush_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"success": false, "error": "Access to the endpoint is not allowed"}`))
+	}))
+	defer srv.Close()
+
+	flush := cmd.DigestFlush{ServiceClientArgs: cmd.ServiceClientArgs{
+		Config: writeTestConfig(t, "api_key: secret\n"),
+		Url:    srv.URL,
+	}}
+	assert.ErrorContains(t, flush.Run(), "status 403: Access to the endpoint is not allowed")
+}
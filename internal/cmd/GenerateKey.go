@@ -5,15 +5,23 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strings"
+
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
 )
 
-type GenerateKey struct{}
+type GenerateKey struct {
+	Hash bool `help:"Also output the key hash, to store in the config instead of the key itself"`
+}
 
 func (cmd *GenerateKey) Run() error {
 	key := make([]byte, 30)
 	if _, err := rand.Read(key); err != nil {
 		return fmt.Errorf("error while generating a random key: %w", err)
 	}
-	fmt.Println(strings.ToUpper(hex.EncodeToString(key)))
+	keyStr := strings.ToUpper(hex.EncodeToString(key))
+	fmt.Println(keyStr)
+	if cmd.Hash {
+		fmt.Println(middleware.HashApiKey(keyStr))
+	}
 	return nil
 }
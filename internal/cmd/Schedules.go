@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/schedules"
+)
+
+type Schedules struct {
+	List SchedulesList `cmd:"" help:"List the recurring messages from the config with their next run times"`
+}
+
+type SchedulesList struct {
+	Config string `short:"c" help:"Configuration file path ($BOT_CONFIG_PATH)"`
+	Count  int    `short:"n" default:"1" help:"Number of the next run times to show"`
+}
+
+func (cmd *SchedulesList) Run() error {
+	cfg, err := config.Load(cmd.Config)
+	if err != nil {
+		return err
+	}
+	jobs, err := schedules.NewJobs(cfg.Schedules, cfg.Groups(), cfg.Recipients)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		fmt.Println("No schedules are configured")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCRON\tTIMEZONE\tNEXT RUN")
+	now := time.Now()
+	for _, job := range jobs {
+		name, expr, location := job.Name, job.Cron, job.Location.String()
+		next := now
+		for range max(cmd.Count, 1) {
+			if next = job.Next(next); next.IsZero() {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, expr, location, "never")
+				break
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, expr, location, next.Format("2006-01-02 15:04 MST"))
+			// only the first line of the job has its details
+			name, expr, location = "", "", ""
+		}
+	}
+	return w.Flush()
+}
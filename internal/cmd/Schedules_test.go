@@ -0,0 +1,61 @@
+package cmd_test
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/cmd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureStdout returns the output of fn to the standard output.
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fnErr := fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, fnErr)
+	return string(out)
+}
+
+func TestSchedulesList(t *testing.T) {
+	list := cmd.SchedulesList{
+		Config: writeTestConfig(t, `
+recipients: ["1"]
+schedules:
+  - name: standup
+    cron: "55 9 * * mon-fri"
+    timezone: Europe/Berlin
+    template: Stand-up in 5 minutes
+  - name: handover
+    cron: "@weekly"
+    timezone: UTC
+    template: On-call handover
+`),
+		Count: 2,
+	}
+	out := captureStdout(t, list.Run)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 5)
+	assert.Regexp(t, `^NAME\s+CRON\s+TIMEZONE\s+NEXT RUN$`, lines[0])
+	assert.Regexp(t, `^standup\s+55 9 \* \* mon-fri\s+Europe/Berlin\s+\d{4}-\d{2}-\d{2} 09:55 CES?T$`, lines[1])
+	assert.Regexp(t, `^\s+\d{4}-\d{2}-\d{2} 09:55 CES?T$`, lines[2])
+	assert.Regexp(t, `^handover\s+@weekly\s+UTC\s+\d{4}-\d{2}-\d{2} 00:00 UTC$`, lines[3])
+}
+
+func TestSchedulesList_Empty(t *testing.T) {
+	list := cmd.SchedulesList{Config: writeTestConfig(t, "recipients: [\"1\"]\n"), Count: 1}
+	out := captureStdout(t, list.Run)
+	assert.Equal(t, "No schedules are configured\n", out)
+}
@@ -1,23 +1,65 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/religiosa1/tgnotifier"
 	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/religiosa1/tgnotifier/internal/http/models"
 )
 
 type Send struct {
 	CommonBotCliArgs `embed:""`
-	ParseMode        string `short:"m" placeholder:"MarkdownV2" help:"Message parse mode"`
-	Message          string `arg:"" optional:"" help:"Message to send. Read from STDIN if not specified"`
+	ParseMode        string        `short:"m" placeholder:"MarkdownV2" help:"Message parse mode"`
+	Batch            string        `placeholder:"FILE" help:"Send messages from JSON lines file ('-' for STDIN), each line is a payload as in the HTTP API"`
+	At               string        `placeholder:"TIME" help:"Schedule the message on the running service to be sent at RFC 3339 or local 'YYYY-MM-DD HH:MM' time"`
+	In               time.Duration `placeholder:"15m" help:"Schedule the message on the running service to be sent after the delay"`
+	Url              string        `help:"Running service URL for scheduled messages (defaults to the configured address)"`
+	ApiKey           string        `short:"k" help:"API key of the running service for scheduled messages (defaults to api_key value from config or $BOT_API_KEY)"`
+	Message          string        `arg:"" optional:"" help:"Message to send. Read from STDIN if not specified"`
+
+	sendAt time.Time
 }
 
+// local time format, accepted by --at
+const localTimeFormat = "2006-01-02 15:04"
+
+// maximum length of a line in the batch file
+const maxBatchLineLen = 1024 * 1024
+
 func (cmd *Send) AfterApply(ctx *kong.Context) error {
+	if cmd.At != "" && cmd.In != 0 {
+		return errors.New("--at and --in can't be used together")
+	}
+	if cmd.In < 0 {
+		return errors.New("--in must not be negative")
+	}
+	if cmd.At != "" {
+		var err error
+		if cmd.sendAt, err = parseSendAt(cmd.At); err != nil {
+			return err
+		}
+	}
+	if cmd.Batch != "" {
+		if cmd.Message != "" {
+			return errors.New("message can't be used together with --batch")
+		}
+		if cmd.isScheduled() {
+			return errors.New("--at and --in can't be used together with --batch")
+		}
+		return nil
+	}
 	if cmd.Message == "" {
 		// limiting to one extra bite from the allowed max, so we can error out from tgnotifier
 		r := io.LimitReader(os.Stdin, int64(tgnotifier.MaxMsgLen+1))
@@ -31,12 +73,16 @@ func (cmd *Send) AfterApply(ctx *kong.Context) error {
 }
 
 func (cmd *Send) Run() error {
+	if cmd.isScheduled() {
+		return cmd.schedule()
+	}
 	cfg, err := config.Load(cmd.Config)
 	if err != nil {
 		return err
 	}
 	cmd.MergeConfig(cfg)
-	if len(cmd.Recipients) == 0 {
+	// batch lines can have their own recipients
+	if len(cmd.Recipients) == 0 && cmd.Batch == "" {
 		return errors.New("recipients list must be provided through the CLI, config or environment variable")
 	}
 	// we're not validating the Send struct, only common args, allowing bot to error out
@@ -47,7 +93,113 @@ func (cmd *Send) Run() error {
 	if err != nil {
 		return fmt.Errorf("error initializing the bot: %w", err)
 	}
-	if err := bot.SendMessage(cmd.Message, cmd.ParseMode, cmd.Recipients); err != nil {
+	if cmd.Batch != "" {
+		return cmd.sendBatch(bot, cfg.Groups())
+	}
+	recipients, err := cfg.Groups().Resolve(cmd.Recipients)
+	if err != nil {
+		return err
+	}
+	if err := bot.SendMessage(cmd.Message, cmd.ParseMode, recipients); err != nil {
+		return fmt.Errorf("error sending the message: %w", err)
+	}
+	return nil
+}
+
+func (cmd *Send) isScheduled() bool {
+	return !cmd.sendAt.IsZero() || cmd.In != 0
+}
+
+// schedule submits the message to the running service, which persists and
+// sends it at the time. Recipients default to the service's ones.
+func (cmd *Send) schedule() error {
+	payload := handlers.RequestPayload{
+		Message:    cmd.Message,
+		ParseMode:  cmd.ParseMode,
+		Recipients: cmd.Recipients,
+	}
+	if !cmd.sendAt.IsZero() {
+		payload.SendAt = &cmd.sendAt
+	} else {
+		payload.Delay = cmd.In.String()
+	}
+	client := ServiceClientArgs{Config: cmd.Config, Url: cmd.Url, ApiKey: cmd.ApiKey}
+	var resp models.ScheduledMessageResponsePayload
+	if err := client.call(http.MethodPost, "/", payload, &resp); err != nil {
+		return fmt.Errorf("error scheduling the message: %w", err)
+	}
+	fmt.Printf("Message %s scheduled at %s\n", resp.ID, resp.SendAt.Local().Format(time.RFC3339))
+	return nil
+}
+
+// parseSendAt parses the time in RFC 3339 or local time format.
+func parseSendAt(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(localTimeFormat, value, time.Local)
+	if err != nil {
+		return t, fmt.Errorf("invalid --at value %q, expected RFC 3339 or 'YYYY-MM-DD HH:MM' time", value)
+	}
+	return t, nil
+}
+
+// sendBatch sends the messages from the batch file one by one, returning the
+// errors of all of the failed lines.
+func (cmd *Send) sendBatch(bot tgnotifier.BotInterface, groups tgnotifier.RecipientGroups) error {
+	var input io.Reader = os.Stdin
+	if cmd.Batch != "-" {
+		file, err := os.Open(cmd.Batch)
+		if err != nil {
+			return fmt.Errorf("error opening the batch file: %w", err)
+		}
+		defer file.Close()
+		input = file
+	}
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(nil, maxBatchLineLen)
+	var errs []error
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := cmd.sendBatchLine(bot, groups, line); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", lineNo, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("error reading the batch file: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func (cmd *Send) sendBatchLine(bot tgnotifier.BotInterface, groups tgnotifier.RecipientGroups, line []byte) error {
+	var payload handlers.RequestPayload
+	if err := json.Unmarshal(line, &payload); err != nil {
+		return fmt.Errorf("error decoding the message: %w", err)
+	}
+	// messages are sent right away, scheduling is done by the running service
+	if payload.SendAt != nil || payload.Delay != "" {
+		return errors.New("send_at and delay aren't supported in the batch, schedule the messages with --at or --in one by one")
+	}
+	recipients := cmd.Recipients
+	if payload.Recipients != nil {
+		recipients = payload.Recipients
+	}
+	if len(recipients) == 0 {
+		return errors.New("recipients list must be provided in the line, CLI, config or environment variable")
+	}
+	recipients, err := groups.Resolve(recipients)
+	if err != nil {
+		return err
+	}
+	parseMode := payload.ParseMode
+	if parseMode == "" {
+		parseMode = cmd.ParseMode
+	}
+	options := tgnotifier.SendOptions{DisableNotification: payload.Silent}
+	if err := tgnotifier.SendWithOptions(context.Background(), bot, payload.Message, parseMode, recipients, options); err != nil {
 		return fmt.Errorf("error sending the message: %w", err)
 	}
 	return nil
@@ -1,11 +1,17 @@
 package cmd_test
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/religiosa1/tgnotifier/internal/cmd"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
 	"github.com/religiosa1/tgnotifier/internal/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSend_parseFlags(t *testing.T) {
@@ -76,3 +82,64 @@ func TestSend_parsePriorityFlagEnvConfig(t *testing.T) {
 	assert.Equal(t, []string{"5", "6", "7"}, cmd.Recipients) // flag overrides env
 	assert.Equal(t, "test-token", cmd.BotToken)              // env without flag
 }
+
+func TestSend_parseBatch(t *testing.T) {
+	var cmd cmd.Send
+	p := newCliParserWithConfig(t, &cmd, test.MockConfig)
+	_, err := p.Parse([]string{"--batch", "messages.jsonl"})
+	if err != nil {
+		t.Fatalf("error parsing args: %v", err)
+	}
+	assert.Equal(t, "messages.jsonl", cmd.Batch)
+	assert.Equal(t, "", cmd.Message) // not read from stdin
+}
+
+func TestSend_parseBatchWithMessage(t *testing.T) {
+	var cmd cmd.Send
+	p := newCliParserWithConfig(t, &cmd, test.MockConfig)
+	_, err := p.Parse([]string{"--batch", "messages.jsonl", "lorem"})
+	assert.Error(t, err)
+}
+
+func TestSend_parseScheduleErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+	}{
+		{"at and in", []string{"--at", "2100-01-01T09:00:00Z", "--in", "1h", "lorem"}},
+		{"invalid at", []string{"--at", "tomorrow", "lorem"}},
+		{"negative in", []string{"--in", "-1h", "lorem"}},
+		{"with batch", []string{"--in", "1h", "--batch", "messages.jsonl"}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var cmd cmd.Send
+			p := newCliParserWithConfig(t, &cmd, test.MockConfig)
+			_, err := p.Parse(tt.args)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSend_Schedule(t *testing.T) {
+	var gotPath string
+	var gotPayload handlers.RequestPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.Method + " " + r.URL.Path
+		json.NewDecoder(r.Body).Decode(&gotPayload)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"success": true, "id": "01J", "send_at": "2100-01-01T09:00:00Z"}`))
+	}))
+	defer srv.Close()
+
+	var send cmd.Send
+	p := newCliParserWithConfig(t, &send, test.MockConfig)
+	_, err := p.Parse([]string{"--url", srv.URL, "-c", writeTestConfig(t, "api_key: secret\n"), "--at", "2100-01-01 09:00", "lorem"})
+	require.NoError(t, err)
+	require.NoError(t, send.Run())
+
+	assert.Equal(t, "POST /", gotPath)
+	assert.Equal(t, "lorem", gotPayload.Message)
+	require.NotNil(t, gotPayload.SendAt)
+	assert.True(t, time.Date(2100, 1, 1, 9, 0, 0, 0, time.Local).Equal(*gotPayload.SendAt))
+}
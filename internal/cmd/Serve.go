@@ -1,18 +1,49 @@
 package cmd
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/certs"
 	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/cron"
+	"github.com/religiosa1/tgnotifier/internal/digest"
+	"github.com/religiosa1/tgnotifier/internal/heartbeat"
 	"github.com/religiosa1/tgnotifier/internal/http/handlers"
 	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/religiosa1/tgnotifier/internal/idempotency"
+	"github.com/religiosa1/tgnotifier/internal/jwt"
+	"github.com/religiosa1/tgnotifier/internal/listener"
+	"github.com/religiosa1/tgnotifier/internal/quiet"
+	"github.com/religiosa1/tgnotifier/internal/ratelimit"
+	"github.com/religiosa1/tgnotifier/internal/scheduled"
+	"github.com/religiosa1/tgnotifier/internal/schedules"
+)
+
+const (
+	// certsWatchInterval is how often TLS certificate files are checked for changes
+	certsWatchInterval = time.Minute
+	// defaultJwksCacheTtl is how long fetched JWKS is cached, if not configured
+	defaultJwksCacheTtl = time.Hour
+	// jwtLeeway is the allowed clock difference for JWT expiration checks
+	jwtLeeway = time.Minute
+	// shutdownTimeout is how long the running requests are waited for on shutdown
+	shutdownTimeout = 30 * time.Second
+	// digestShutdownTimeout is how long the pending digest is sent on shutdown
+	digestShutdownTimeout = 30 * time.Second
 )
 
 // We can't use enums, default values, etc. in struct tags unless we implement
@@ -21,11 +52,18 @@ import (
 // https://github.com/alecthomas/kong/issues/365
 
 type Serve struct {
-	CommonBotCliArgs `embed:""`
-	Address          string `arg:"" optional:"" env:"BOT_ADDR" placeholder:"localhost:6000" help:"HTTP server listening address ($BOT_ADDR)"`
-	LogType          string `placeholder:"text" help:"Logger output type ($BOT_LOG_TYPE)"`
-	LogLevel         string `placeholder:"info" help:"Minimum logging level ($BOT_LOG_LEVEL)"`
-	ApiKey           string `help:"API key, passed in 'x-api-key' header to authorize incoming requests ($BOT_API_KEY)"`
+	CommonBotCliArgs  `embed:""`
+	Address           string        `arg:"" optional:"" env:"BOT_ADDR" placeholder:"localhost:6000" help:"HTTP server listening address or 'unix:' prefixed socket path ($BOT_ADDR)"`
+	SocketMode        string        `placeholder:"0660" help:"Unix socket file mode ($BOT_SOCKET_MODE)"`
+	SocketGroup       string        `help:"Unix socket group name or id ($BOT_SOCKET_GROUP)"`
+	LogType           string        `placeholder:"text" help:"Logger output type ($BOT_LOG_TYPE)"`
+	LogLevel          string        `placeholder:"info" help:"Minimum logging level ($BOT_LOG_LEVEL)"`
+	ApiKey            string        `help:"API key, passed in 'x-api-key' header to authorize incoming requests ($BOT_API_KEY)"`
+	HmacWindow        time.Duration `placeholder:"5m" help:"Maximum allowed clock difference for HMAC signed requests ($BOT_HMAC_WINDOW)"`
+	TlsCert           string        `help:"TLS certificate file, enables HTTPS ($BOT_TLS_CERT)"`
+	TlsKey            string        `help:"TLS private key file ($BOT_TLS_KEY)"`
+	TlsClientCa       string        `help:"CA bundle to verify client certificates against ($BOT_TLS_CLIENT_CA)"`
+	TlsClientSubjects []string      `help:"Client certificate subjects allowed to access the API, comma separated ($BOT_TLS_CLIENT_SUBJECTS)"`
 }
 
 func (cmd *Serve) MergeConfig(cfg config.Config) {
@@ -33,7 +71,16 @@ func (cmd *Serve) MergeConfig(cfg config.Config) {
 	MergeValueInto(&cmd.LogType, cfg.LogType)
 	MergeValueInto(&cmd.LogLevel, cfg.LogLevel)
 	MergeValueInto(&cmd.Address, cfg.Address)
+	MergeValueInto(&cmd.SocketMode, cfg.SocketMode)
+	MergeValueInto(&cmd.SocketGroup, cfg.SocketGroup)
 	MergeValueInto(&cmd.ApiKey, cfg.ApiKey)
+	MergeValueInto(&cmd.HmacWindow, cfg.HmacWindow)
+	MergeValueInto(&cmd.TlsCert, cfg.TlsCert)
+	MergeValueInto(&cmd.TlsKey, cfg.TlsKey)
+	MergeValueInto(&cmd.TlsClientCa, cfg.TlsClientCa)
+	if len(cmd.TlsClientSubjects) == 0 {
+		cmd.TlsClientSubjects = cfg.TlsClientSubjects
+	}
 }
 func MergeValueInto[T comparable](target *T, source T) {
 	var zero T
@@ -49,6 +96,15 @@ func (cmd *Serve) ValidatePostMerge() error {
 	if cmd.LogType != "text" && cmd.LogType != "json" {
 		return errors.New(`incorrect value for log type, only "text" and "json" are supported`)
 	}
+	if (cmd.TlsCert == "") != (cmd.TlsKey == "") {
+		return errors.New("tls_cert and tls_key must be provided together")
+	}
+	if cmd.TlsClientCa != "" && cmd.TlsCert == "" {
+		return errors.New("tls_client_ca requires tls_cert and tls_key to be set")
+	}
+	if _, err := listener.ParseSocketMode(cmd.SocketMode); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -64,48 +120,467 @@ func (cmd *Serve) Run() error {
 	}
 
 	logger := setupLogger(cmd.LogType, cmd.LogLevel)
-	bot, err := tgnotifier.New(cmd.BotToken)
+	tgBot, err := tgnotifier.New(cmd.BotToken)
 	if err != nil {
 		logger.Error("Error creating a bot", slog.Any("error", err))
 		return err
 	}
 
-	botInfo, err := bot.GetMe()
+	botInfo, err := tgBot.GetMe()
 	if err != nil {
 		logger.Error("Error accessing the telegram API with the provided bot token", slog.Any("error", err))
 		return err
 	}
 	logger.Debug("Bot initialized", slog.Any("GetMeInfo", botInfo))
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
-	errCh := make(chan error, 1)
+	authenticators, err := cmd.authenticators(cfg.ApiKeys, cfg.Jwt)
+	if err != nil {
+		logger.Error("Error in the authentication config", slog.Any("error", err))
+		return err
+	}
+	trustedProxies, err := middleware.ParsePrefixes(cfg.TrustedProxies)
+	if err != nil {
+		logger.Error("Error in the trusted proxies config", slog.Any("error", err))
+		return err
+	}
+	allowedNetworks, err := middleware.ParsePrefixes(cfg.AllowedNetworks)
+	if err != nil {
+		logger.Error("Error in the allowed networks config", slog.Any("error", err))
+		return err
+	}
+	channels, err := handlers.NewChannels(cfg.Channels)
+	if err != nil {
+		logger.Error("Error in the channels config", slog.Any("error", err))
+		return err
+	}
+	limiter := ratelimit.NewLimiter()
+	baseMiddlewares := middleware.Chain(
+		middleware.WithClientIp(trustedProxies),
+		middleware.WithLogger(logger),
+		middleware.WithAllowedNetworks(allowedNetworks),
+		middleware.WithIpRateLimit(limiter, cfg.RateLimit.PerIp),
+	)
+	// webhooks of the third party services, verifying their own signatures, skip the auth
+	hasHookRoutes := false
+	hookMiddlewares := func(next http.Handler) http.Handler {
+		hasHookRoutes = true
+		return baseMiddlewares(next)
+	}
+	middlewares := middleware.Chain(
+		baseMiddlewares,
+		middleware.WithAuth(authenticators...),
+		middleware.WithKeyRateLimit(limiter, cfg.RateLimit.PerKey, cfg.ApiKeys),
+	)
+	withScope := func(scope string) middleware.Middleware {
+		return middleware.Chain(middlewares, middleware.RequireScope(scope))
+	}
+
+	quietRules, err := quietHoursRules(cfg.QuietHours, cfg.Groups())
+	if err != nil {
+		logger.Error("Error in the quiet hours config", slog.Any("error", err))
+		return err
+	}
+	var bot tgnotifier.BotInterface = tgBot
+	var quietBot *quiet.Bot
+	if len(quietRules) > 0 {
+		quietBot = quiet.NewBot(tgBot, quietRules, logger)
+		bot = quietBot
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /", withScope(middleware.ScopeHealthcheck)(handlers.Healthcheck{Bot: bot}))
+	digestSchedule, err := cron.Parse(cfg.Digest.Schedule)
+	if err != nil {
+		logger.Error("Error in the digest config", slog.Any("error", err))
+		return err
+	}
+	messageDigest := digest.New(bot)
+	digestDone := make(chan struct{})
 	go func() {
-		mux := http.NewServeMux()
-		middlewares := middleware.Chain(
-			middleware.WithLogger(logger),
-			middleware.WithApiKeyAuth(cmd.ApiKey),
-		)
-		mux.Handle("GET /", middlewares(handlers.Healthcheck{Bot: bot}))
-		mux.Handle("POST /", middlewares(handlers.Notify{Bot: bot, Recipients: cmd.Recipients}))
+		defer close(digestDone)
+		messageDigest.Run(ctx, digestSchedule, logger)
+	}()
+	scheduledMessages, err := scheduled.Open(bot, cfg.ScheduledFile, cfg.ScheduledMaxPending, logger)
+	if err != nil {
+		logger.Error("Error loading the scheduled messages", slog.Any("error", err))
+		return err
+	}
+	defer scheduledMessages.Close()
+	jobs, err := schedules.NewJobs(cfg.Schedules, cfg.Groups(), cmd.Recipients)
+	if err != nil {
+		logger.Error("Error in the schedules config", slog.Any("error", err))
+		return err
+	}
+	go schedules.Run(ctx, bot, jobs, logger)
+	checks, err := heartbeat.NewChecks(cfg.Heartbeats, cfg.Groups(), cmd.Recipients)
+	if err != nil {
+		logger.Error("Error in the heartbeats config", slog.Any("error", err))
+		return err
+	}
+	heartbeatMonitor, err := heartbeat.NewMonitor(bot, checks, cfg.HeartbeatsFile, logger)
+	if err != nil {
+		logger.Error("Error loading the heartbeats state", slog.Any("error", err))
+		return err
+	}
+	defer heartbeatMonitor.Close()
 
-		if err := http.ListenAndServe(cmd.Address, mux); err != nil {
-			logger.Error("Error starting the server", slog.Any("error", err))
-			errCh <- err
+	notify := handlers.Notify{
+		Bot:         bot,
+		Recipients:  cmd.Recipients,
+		Groups:      cfg.Groups(),
+		Channels:    channels,
+		Idempotency: idempotency.NewCache(cfg.IdempotencyTtl),
+		Digest:      messageDigest,
+		Scheduled:   scheduledMessages,
+	}
+	mux.Handle("POST /", withScope(middleware.ScopeNotify)(notify))
+	mux.Handle("POST /notify/{channel}", withScope(middleware.ScopeNotify)(notify))
+	if !cfg.DisableGetSend {
+		// for the clients, able to send only GET requests, with the API key in basic auth of the URL
+		mux.Handle("GET /send", withScope(middleware.ScopeNotify)(notify))
+		mux.Handle("GET /send/{channel}", withScope(middleware.ScopeNotify)(notify))
+	}
+	scheduledHandler := handlers.Scheduled{Store: scheduledMessages}
+	mux.Handle("GET /scheduled", withScope(middleware.ScopeNotify)(scheduledHandler))
+	mux.Handle("GET /scheduled/{id}", withScope(middleware.ScopeNotify)(scheduledHandler))
+	mux.Handle("DELETE /scheduled/{id}", withScope(middleware.ScopeNotify)(scheduledHandler))
+	if len(checks) > 0 {
+		// cron jobs are likely to ping with a plain curl, so GET is accepted as well
+		heartbeatHandler := withScope(middleware.ScopeNotify)(handlers.Heartbeat{
+			Monitor: heartbeatMonitor,
+			Groups:  cfg.Groups(),
+		})
+		for _, route := range []string{"/heartbeat/{name}", "/heartbeat/{name}/{action}"} {
+			mux.Handle("GET "+route, heartbeatHandler)
+			mux.Handle("POST "+route, heartbeatHandler)
 		}
-	}()
-	logger.Info("Running bot http server", slog.String("address", cmd.Address), slog.Any("recipients", cmd.Recipients))
+	}
+	mux.Handle("POST /digest/flush", withScope(middleware.ScopeAdmin)(handlers.DigestFlush{Digest: messageDigest}))
+	mux.Handle("POST /batch", withScope(middleware.ScopeNotify)(handlers.Batch{
+		Notify:    notify,
+		MaxItems:  cfg.BatchMaxItems,
+		RateLimit: middleware.KeyRateLimit{Limiter: limiter, Limit: cfg.RateLimit.PerKey, ApiKeys: cfg.ApiKeys},
+	}))
+	mux.Handle("POST /hooks/alertmanager", withScope(middleware.ScopeNotify)(handlers.Alertmanager{
+		Bot:             bot,
+		Recipients:      cmd.Recipients,
+		Groups:          cfg.Groups(),
+		RecipientsLabel: cfg.Hooks.Alertmanager.RecipientsLabel,
+	}))
+	mux.Handle("POST /hooks/grafana", withScope(middleware.ScopeNotify)(handlers.Grafana{
+		Bot:             bot,
+		Recipients:      cmd.Recipients,
+		Groups:          cfg.Groups(),
+		RecipientsLabel: cfg.Hooks.Grafana.RecipientsLabel,
+	}))
+	if hook := cfg.Hooks.GitHub; hook.Secret != "" {
+		mux.Handle("POST /hooks/github", hookMiddlewares(handlers.GitHub{
+			Bot:        bot,
+			Recipients: cmd.hookRecipients(hook.Recipients),
+			Groups:     cfg.Groups(),
+			Secret:     hook.Secret,
+			Filter:     handlers.EventFilter{Events: hook.Events, Branches: hook.Branches},
+		}))
+	}
+	if hook := cfg.Hooks.GitLab; hook.Secret != "" {
+		mux.Handle("POST /hooks/gitlab", hookMiddlewares(handlers.GitLab{
+			Bot:        bot,
+			Recipients: cmd.hookRecipients(hook.Recipients),
+			Groups:     cfg.Groups(),
+			Token:      hook.Secret,
+			Filter:     handlers.EventFilter{Events: hook.Events, Branches: hook.Branches},
+		}))
+	}
+
+	slack := handlers.Slack{
+		Bot:        bot,
+		Recipients: cmd.hookRecipients(cfg.Hooks.Slack.Recipients),
+		Groups:     cfg.Groups(),
+		ParseMode:  cfg.Hooks.Slack.ParseMode,
+	}
+	mux.Handle("POST /hooks/slack", withScope(middleware.ScopeNotify)(slack))
+	if token := cfg.Hooks.Slack.Token; token != "" {
+		// secret webhook URL, like Slack's own ones, for the tools which can't send the API key
+		slack.Token = token
+		mux.Handle("POST /hooks/slack/{token}", hookMiddlewares(slack))
+	}
+	discord := handlers.Discord{
+		Bot:        bot,
+		Recipients: cmd.hookRecipients(cfg.Hooks.Discord.Recipients),
+		Groups:     cfg.Groups(),
+		ParseMode:  cfg.Hooks.Discord.ParseMode,
+	}
+	mux.Handle("POST /hooks/discord", withScope(middleware.ScopeNotify)(discord))
+	if token := cfg.Hooks.Discord.Token; token != "" {
+		discord.Token = token
+		mux.Handle("POST /hooks/discord/{token}", hookMiddlewares(discord))
+	}
+
+	if hook := cfg.Hooks.Ntfy; len(hook.Topics) > 0 {
+		ntfy := handlers.Ntfy{
+			Bot:            bot,
+			Groups:         cfg.Groups(),
+			Topics:         hook.TopicRecipients(),
+			SilentPriority: hook.SilentThreshold(),
+		}
+		withNtfyAuth := withScope(middleware.ScopeNotify)
+		if hook.Auth == config.HookAuthNone {
+			withNtfyAuth = hookMiddlewares
+		}
+		mux.Handle("PUT /ntfy/{topic}", withNtfyAuth(ntfy))
+		mux.Handle("POST /ntfy/{topic}", withNtfyAuth(ntfy))
+		mux.Handle("POST /ntfy", withNtfyAuth(ntfy))
+	}
+	if hook := cfg.Hooks.Gotify; len(hook.Apps) > 0 {
+		// authorized by the application tokens
+		mux.Handle("POST /gotify/message", hookMiddlewares(handlers.Gotify{
+			Bot:            bot,
+			Groups:         cfg.Groups(),
+			Apps:           hook.AppRecipients(),
+			SilentPriority: hook.SilentThreshold(),
+		}))
+	}
+
+	// custom hooks can't be served under the built-in paths, as validated in
+	// the config, the exact duplicates of the remaining routes are checked here
+	for _, hookCfg := range cfg.Hooks.Custom {
+		hook, err := handlers.NewTemplateHook(hookCfg)
+		if err != nil {
+			logger.Error("Error in the custom hooks config", slog.Any("error", err))
+			return err
+		}
+		hook.Bot = bot
+		hook.Groups = cfg.Groups()
+		if len(hook.Recipients) == 0 {
+			hook.Recipients = cmd.Recipients
+		}
+		route := hookCfg.Route()
+		if err := checkRouteIsFree(mux, route); err != nil {
+			logger.Error("Error in the custom hooks config", slog.Any("error", err), slog.String("hook", hookCfg.Name))
+			return err
+		}
+		if hookCfg.Auth == config.HookAuthApiKey || hookCfg.Auth == "" {
+			mux.Handle(route, withScope(middleware.ScopeNotify)(hook))
+		} else {
+			mux.Handle(route, hookMiddlewares(hook))
+		}
+	}
+
+	server := &http.Server{Handler: mux}
+	if cmd.TlsCert != "" {
+		// client certificate isn't required during the handshake, if there are
+		// other ways to authorize, besides the certificate one, or routes
+		// without the auth
+		clientCertOptional := hasHookRoutes || len(authenticators) > 1
+		tlsConfig, err := cmd.setupTls(ctx, logger, clientCertOptional)
+		if err != nil {
+			logger.Error("Error loading TLS certificates", slog.Any("error", err))
+			return err
+		}
+		server.TLSConfig = tlsConfig
+	}
+
+	listeners, err := cmd.listen()
+	if err != nil {
+		logger.Error("Error starting the server", slog.Any("error", err))
+		return err
+	}
+
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			var err error
+			if server.TLSConfig != nil {
+				err = server.ServeTLS(l, "", "")
+			} else {
+				err = server.Serve(l)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("Error running the server", slog.Any("error", err))
+				errCh <- err
+			}
+		}(l)
+		logger.Info("Running bot http server",
+			slog.String("address", l.Addr().String()),
+			slog.Bool("tls", server.TLSConfig != nil),
+			slog.Any("recipients", cmd.Recipients),
+		)
+	}
 
 	select {
 	case <-done:
+		// running handlers can still add messages to the digest, so they're
+		// waited for, and the digest schedule is stopped before the flush
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancelShutdown()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Error waiting for the running requests", slog.Any("error", err))
+			server.Close()
+		}
+		cancel()
+		<-digestDone
 		logger.Info("Server closed")
+		// digest is kept in memory, so it's sent before exit, ignoring the
+		// quiet hours, as deferred messages are lost on exit as well
+		if messageDigest.Len() > 0 {
+			flushCtx, cancelFlush := context.WithTimeout(quiet.WithBypass(context.Background()), digestShutdownTimeout)
+			defer cancelFlush()
+			sent, err := messageDigest.Flush(flushCtx)
+			if err != nil {
+				logger.Error("Error sending the digest", slog.Any("error", err))
+			}
+			logger.Info("Digest sent", slog.Int("messages", sent))
+		}
+		if pending := scheduledMessages.Len(); pending > 0 && cfg.ScheduledFile == "" {
+			logger.Warn("Scheduled messages are lost, as scheduled_file isn't set", slog.Int("messages", pending))
+		}
+		if quietBot != nil {
+			if deferred := quietBot.Deferred(); deferred > 0 {
+				logger.Warn("Messages, deferred by quiet hours, are lost", slog.Int("messages", deferred))
+			}
+		}
 	case err := <-errCh:
 		return err
 	}
 	return nil
 }
 
+// quietHoursRules converts the config into rules with resolved recipients.
+func quietHoursRules(quietHours []config.QuietHours, groups tgnotifier.RecipientGroups) ([]quiet.Rule, error) {
+	rules := make([]quiet.Rule, 0, len(quietHours))
+	for i, cfg := range quietHours {
+		recipients, err := groups.Resolve(cfg.Recipients)
+		if err != nil {
+			return nil, fmt.Errorf("quiet_hours[%d]: %w", i, err)
+		}
+		// already validated in the config
+		from, _ := quiet.ParseClock(cfg.From)
+		to, _ := quiet.ParseClock(cfg.To)
+		location, _ := cfg.Location()
+		rules = append(rules, quiet.Rule{
+			Recipients: recipients,
+			From:       from,
+			To:         to,
+			Location:   location,
+			Policy:     cfg.Policy,
+		})
+	}
+	return rules, nil
+}
+
+// listen returns the listeners passed by systemd socket activation or
+// creates a new one on the configured address.
+func (cmd *Serve) listen() ([]net.Listener, error) {
+	listeners, err := listener.SystemdListeners()
+	if err != nil || len(listeners) > 0 {
+		return listeners, err
+	}
+	// mode is already validated in ValidatePostMerge
+	mode, _ := listener.ParseSocketMode(cmd.SocketMode)
+	l, err := listener.Listen(cmd.Address, listener.Options{SocketMode: mode, SocketGroup: cmd.SocketGroup})
+	if err != nil {
+		return nil, err
+	}
+	return []net.Listener{l}, nil
+}
+
+// hookRecipients returns the recipients of the hook, falling back to the default ones.
+func (cmd *Serve) hookRecipients(recipients []string) []string {
+	if len(recipients) > 0 {
+		return recipients
+	}
+	return cmd.Recipients
+}
+
+// checkRouteIsFree returns an error, if the route is already registered on the mux.
+func checkRouteIsFree(mux *http.ServeMux, route string) error {
+	method, path, _ := strings.Cut(route, " ")
+	_, pattern := mux.Handler(&http.Request{Method: method, URL: &url.URL{Path: path}})
+	if pattern == route {
+		return fmt.Errorf("route %q is already in use", route)
+	}
+	return nil
+}
+
+// authenticators returns the list of enabled authentication methods, any of
+// them is sufficient to authorize a request.
+func (cmd *Serve) authenticators(apiKeys []config.ApiKey, jwtConfig config.JwtConfig) ([]middleware.Authenticator, error) {
+	var authenticators []middleware.Authenticator
+	if cmd.TlsClientCa != "" {
+		authenticators = append(authenticators, middleware.ClientCertAuthenticator(cmd.TlsClientSubjects))
+	}
+	if cmd.ApiKey != "" {
+		apiKeys = append([]config.ApiKey{{Name: "default", Key: cmd.ApiKey}}, apiKeys...)
+	}
+	if len(apiKeys) > 0 {
+		authenticator, err := middleware.ApiKeysAuthenticator(apiKeys)
+		if err != nil {
+			return nil, err
+		}
+		// the same keys can be used as secrets to sign requests instead of sending them
+		hmacAuthenticator, err := middleware.HmacAuthenticator(apiKeys, cmd.HmacWindow)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, authenticator, hmacAuthenticator)
+	}
+	if jwtConfig.Jwks != "" {
+		ttl := jwtConfig.JwksCacheTtl
+		if ttl <= 0 {
+			ttl = defaultJwksCacheTtl
+		}
+		verifier := &jwt.Verifier{
+			Keys:     jwt.NewJwksSource(jwtConfig.Jwks, ttl, &http.Client{Timeout: tgnotifier.DefaultTimeout}),
+			Issuer:   jwtConfig.Issuer,
+			Audience: jwtConfig.Audience,
+			Leeway:   jwtLeeway,
+		}
+		authenticators = append(authenticators, middleware.JwtAuthenticator(verifier, middleware.JwtClaimsMapping{
+			Recipients: jwtConfig.RecipientsClaim,
+			Scopes:     jwtConfig.ScopesClaim,
+		}))
+	}
+	return authenticators, nil
+}
+
+// setupTls loads the certificates and keeps reloading them on file change or SIGHUP.
+func (cmd *Serve) setupTls(ctx context.Context, logger *slog.Logger, clientCertOptional bool) (*tls.Config, error) {
+	reloader, err := certs.NewReloader(cmd.TlsCert, cmd.TlsKey, cmd.TlsClientCa)
+	if err != nil {
+		return nil, err
+	}
+	logReload := func(err error) {
+		if err != nil {
+			logger.Error("Error reloading TLS certificates", slog.Any("error", err))
+			return
+		}
+		logger.Info("TLS certificates reloaded")
+	}
+	go reloader.Watch(ctx, certsWatchInterval, logReload)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				logReload(reloader.Reload())
+			}
+		}
+	}()
+
+	return reloader.TLSConfig(clientCertOptional), nil
+}
+
 func setupLogger(logType string, logLevel string) *slog.Logger {
 	var logger *slog.Logger
 	var programLevel = new(slog.LevelVar)
@@ -88,3 +88,62 @@ func TestServe_parsePriorityFlagEnvConfig(t *testing.T) {
 	assert.Equal(t, test.MockConfig.ApiKey, cmd.ApiKey)
 	assert.Equal(t, test.MockConfig.Address, cmd.Address)
 }
+
+func TestServe_parseTlsFlags(t *testing.T) {
+	var cmd cmd.Serve
+	p := newCliParserWithConfig(t, &cmd, test.MockConfig)
+	_, err := p.Parse([]string{
+		"--tls-cert", "cert.pem",
+		"--tls-key", "key.pem",
+		"--tls-client-ca", "ca.pem",
+		"--tls-client-subjects", "backup,CN=ci",
+	})
+	if err != nil {
+		t.Fatalf("error parsing args: %v", err)
+	}
+	assert.Equal(t, "cert.pem", cmd.TlsCert)
+	assert.Equal(t, "key.pem", cmd.TlsKey)
+	assert.Equal(t, "ca.pem", cmd.TlsClientCa)
+	assert.Equal(t, []string{"backup", "CN=ci"}, cmd.TlsClientSubjects)
+}
+
+func TestServe_validateTls(t *testing.T) {
+	cases := []struct {
+		name    string
+		cert    string
+		key     string
+		ca      string
+		wantErr bool
+	}{
+		{"no tls", "", "", "", false},
+		{"cert and key", "cert.pem", "key.pem", "", false},
+		{"mutual tls", "cert.pem", "key.pem", "ca.pem", false},
+		{"cert without key", "cert.pem", "", "", true},
+		{"key without cert", "", "key.pem", "", true},
+		{"client ca without cert", "", "", "ca.pem", true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := cmd.Serve{LogType: "text", TlsCert: tt.cert, TlsKey: tt.key, TlsClientCa: tt.ca}
+			cmd.BotToken = "test-token"
+			err := cmd.ValidatePostMerge()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestServe_parseSocketFlags(t *testing.T) {
+	var cmd cmd.Serve
+	p := newCliParserWithConfig(t, &cmd, test.MockConfig)
+	_, err := p.Parse([]string{"--socket-mode", "0660", "--socket-group", "tgnotifier", "unix:/run/tgnotifier.sock"})
+	if err != nil {
+		t.Fatalf("error parsing args: %v", err)
+	}
+	assert.Equal(t, "unix:/run/tgnotifier.sock", cmd.Address)
+	assert.Equal(t, "0660", cmd.SocketMode)
+	assert.Equal(t, "tgnotifier", cmd.SocketGroup)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+type Sign struct {
+	Config  string `short:"c" help:"Configuration file path ($BOT_CONFIG_PATH)"`
+	Key     string `short:"k" help:"API key to sign the body with (defaults to api_key value from config or $BOT_API_KEY)"`
+	KeyName string `help:"Name of the key from api_keys config to sign the body with"`
+	Method  string `default:"POST" help:"HTTP method of the request"`
+	Path    string `default:"/" help:"Request path with the query, e.g. '/notify/ops?silent=1'"`
+	Curl    bool   `help:"Output headers as curl arguments"`
+	Body    string `arg:"" optional:"" help:"Request body to sign. Read from STDIN if not specified"`
+}
+
+func (cmd *Sign) Run() error {
+	key, err := cmd.signingKey()
+	if err != nil {
+		return err
+	}
+
+	body := []byte(cmd.Body)
+	if cmd.Body == "" {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read from stdin: %w", err)
+		}
+		body = input
+	}
+
+	timestamp := time.Now().Unix()
+	signature := middleware.SignRequest(key, timestamp, strings.ToUpper(cmd.Method), cmd.Path, body)
+	if cmd.Curl {
+		fmt.Printf("-H %s:%d -H %s:%s\n", middleware.TimestampHeader, timestamp, middleware.SignatureHeader, signature)
+		return nil
+	}
+	fmt.Printf("%s: %d\n", middleware.TimestampHeader, timestamp)
+	fmt.Printf("%s: %s\n", middleware.SignatureHeader, signature)
+	return nil
+}
+
+// signingKey returns the key from the flag, the named key from the config or
+// the config's api_key.
+func (cmd *Sign) signingKey() (string, error) {
+	if cmd.Key != "" && cmd.KeyName != "" {
+		return "", errors.New("--key and --key-name can't be used together")
+	}
+	if cmd.Key != "" {
+		return cmd.Key, nil
+	}
+	cfg, err := config.Load(cmd.Config)
+	if err != nil {
+		return "", err
+	}
+	if cmd.KeyName != "" {
+		for _, key := range cfg.ApiKeys {
+			if key.Name != cmd.KeyName {
+				continue
+			}
+			if key.Key == "" {
+				return "", fmt.Errorf("api key %q has no plain text value, hashed keys can't be used for signing", key.Name)
+			}
+			return key.Key, nil
+		}
+		return "", fmt.Errorf("api key %q is not found in api_keys config", cmd.KeyName)
+	}
+	if cfg.ApiKey == "" {
+		return "", errors.New("api key must be provided through the CLI, config or environment variable")
+	}
+	return cfg.ApiKey, nil
+}
@@ -0,0 +1,38 @@
+package cmd_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/cmd"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign_KeyName(t *testing.T) {
+	cfgName := writeTestConfig(t, `
+bot_token: "123:abc"
+api_key: default-key
+api_keys:
+  - name: ci
+    key: ci-key
+  - name: hashed
+    key_hash: "sha256:2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"
+`)
+	sign := &cmd.Sign{Config: cfgName, KeyName: "ci", Method: "delete", Path: "/scheduled/1", Body: "-"}
+	out := captureStdout(t, sign.Run)
+
+	timestamp := strings.TrimSpace(strings.TrimPrefix(strings.Split(out, "\n")[0], middleware.TimestampHeader+":"))
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Now().Unix(), ts, 5)
+	assert.Contains(t, out, middleware.SignatureHeader+": "+middleware.SignRequest("ci-key", ts, "DELETE", "/scheduled/1", []byte("-")))
+
+	for _, name := range []string{"hashed", "unknown"} {
+		sign := &cmd.Sign{Config: cfgName, KeyName: name, Method: "POST", Path: "/", Body: "-"}
+		assert.Error(t, sign.Run())
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/http/models"
+)
+
+// ServiceClientArgs are the arguments of the commands, calling the running
+// service's HTTP API.
+type ServiceClientArgs struct {
+	Config string `short:"c" help:"Configuration file path ($BOT_CONFIG_PATH)"`
+	Url    string `help:"Running service URL (defaults to the configured address)"`
+	ApiKey string `short:"k" help:"API key to authorize the request (defaults to api_key value from config or $BOT_API_KEY)"`
+}
+
+// call sends the request to the running service, decoding JSON response into
+// the result, which must include [models.ResponsePayload] fields.
+func (args ServiceClientArgs) call(method string, path string, body any, result any) error {
+	cfg, err := config.Load(args.Config)
+	if err != nil {
+		return err
+	}
+	if args.ApiKey == "" {
+		args.ApiKey = cfg.ApiKey
+	}
+	client, baseUrl := serviceClient(args.Url, cfg)
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, baseUrl+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("error creating the request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if args.ApiKey != "" {
+		req.Header.Set("x-api-key", args.ApiKey)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling the service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading the service response: %w", err)
+	}
+	var status models.ResponsePayload
+	if err := json.Unmarshal(data, &status); err != nil {
+		return fmt.Errorf("unexpected service response, status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		if status.Error == "" {
+			status.Error = http.StatusText(resp.StatusCode)
+		}
+		return fmt.Errorf("service responded with status %d: %s", resp.StatusCode, status.Error)
+	}
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("error decoding the service response: %w", err)
+		}
+	}
+	if !status.Success {
+		return errors.New(status.Error)
+	}
+	return nil
+}
+
+// serviceClient returns the HTTP client and the base URL of the service, from
+// the explicit URL or the configured address, which can be a unix socket.
+func serviceClient(serviceUrl string, cfg config.Config) (*http.Client, string) {
+	client := &http.Client{Timeout: tgnotifier.DefaultTimeout}
+	if serviceUrl != "" {
+		return client, strings.TrimSuffix(serviceUrl, "/")
+	}
+	scheme := "http"
+	if cfg.TlsCert != "" {
+		scheme = "https"
+	}
+	if socketPath, ok := strings.CutPrefix(cfg.Address, "unix:"); ok {
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		}
+		return client, scheme + "://localhost"
+	}
+	address := cfg.Address
+	if strings.HasPrefix(address, ":") {
+		address = "localhost" + address
+	}
+	return client, scheme + "://" + address
+}
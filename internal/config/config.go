@@ -1,10 +1,20 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
+	"gopkg.in/yaml.v3"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/cron"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/quiet"
 )
 
 const configPathEnvKey = "BOT_CONFIG_PATH"
@@ -17,9 +27,521 @@ type Config struct {
 	// your bot token as given by botfather
 	BotToken   string   `yaml:"bot_token" env:"BOT_TOKEN"`
 	Recipients []string `yaml:"recipients" env:"BOT_RECIPIENTS"`
-	Address    string   `yaml:"address" env:"BOT_ADDR" env-default:"localhost:6000"`
+	// named groups of recipients or aliases, usable in place of chat ids
+	RecipientGroups map[string]StringList `yaml:"recipient_groups"`
+	// named channels, served at "POST /notify/{channel}"
+	Channels map[string]Channel `yaml:"channels"`
+	// disables "GET /send" endpoints, taking the message from the query string
+	DisableGetSend bool `yaml:"disable_get_send" env:"BOT_DISABLE_GET_SEND"`
+	// maximum number of messages in a "POST /batch" request
+	BatchMaxItems int `yaml:"batch_max_items" env:"BOT_BATCH_MAX_ITEMS" env-default:"100"`
+	// how long results of the requests with idempotency keys are kept
+	IdempotencyTtl time.Duration `yaml:"idempotency_ttl" env:"BOT_IDEMPOTENCY_TTL" env-default:"24h"`
+	// digest of the messages with "digest" priority
+	Digest DigestConfig `yaml:"digest"`
+	// quiet hours of the recipients, when messages are sent silently or deferred
+	QuietHours []QuietHours `yaml:"quiet_hours"`
+	// file to persist the scheduled messages in; they're kept in memory only if empty
+	ScheduledFile string `yaml:"scheduled_file" env:"BOT_SCHEDULED_FILE"`
+	// maximum number of pending scheduled messages
+	ScheduledMaxPending int `yaml:"scheduled_max_pending" env:"BOT_SCHEDULED_MAX_PENDING" env-default:"1000"`
+	// recurring messages, sent by cron expressions
+	Schedules []Schedule `yaml:"schedules"`
+	// named periodic jobs, pinging "/heartbeat/{name}"; late ones are alerted
+	Heartbeats map[string]Heartbeat `yaml:"heartbeats"`
+	// file to persist the heartbeats state in; it's kept in memory only if empty
+	HeartbeatsFile string `yaml:"heartbeats_file" env:"BOT_HEARTBEATS_FILE"`
+	// webhook receivers of the third party services, served at "/hooks/"
+	Hooks HooksConfig `yaml:"hooks"`
+	// TCP address or a unix domain socket path, prefixed with "unix:"
+	Address string `yaml:"address" env:"BOT_ADDR" env-default:"localhost:6000"`
+	// unix socket file mode, in octal e.g. "0660"
+	SocketMode string `yaml:"socket_mode" env:"BOT_SOCKET_MODE"`
+	// unix socket group name or id
+	SocketGroup string `yaml:"socket_group" env:"BOT_SOCKET_GROUP"`
 	// API key, passed in 'x-api-key' to authorize requests to the app
 	ApiKey string `yaml:"api_key" env:"BOT_API_KEY"`
+	// maximum allowed clock difference for HMAC signed requests
+	HmacWindow time.Duration `yaml:"hmac_window" env:"BOT_HMAC_WINDOW" env-default:"5m"`
+	// named API keys with optional restrictions, alternative to a single ApiKey
+	ApiKeys []ApiKey `yaml:"api_keys"`
+	// requests rate limits per client IP and per API key
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	// networks (IPs or CIDRs) allowed to access the service; any if empty
+	AllowedNetworks []string `yaml:"allowed_networks" env:"BOT_ALLOWED_NETWORKS"`
+	// proxies (IPs or CIDRs), allowed to pass the client address in Forwarded or X-Forwarded-For headers
+	TrustedProxies []string `yaml:"trusted_proxies" env:"BOT_TRUSTED_PROXIES"`
+	// JWT bearer tokens authentication
+	Jwt JwtConfig `yaml:"jwt"`
+	// TLS certificate and key files; if set, server is serving HTTPS
+	TlsCert string `yaml:"tls_cert" env:"BOT_TLS_CERT"`
+	TlsKey  string `yaml:"tls_key" env:"BOT_TLS_KEY"`
+	// CA bundle to verify client certificates against (mutual TLS)
+	TlsClientCa string `yaml:"tls_client_ca" env:"BOT_TLS_CLIENT_CA"`
+	// client certificate subjects (CN or full DN), allowed to access the API
+	TlsClientSubjects []string `yaml:"tls_client_subjects" env:"BOT_TLS_CLIENT_SUBJECTS"`
+}
+
+// StringList is a list of strings, which can be written in YAML as a single
+// scalar value as well.
+type StringList []string
+
+func (l *StringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = StringList{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// Groups returns recipient groups in the form accepted by the library.
+func (c Config) Groups() tgnotifier.RecipientGroups {
+	groups := make(tgnotifier.RecipientGroups, len(c.RecipientGroups))
+	for name, members := range c.RecipientGroups {
+		groups[name] = members
+	}
+	return groups
+}
+
+// ApiKey is a named API key, optionally restricted in what it can access.
+type ApiKey struct {
+	// key name, shown in logs
+	Name string `yaml:"name"`
+	// plain text key value
+	Key string `yaml:"key,omitempty"`
+	// key hash in the form of "sha256:<hex>", alternative to the plain text key
+	KeyHash string `yaml:"key_hash,omitempty"`
+	// allowed endpoint scopes: "notify", "healthcheck", "admin"; all if empty
+	Scopes []string `yaml:"scopes,omitempty"`
+	// allowed recipients; any if empty
+	Recipients []string `yaml:"recipients,omitempty"`
+	// key expiration time; key never expires if not set
+	ExpiresAt time.Time `yaml:"expires_at,omitempty"`
+	// rate limit override for this key
+	RateLimit *RateLimit `yaml:"rate_limit,omitempty"`
+}
+
+// Channel is a named notification route with its own defaults.
+type Channel struct {
+	// default recipients or recipient groups of the channel
+	Recipients StringList `yaml:"recipients,omitempty"`
+	// default parse mode of the channel messages
+	ParseMode string `yaml:"parse_mode,omitempty"`
+	// send messages without sound notification
+	Silent bool `yaml:"silent,omitempty"`
+	// text prepended to every message
+	Prefix string `yaml:"prefix,omitempty"`
+	// go text/template of the message, with .Message and .Channel fields
+	Template string `yaml:"template,omitempty"`
+	// names of API keys allowed to use the channel; any if empty
+	ApiKeys []string `yaml:"api_keys,omitempty"`
+	// window, in which repeats of the same message to the same recipient are
+	// suppressed; disabled if zero
+	Dedup time.Duration `yaml:"dedup,omitempty"`
+	// default priority of the messages: "normal", "digest" or "critical"
+	Priority string `yaml:"priority,omitempty"`
+}
+
+type DigestConfig struct {
+	// cron expression or "@every <duration>", when the digest is sent
+	Schedule string `yaml:"schedule" env:"BOT_DIGEST_SCHEDULE" env-default:"@hourly"`
+}
+
+// QuietHours is a daily period of the recipients, when messages are sent
+// without sound or deferred until its end. Critical messages ignore it.
+type QuietHours struct {
+	// recipients or recipient groups
+	Recipients StringList `yaml:"recipients"`
+	// start and end of the period in "HH:MM" format; it can span midnight
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	// IANA time zone name, e.g. "Europe/Berlin"; local one if empty
+	Timezone string `yaml:"timezone,omitempty"`
+	// "silent" (default) or "defer"
+	Policy string `yaml:"policy,omitempty"`
+}
+
+// Location returns the time zone of the quiet hours.
+func (q QuietHours) Location() (*time.Location, error) {
+	return loadLocation(q.Timezone)
+}
+
+// loadLocation loads IANA time zone, the local one if the name is empty.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+func (q QuietHours) Validate() error {
+	if len(q.Recipients) == 0 {
+		return errors.New("recipients must not be empty")
+	}
+	from, err := quiet.ParseClock(q.From)
+	if err != nil {
+		return fmt.Errorf("from: %w", err)
+	}
+	to, err := quiet.ParseClock(q.To)
+	if err != nil {
+		return fmt.Errorf("to: %w", err)
+	}
+	if from == to {
+		return errors.New("from and to must be different")
+	}
+	if _, err := q.Location(); err != nil {
+		return fmt.Errorf("timezone: %w", err)
+	}
+	if !quiet.IsValidPolicy(q.Policy) {
+		return fmt.Errorf("invalid policy %q, expected %q or %q", q.Policy, quiet.PolicySilent, quiet.PolicyDefer)
+	}
+	return nil
+}
+
+// Schedule is a recurring message, sent by a cron expression.
+type Schedule struct {
+	// schedule name, shown in logs
+	Name string `yaml:"name"`
+	// cron expression or "@every <duration>"
+	Cron string `yaml:"cron"`
+	// IANA time zone name of the cron expression, e.g. "Europe/Berlin"; local one if empty
+	Timezone string `yaml:"timezone,omitempty"`
+	// go text/template of the message, with .Name and .Time fields
+	Template string `yaml:"template"`
+	// parse mode of the message, used by the template's escape function as well
+	ParseMode string `yaml:"parse_mode,omitempty"`
+	// send messages without sound notification
+	Silent bool `yaml:"silent,omitempty"`
+	// recipients or groups, default recipients if empty
+	Recipients StringList `yaml:"recipients,omitempty"`
+}
+
+// Location returns the time zone of the cron expression.
+func (s Schedule) Location() (*time.Location, error) {
+	return loadLocation(s.Timezone)
+}
+
+func (s Schedule) Validate() error {
+	if s.Name == "" {
+		return errors.New("schedule name must not be empty")
+	}
+	if _, err := cron.Parse(s.Cron); err != nil {
+		return fmt.Errorf("schedule %q: cron: %w", s.Name, err)
+	}
+	if _, err := s.Location(); err != nil {
+		return fmt.Errorf("schedule %q: timezone: %w", s.Name, err)
+	}
+	if s.ParseMode != "" && !tgnotifier.IsValidParseMode(s.ParseMode) {
+		return fmt.Errorf("schedule %q: %w: %s", s.Name, tgnotifier.ErrParseModeInvalid, s.ParseMode)
+	}
+	if s.Template == "" {
+		return fmt.Errorf("schedule %q: template must not be empty", s.Name)
+	}
+	if _, err := format.ParseTemplate(s.Name, s.Template, s.ParseMode); err != nil {
+		return fmt.Errorf("schedule %q: error parsing template: %w", s.Name, err)
+	}
+	return nil
+}
+
+// Heartbeat is a periodic job, which pings the service on success.
+type Heartbeat struct {
+	// expected period between the pings
+	Period time.Duration `yaml:"period"`
+	// time after the period, before the job is late, and the maximum duration
+	// of its started run; 1h if zero
+	Grace time.Duration `yaml:"grace,omitempty"`
+	// recipients or groups of the alerts, default recipients if empty
+	Recipients StringList `yaml:"recipients,omitempty"`
+}
+
+func (h Heartbeat) Validate() error {
+	if h.Period <= 0 {
+		return fmt.Errorf("period must be positive, got %s", h.Period)
+	}
+	if h.Grace < 0 {
+		return fmt.Errorf("grace must not be negative, got %s", h.Grace)
+	}
+	return nil
+}
+
+type HooksConfig struct {
+	Alertmanager AlertsHook `yaml:"alertmanager"`
+	Grafana      AlertsHook `yaml:"grafana"`
+	GitHub       GitHook    `yaml:"github"`
+	GitLab       GitHook    `yaml:"gitlab"`
+	Slack        ChatHook   `yaml:"slack"`
+	Discord      ChatHook   `yaml:"discord"`
+	Ntfy         NtfyHook   `yaml:"ntfy"`
+	Gotify       GotifyHook `yaml:"gotify"`
+	// hooks with the messages, rendered from incoming JSON by templates
+	Custom []TemplateHook `yaml:"custom"`
+}
+
+// GitHook configures GitHub or GitLab webhook receiver; it's enabled if
+// Secret is set.
+type GitHook struct {
+	// webhook secret (GitHub) or secret token (GitLab)
+	Secret string `yaml:"secret,omitempty"`
+	// recipients of the events, default ones if empty
+	Recipients StringList `yaml:"recipients,omitempty"`
+	// event types to notify about; all supported if empty
+	Events []string `yaml:"events,omitempty"`
+	// branch names or glob patterns to notify about; any if empty
+	Branches []string `yaml:"branches,omitempty"`
+}
+
+// ChatHook configures a receiver of a chat service compatible webhook, for
+// the tools which can only notify that service.
+type ChatHook struct {
+	// if set, the hook is served at "/hooks/<service>/<token>" path without
+	// the API key auth, for the tools which only accept a webhook URL
+	Token string `yaml:"token,omitempty"`
+	// parse mode the markup is converted into
+	ParseMode string `yaml:"parse_mode" env-default:"HTML"`
+	// recipients of the messages, default ones if empty
+	Recipients StringList `yaml:"recipients,omitempty"`
+}
+
+// Validate checks the hook's parse mode and token
+func (h ChatHook) Validate() error {
+	// markup can't be converted into the legacy Markdown
+	if h.ParseMode != tgnotifier.ParseModeHTML && h.ParseMode != tgnotifier.ParseModeMD {
+		return fmt.Errorf("%w: %s, only HTML and MarkdownV2 are supported", tgnotifier.ErrParseModeInvalid, h.ParseMode)
+	}
+	if strings.ContainsAny(h.Token, "/?# \t") {
+		return errors.New("token can't contain '/', '?', '#' or spaces")
+	}
+	return nil
+}
+
+// NtfyHook configures ntfy compatible publishing API, served at
+// "/ntfy/<topic>"; it's enabled if there are topics.
+type NtfyHook struct {
+	// topics mapped to their recipients or groups
+	Topics map[string]StringList `yaml:"topics,omitempty"`
+	// authentication method: "api_key" (default) or "none", if topic names are secret
+	Auth string `yaml:"auth,omitempty"`
+	// messages with this or lower priority (1-5) are sent without sound
+	// notification, 2 (low) by default
+	SilentPriority *int `yaml:"silent_priority,omitempty"`
+}
+
+// SilentThreshold returns the configured or default silent priority
+func (h NtfyHook) SilentThreshold() int {
+	if h.SilentPriority == nil {
+		return 2
+	}
+	return *h.SilentPriority
+}
+
+// TopicRecipients returns recipients of the topics
+func (h NtfyHook) TopicRecipients() map[string][]string {
+	return stringLists(h.Topics)
+}
+
+// Validate checks the topics and auth method
+func (h NtfyHook) Validate() error {
+	for topic, recipients := range h.Topics {
+		if topic == "" || strings.ContainsAny(topic, "/?# \t") {
+			return fmt.Errorf("invalid topic name %q", topic)
+		}
+		if len(recipients) == 0 {
+			return fmt.Errorf("topic %q has no recipients", topic)
+		}
+	}
+	switch h.Auth {
+	case "", HookAuthApiKey, HookAuthNone:
+	default:
+		return fmt.Errorf("unknown auth method %q", h.Auth)
+	}
+	return nil
+}
+
+// GotifyHook configures Gotify compatible "/gotify/message" endpoint; it's
+// enabled if there are apps.
+type GotifyHook struct {
+	// application tokens mapped to their recipients or groups
+	Apps map[string]StringList `yaml:"apps,omitempty"`
+	// messages with this or lower priority (0-10) are sent without sound
+	// notification, 3 by default, as Gotify clients don't make sound for them
+	SilentPriority *int `yaml:"silent_priority,omitempty"`
+}
+
+// SilentThreshold returns the configured or default silent priority
+func (h GotifyHook) SilentThreshold() int {
+	if h.SilentPriority == nil {
+		return 3
+	}
+	return *h.SilentPriority
+}
+
+// AppRecipients returns recipients of the application tokens
+func (h GotifyHook) AppRecipients() map[string][]string {
+	return stringLists(h.Apps)
+}
+
+// Validate checks the application tokens
+func (h GotifyHook) Validate() error {
+	for token, recipients := range h.Apps {
+		if token == "" {
+			return errors.New("application token must not be empty")
+		}
+		if len(recipients) == 0 {
+			return errors.New("application has no recipients")
+		}
+	}
+	return nil
+}
+
+func stringLists(lists map[string]StringList) map[string][]string {
+	result := make(map[string][]string, len(lists))
+	for key, list := range lists {
+		result[key] = list
+	}
+	return result
+}
+
+// AlertsHook configures alerts webhook receiver (Alertmanager or Grafana).
+type AlertsHook struct {
+	// alert label with comma separated recipients or groups of the alert
+	RecipientsLabel string `yaml:"recipients_label" env-default:"tg_recipients"`
+}
+
+// Authentication methods of the templated hooks
+const (
+	// regular authentication, like for other endpoints
+	HookAuthApiKey = "api_key"
+	// Token passed in the "X-Hook-Token" header or "token" query parameter
+	HookAuthToken = "token"
+	// no authentication
+	HookAuthNone = "none"
+)
+
+// TemplateHook is a webhook endpoint, rendering incoming JSON into a message
+// with go text/template.
+type TemplateHook struct {
+	// hook name, shown in logs
+	Name string `yaml:"name"`
+	// endpoint path, e.g. "/hooks/uptime-kuma"
+	Path string `yaml:"path"`
+	// http method, "POST" by default
+	Method string `yaml:"method,omitempty"`
+	// authentication method: "api_key" (default), "token" or "none"
+	Auth string `yaml:"auth,omitempty"`
+	// token for the "token" auth method
+	Token string `yaml:"token,omitempty"`
+	// go text/template of the message, executed with the request JSON
+	Template string `yaml:"template"`
+	// parse mode of the message, used by the template's escape function as well
+	ParseMode string `yaml:"parse_mode,omitempty"`
+	// send messages without sound notification
+	Silent bool `yaml:"silent,omitempty"`
+	// recipients or groups, default recipients if empty
+	Recipients StringList `yaml:"recipients,omitempty"`
+}
+
+// Route returns the hook's mux pattern, e.g. "POST /hooks/uptime-kuma"
+func (h TemplateHook) Route() string {
+	method := h.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	return method + " " + h.Path
+}
+
+// reservedHookPaths are the paths of the built-in endpoints; custom hooks
+// can't be served at them or under them, as the mux would route requests to
+// the most specific pattern, which can be the custom one.
+var reservedHookPaths = []string{
+	"/notify",
+	"/send",
+	"/scheduled",
+	"/heartbeat",
+	"/digest",
+	"/batch",
+	"/ntfy",
+	"/gotify",
+	"/hooks/alertmanager",
+	"/hooks/grafana",
+	"/hooks/github",
+	"/hooks/gitlab",
+	"/hooks/slack",
+	"/hooks/discord",
+}
+
+// Validate checks the hook's config and parses its template.
+func (h TemplateHook) Validate() error {
+	if h.Name == "" {
+		return errors.New("hook name must not be empty")
+	}
+	if !strings.HasPrefix(h.Path, "/") || strings.ContainsAny(h.Path, "{} \t") {
+		return fmt.Errorf("hook %q: path must start with '/' and can't contain wildcards or spaces", h.Name)
+	}
+	for _, reserved := range reservedHookPaths {
+		if h.Path == reserved || strings.HasPrefix(h.Path, reserved+"/") {
+			return fmt.Errorf("hook %q: path %s is reserved for the built-in endpoints", h.Name, reserved)
+		}
+	}
+	switch h.Method {
+	case "", http.MethodPost, http.MethodPut, http.MethodGet:
+	default:
+		return fmt.Errorf("hook %q: unsupported method %q", h.Name, h.Method)
+	}
+	switch h.Auth {
+	case "", HookAuthApiKey, HookAuthNone:
+	case HookAuthToken:
+		if h.Token == "" {
+			return fmt.Errorf("hook %q: token must be set for the token auth", h.Name)
+		}
+	default:
+		return fmt.Errorf("hook %q: unknown auth method %q", h.Name, h.Auth)
+	}
+	if h.ParseMode != "" && !tgnotifier.IsValidParseMode(h.ParseMode) {
+		return fmt.Errorf("hook %q: %w: %s", h.Name, tgnotifier.ErrParseModeInvalid, h.ParseMode)
+	}
+	if h.Template == "" {
+		return fmt.Errorf("hook %q: template must not be empty", h.Name)
+	}
+	if _, err := format.ParseTemplate(h.Name, h.Template, h.ParseMode); err != nil {
+		return fmt.Errorf("hook %q: error parsing template: %w", h.Name, err)
+	}
+	return nil
+}
+
+// RateLimit allows Requests per the Per period, with bursts up to Burst
+// requests (defaults to Requests). Zero Requests value disables the limit.
+type RateLimit struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst,omitempty"`
+}
+
+type RateLimitConfig struct {
+	// limit per client IP address, applied before authentication
+	PerIp RateLimit `yaml:"per_ip"`
+	// default limit per API key or other authenticated caller
+	PerKey RateLimit `yaml:"per_key"`
+}
+
+// JwtConfig configures authentication with JWT bearer tokens; it's enabled if Jwks is set.
+type JwtConfig struct {
+	// JWKS file path or http(s) URL
+	Jwks string `yaml:"jwks,omitempty"`
+	// how long the fetched JWKS is cached
+	JwksCacheTtl time.Duration `yaml:"jwks_cache_ttl,omitempty"`
+	// expected "iss" claim, not checked if empty
+	Issuer string `yaml:"issuer,omitempty"`
+	// expected "aud" claim, not checked if empty
+	Audience string `yaml:"audience,omitempty"`
+	// claim with the list of recipients the token is allowed to send to
+	RecipientsClaim string `yaml:"recipients_claim,omitempty"`
+	// claim with the list of scopes the token is allowed to access
+	ScopesClaim string `yaml:"scopes_claim,omitempty"`
 }
 
 func Load(configPath string) (Config, error) {
@@ -64,9 +586,79 @@ func Load(configPath string) (Config, error) {
 	} else if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
 		return cfg, fmt.Errorf("error loading configuration file: %w", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid configuration: %w", err)
+	}
 	return cfg, nil
 }
 
+// Validate checks the parts of the config, which can't be checked by their
+// types alone.
+func (c Config) Validate() error {
+	if err := c.Groups().Validate(); err != nil {
+		return fmt.Errorf("recipient_groups: %w", err)
+	}
+	if c.BatchMaxItems < 1 {
+		return fmt.Errorf("batch_max_items must be positive, got %d", c.BatchMaxItems)
+	}
+	if c.ScheduledMaxPending < 1 {
+		return fmt.Errorf("scheduled_max_pending must be positive, got %d", c.ScheduledMaxPending)
+	}
+	if c.IdempotencyTtl <= 0 {
+		return fmt.Errorf("idempotency_ttl must be positive, got %s", c.IdempotencyTtl)
+	}
+	if _, err := cron.Parse(c.Digest.Schedule); err != nil {
+		return fmt.Errorf("digest: schedule: %w", err)
+	}
+	for i, quietHours := range c.QuietHours {
+		if err := quietHours.Validate(); err != nil {
+			return fmt.Errorf("quiet_hours[%d]: %w", i, err)
+		}
+	}
+	names := make(map[string]bool, len(c.Schedules))
+	for _, schedule := range c.Schedules {
+		if err := schedule.Validate(); err != nil {
+			return fmt.Errorf("schedules: %w", err)
+		}
+		if names[schedule.Name] {
+			return fmt.Errorf("schedules: duplicate schedule name %q", schedule.Name)
+		}
+		names[schedule.Name] = true
+	}
+	for name, heartbeat := range c.Heartbeats {
+		if name == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("heartbeats: invalid heartbeat name %q", name)
+		}
+		if err := heartbeat.Validate(); err != nil {
+			return fmt.Errorf("heartbeats: %s: %w", name, err)
+		}
+	}
+	if err := c.Hooks.Slack.Validate(); err != nil {
+		return fmt.Errorf("hooks: slack: %w", err)
+	}
+	if err := c.Hooks.Discord.Validate(); err != nil {
+		return fmt.Errorf("hooks: discord: %w", err)
+	}
+	if err := c.Hooks.Ntfy.Validate(); err != nil {
+		return fmt.Errorf("hooks: ntfy: %w", err)
+	}
+	if err := c.Hooks.Gotify.Validate(); err != nil {
+		return fmt.Errorf("hooks: gotify: %w", err)
+	}
+	paths := make(map[string]bool, len(c.Hooks.Custom))
+	for _, hook := range c.Hooks.Custom {
+		if err := hook.Validate(); err != nil {
+			return fmt.Errorf("hooks: %w", err)
+		}
+		route := hook.Route()
+		if paths[route] {
+			return fmt.Errorf("hooks: duplicate hook route %q", route)
+		}
+		paths[route] = true
+	}
+	return nil
+}
+
 func formatTriedPaths(paths []string) string {
 	if len(paths) == 0 {
 		return "none"
@@ -1,8 +1,12 @@
 package config_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/religiosa1/tgnotifier"
 	"github.com/religiosa1/tgnotifier/internal/config"
 	"github.com/religiosa1/tgnotifier/internal/test"
 	"github.com/stretchr/testify/assert"
@@ -60,6 +64,16 @@ func TestLoad_MissingImplicitFile(t *testing.T) {
 	assert.Empty(t, cfg.Recipients)
 	assert.Equal(t, "localhost:6000", cfg.Address)
 	assert.Equal(t, "", cfg.ApiKey)
+	assert.Equal(t, 5*time.Minute, cfg.HmacWindow)
+	assert.False(t, cfg.DisableGetSend)
+	assert.Equal(t, 100, cfg.BatchMaxItems)
+	assert.Equal(t, 24*time.Hour, cfg.IdempotencyTtl)
+	assert.Equal(t, 1000, cfg.ScheduledMaxPending)
+	assert.Equal(t, "@hourly", cfg.Digest.Schedule)
+	assert.Equal(t, "tg_recipients", cfg.Hooks.Alertmanager.RecipientsLabel)
+	assert.Equal(t, "tg_recipients", cfg.Hooks.Grafana.RecipientsLabel)
+	assert.Equal(t, "HTML", cfg.Hooks.Slack.ParseMode)
+	assert.Equal(t, "HTML", cfg.Hooks.Discord.ParseMode)
 }
 
 func TestLoad_EnvOverridesConfig(t *testing.T) {
@@ -72,3 +86,251 @@ func TestLoad_EnvOverridesConfig(t *testing.T) {
 	assert.Equal(t, test.MockConfig.Recipients, cfg.Recipients) // keeps value where not overridden
 	assert.Equal(t, "powerman:5000", cfg.Address)               // overrides from env
 }
+
+func TestLoad_ApiKeys(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	cfg := test.MockConfig
+	cfg.ApiKeys = []config.ApiKey{
+		{Name: "ci", Key: "ci-key", Scopes: []string{"notify"}, Recipients: []string{"123"}, ExpiresAt: expiresAt},
+		{Name: "monitoring", KeyHash: "sha256:abcd"},
+	}
+	cfgName := test.CreateConfigFile(t, cfg)
+
+	loaded, err := config.Load(cfgName)
+	require.NoError(t, err)
+
+	assert.Equal(t, cfg.ApiKeys, loaded.ApiKeys)
+}
+
+func TestLoad_RecipientGroups(t *testing.T) {
+	cfgName := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(cfgName, []byte(`
+recipient_groups:
+  alice: 1234
+  ops: [alice, "5678"]
+`), 0o600)
+	require.NoError(t, err)
+
+	cfg, err := config.Load(cfgName)
+	require.NoError(t, err)
+
+	assert.Equal(t, config.StringList{"1234"}, cfg.RecipientGroups["alice"])
+	assert.Equal(t, config.StringList{"alice", "5678"}, cfg.RecipientGroups["ops"])
+	resolved, err := cfg.Groups().Resolve([]string{"ops"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1234", "5678"}, resolved)
+}
+
+func TestLoad_RecipientGroupsCycle(t *testing.T) {
+	cfgName := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(cfgName, []byte(`
+recipient_groups:
+  ops: dev
+  dev: ops
+`), 0o600)
+	require.NoError(t, err)
+
+	_, err = config.Load(cfgName)
+	assert.ErrorIs(t, err, tgnotifier.ErrRecipientGroupCycle)
+}
+
+func TestLoad_CustomHooks(t *testing.T) {
+	cfgName := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(cfgName, []byte(`
+hooks:
+  custom:
+    - name: uptime
+      path: /hooks/uptime
+      auth: token
+      token: secret
+      parse_mode: HTML
+      template: "{{ .monitor.name | escape }} is {{ .status }}"
+      recipients: ops
+`), 0o600)
+	require.NoError(t, err)
+
+	cfg, err := config.Load(cfgName)
+	require.NoError(t, err)
+	require.Len(t, cfg.Hooks.Custom, 1)
+	hook := cfg.Hooks.Custom[0]
+	assert.Equal(t, "POST /hooks/uptime", hook.Route())
+	assert.Equal(t, config.StringList{"ops"}, hook.Recipients)
+}
+
+func TestTemplateHook_Validate(t *testing.T) {
+	valid := config.TemplateHook{Name: "test", Path: "/hooks/test", Template: "{{ .text }}"}
+	require.NoError(t, valid.Validate())
+
+	cases := []struct {
+		name   string
+		modify func(h *config.TemplateHook)
+	}{
+		{"no name", func(h *config.TemplateHook) { h.Name = "" }},
+		{"relative path", func(h *config.TemplateHook) { h.Path = "hooks/test" }},
+		{"wildcard path", func(h *config.TemplateHook) { h.Path = "/hooks/{name}" }},
+		{"channel path", func(h *config.TemplateHook) { h.Path = "/notify/ops" }},
+		{"scheduled path", func(h *config.TemplateHook) { h.Path = "/scheduled/x" }},
+		{"batch path", func(h *config.TemplateHook) { h.Path = "/batch" }},
+		{"built-in hook path", func(h *config.TemplateHook) { h.Path = "/hooks/slack/token" }},
+		{"unsupported method", func(h *config.TemplateHook) { h.Method = "DELETE" }},
+		{"unknown auth", func(h *config.TemplateHook) { h.Auth = "basic" }},
+		{"token auth without token", func(h *config.TemplateHook) { h.Auth = config.HookAuthToken }},
+		{"invalid parse mode", func(h *config.TemplateHook) { h.ParseMode = "XML" }},
+		{"empty template", func(h *config.TemplateHook) { h.Template = "" }},
+		{"invalid template", func(h *config.TemplateHook) { h.Template = "{{ .text | nonexistent }}" }},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := valid
+			tt.modify(&hook)
+			assert.Error(t, hook.Validate())
+		})
+	}
+}
+
+func TestLoad_CustomHooksDuplicateRoute(t *testing.T) {
+	cfgName := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(cfgName, []byte(`
+hooks:
+  custom:
+    - name: first
+      path: /hooks/test
+      template: "{{ .text }}"
+    - name: second
+      path: /hooks/test
+      method: POST
+      template: "{{ .message }}"
+`), 0o600)
+	require.NoError(t, err)
+
+	_, err = config.Load(cfgName)
+	assert.ErrorContains(t, err, "POST /hooks/test")
+}
+
+func TestLoad_InvalidDigestSchedule(t *testing.T) {
+	cfgName := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(cfgName, []byte(`
+digest:
+  schedule: "0 25 * * *"
+`), 0o600)
+	require.NoError(t, err)
+
+	_, err = config.Load(cfgName)
+	assert.ErrorContains(t, err, "digest: schedule")
+}
+
+func TestLoad_NtfyAndGotify(t *testing.T) {
+	cfgName := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(cfgName, []byte(`
+hooks:
+  ntfy:
+    topics:
+      backups: ops
+      alerts: [ops, "123"]
+  gotify:
+    silent_priority: 0
+    apps:
+      AppToken: ops
+`), 0o600)
+	require.NoError(t, err)
+
+	cfg, err := config.Load(cfgName)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"backups": {"ops"}, "alerts": {"ops", "123"}}, cfg.Hooks.Ntfy.TopicRecipients())
+	assert.Equal(t, 2, cfg.Hooks.Ntfy.SilentThreshold())
+	assert.Equal(t, map[string][]string{"AppToken": {"ops"}}, cfg.Hooks.Gotify.AppRecipients())
+	// explicit zero isn't replaced with the default
+	assert.Equal(t, 0, cfg.Hooks.Gotify.SilentThreshold())
+}
+
+func TestNtfyHook_Validate(t *testing.T) {
+	assert.Error(t, config.NtfyHook{Topics: map[string]config.StringList{"a/b": {"ops"}}}.Validate())
+	assert.Error(t, config.NtfyHook{Topics: map[string]config.StringList{"backups": {}}}.Validate())
+	assert.Error(t, config.NtfyHook{Auth: config.HookAuthToken}.Validate())
+	assert.NoError(t, config.NtfyHook{Topics: map[string]config.StringList{"backups": {"ops"}}, Auth: config.HookAuthNone}.Validate())
+}
+
+func TestQuietHours_Validate(t *testing.T) {
+	valid := config.QuietHours{Recipients: config.StringList{"ops"}, From: "22:00", To: "08:00", Timezone: "Europe/Berlin", Policy: "defer"}
+	assert.NoError(t, valid.Validate())
+
+	cases := []struct {
+		name   string
+		modify func(q *config.QuietHours)
+	}{
+		{"no recipients", func(q *config.QuietHours) { q.Recipients = nil }},
+		{"from", func(q *config.QuietHours) { q.From = "25:00" }},
+		{"to", func(q *config.QuietHours) { q.To = "8" }},
+		{"empty period", func(q *config.QuietHours) { q.To = q.From }},
+		{"timezone", func(q *config.QuietHours) { q.Timezone = "Mars/Olympus" }},
+		{"policy", func(q *config.QuietHours) { q.Policy = "drop" }},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q := valid
+			tt.modify(&q)
+			assert.Error(t, q.Validate())
+		})
+	}
+}
+
+func TestSchedule_Validate(t *testing.T) {
+	valid := config.Schedule{Name: "standup", Cron: "55 9 * * mon-fri", Timezone: "Europe/Berlin", Template: "Stand-up at {{ .Time.Format \"15:04\" }}"}
+	require.NoError(t, valid.Validate())
+
+	cases := []struct {
+		name   string
+		modify func(s *config.Schedule)
+	}{
+		{"no name", func(s *config.Schedule) { s.Name = "" }},
+		{"invalid cron", func(s *config.Schedule) { s.Cron = "* * *" }},
+		{"timezone", func(s *config.Schedule) { s.Timezone = "Mars/Olympus" }},
+		{"invalid parse mode", func(s *config.Schedule) { s.ParseMode = "XML" }},
+		{"empty template", func(s *config.Schedule) { s.Template = "" }},
+		{"invalid template", func(s *config.Schedule) { s.Template = "{{ .Name | nonexistent }}" }},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := valid
+			tt.modify(&schedule)
+			assert.Error(t, schedule.Validate())
+		})
+	}
+}
+
+func TestLoad_SchedulesDuplicateName(t *testing.T) {
+	cfgName := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(cfgName, []byte(`
+schedules:
+  - name: standup
+    cron: "55 9 * * *"
+    template: Stand-up in 5 minutes
+  - name: standup
+    cron: "@weekly"
+    template: On-call handover
+`), 0o600)
+	require.NoError(t, err)
+
+	_, err = config.Load(cfgName)
+	assert.ErrorContains(t, err, `duplicate schedule name "standup"`)
+}
+
+func TestHeartbeat_Validate(t *testing.T) {
+	assert.NoError(t, config.Heartbeat{Period: time.Hour}.Validate())
+	assert.NoError(t, config.Heartbeat{Period: time.Hour, Grace: 10 * time.Minute}.Validate())
+	assert.Error(t, config.Heartbeat{}.Validate())
+	assert.Error(t, config.Heartbeat{Period: time.Hour, Grace: -time.Minute}.Validate())
+}
+
+func TestLoad_InvalidHeartbeatName(t *testing.T) {
+	cfgName := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(cfgName, []byte(`
+heartbeats:
+  backup/daily:
+    period: 24h
+`), 0o600)
+	require.NoError(t, err)
+
+	_, err = config.Load(cfgName)
+	assert.ErrorContains(t, err, `invalid heartbeat name "backup/daily"`)
+}
@@ -0,0 +1,184 @@
+// Package cron parses cron expressions and calculates their next run times.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the next run time after the given one, in its location.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+var predefined = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a standard cron expression of 5 fields (minute, hour, day of
+// month, month, day of week), a predefined one like "@daily", or a fixed
+// interval, like "@every 15m".
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if interval, ok := strings.CutPrefix(expr, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %w", err)
+		}
+		if d < time.Second {
+			return nil, errors.New("interval must be at least 1s")
+		}
+		return Every(d), nil
+	}
+	if spec, ok := predefined[strings.ToLower(expr)]; ok {
+		expr = spec
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in cron expression %q, got %d", expr, len(fields))
+	}
+	var s spec
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	// 7 is Sunday as well as 0
+	if s.dow.has(7) {
+		s.dow |= 1
+	}
+	s.anyDom = fields[2] == "*" || strings.HasPrefix(fields[2], "*/")
+	s.anyDow = fields[4] == "*" || strings.HasPrefix(fields[4], "*/")
+	return s, nil
+}
+
+// Every is a fixed interval schedule.
+type Every time.Duration
+
+func (e Every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// bits is a set of the allowed values of a field
+type bits uint64
+
+func (b bits) has(value int) bool {
+	return b&(1<<value) != 0
+}
+
+type spec struct {
+	minute, hour, dom, month, dow bits
+	// if both days are restricted, either of them matches, as in cron
+	anyDom, anyDow bool
+}
+
+// maximum period, the next run time is searched in
+const searchYears = 5
+
+// Next returns the next matching minute after t, or zero time if there's none,
+// e.g. for February 30.
+func (s spec) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(searchYears, 0, 0)
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		switch {
+		case !s.month.has(int(month)):
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+		case !s.hour.has(t.Hour()):
+			t = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, loc)
+		case !s.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s spec) dayMatches(t time.Time) bool {
+	dom, dow := s.dom.has(t.Day()), s.dow.has(int(t.Weekday()))
+	if s.anyDom || s.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+var monthNames = []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseField parses comma separated values, ranges and steps of a field
+func parseField(field string, low int, high int, names []string) (bits, error) {
+	var result bits
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+		from, to := low, high
+		if rangePart != "*" {
+			fromPart, toPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if from, err = parseValue(fromPart, low, high, names); err != nil {
+				return 0, err
+			}
+			to = from
+			if isRange {
+				if to, err = parseValue(toPart, low, high, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// "a/n" is from a to the maximum with the step n
+				to = high
+			}
+			if to < from {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		}
+		for value := from; value <= to; value += step {
+			result |= 1 << value
+		}
+	}
+	return result, nil
+}
+
+func parseValue(value string, low int, high int, names []string) (int, error) {
+	for i, name := range names {
+		if name != "" && strings.EqualFold(value, name) {
+			return i, nil
+		}
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < low || number > high {
+		return 0, fmt.Errorf("invalid value %q, expected %d-%d", value, low, high)
+	}
+	return number, nil
+}
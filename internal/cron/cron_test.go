@@ -0,0 +1,65 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/cron"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Next(t *testing.T) {
+	// Wednesday
+	from := time.Date(2025, 1, 1, 10, 30, 15, 0, time.UTC)
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2025, 1, 2, 10, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2025, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * SAT,7", time.Date(2025, 1, 4, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 feb *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		// either of the days matches, if both are restricted
+		{"0 0 15 * fri", time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2025, 1, 1, 12, 0, 15, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := cron.Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Next(from))
+		})
+	}
+}
+
+func TestParse_Timezone(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	schedule, err := cron.Parse("0 9 * * *")
+	require.NoError(t, err)
+
+	next := schedule.Next(time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC).In(loc))
+	assert.Equal(t, time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC), next.UTC())
+
+	// the day of DST switch
+	next = schedule.Next(time.Date(2025, 3, 30, 0, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2025, 3, 30, 7, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "0 0 * * foo", "@every 0s", "@every soon", "@sometimes"} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := cron.Parse(expr)
+			assert.Error(t, err)
+		})
+	}
+}
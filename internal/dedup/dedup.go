@@ -0,0 +1,63 @@
+// Package dedup suppresses repeated messages within a time window.
+package dedup
+
+import (
+	"sync"
+	"time"
+)
+
+// Suppressor counts the repeats of the keys within the Window, starting from
+// their first occurrence.
+type Suppressor struct {
+	Window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*window
+}
+
+type window struct {
+	repeats int
+	timer   *time.Timer
+}
+
+func NewSuppressor(duration time.Duration) *Suppressor {
+	return &Suppressor{Window: duration, windows: make(map[string]*window)}
+}
+
+// Allow reports whether the key is seen for the first time in the window.
+// If so, onClose is called at the window close, if the key was repeated in it,
+// with the number of repeats; otherwise the repeat is counted.
+func (s *Suppressor) Allow(key string, onClose func(repeats int)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.windows[key]; ok {
+		w.repeats++
+		return false
+	}
+	w := &window{}
+	s.windows[key] = w
+	w.timer = time.AfterFunc(s.Window, func() {
+		s.mu.Lock()
+		// the key can be forgotten and seen again meanwhile
+		if s.windows[key] == w {
+			delete(s.windows, key)
+		}
+		repeats := w.repeats
+		s.mu.Unlock()
+		if repeats > 0 {
+			onClose(repeats)
+		}
+	})
+	return true
+}
+
+// Forget closes the key's window without calling its onClose, e.g. if the
+// allowed message failed to be sent, so its retry isn't suppressed.
+func (s *Suppressor) Forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.windows[key]; ok {
+		w.timer.Stop()
+		delete(s.windows, key)
+	}
+}
@@ -0,0 +1,39 @@
+package dedup_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/dedup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuppressor(t *testing.T) {
+	suppressor := dedup.NewSuppressor(50 * time.Millisecond)
+	var closed atomic.Int64
+	onClose := func(repeats int) { closed.Store(int64(repeats)) }
+
+	assert.True(t, suppressor.Allow("a", onClose))
+	assert.False(t, suppressor.Allow("a", onClose))
+	assert.False(t, suppressor.Allow("a", onClose))
+	assert.True(t, suppressor.Allow("b", func(int) { t.Error("no repeats of b") }))
+
+	assert.Eventually(t, func() bool { return closed.Load() == 2 }, time.Second, 10*time.Millisecond)
+	// window is closed, the key is new again
+	assert.True(t, suppressor.Allow("a", func(int) {}))
+}
+
+func TestSuppressor_Forget(t *testing.T) {
+	suppressor := dedup.NewSuppressor(50 * time.Millisecond)
+	onClose := func(int) { t.Error("forgotten window isn't closed") }
+
+	assert.True(t, suppressor.Allow("a", onClose))
+	assert.False(t, suppressor.Allow("a", onClose))
+	suppressor.Forget("a")
+	assert.True(t, suppressor.Allow("a", func(int) {}))
+	// unknown keys are ignored
+	suppressor.Forget("b")
+
+	time.Sleep(100 * time.Millisecond)
+}
@@ -0,0 +1,226 @@
+// Package digest accumulates messages, to send them combined by a schedule.
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/cron"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/quiet"
+)
+
+// maxChars is Telegram's limit of the message length in characters; with
+// markup included, it's a safe bound for the combined messages.
+const maxChars = 4096
+
+// separator between the combined messages
+const separator = "\n\n"
+
+// Digest keeps the pending messages per recipient and parse mode, as only
+// the messages with the same parse mode can be combined.
+type Digest struct {
+	Bot tgnotifier.BotInterface
+
+	mu      sync.Mutex
+	pending map[key][]string
+}
+
+type key struct {
+	recipient string
+	parseMode tgnotifier.ParseMode
+}
+
+func New(bot tgnotifier.BotInterface) *Digest {
+	return &Digest{Bot: bot, pending: make(map[key][]string)}
+}
+
+// Add adds the message to the digests of the recipients, which must be
+// resolved chat ids. The message is validated the same way, as it's done on
+// send, so the errors are reported to the caller; its length must leave room
+// for the digest title.
+func (d *Digest) Add(text string, parseMode tgnotifier.ParseMode, recipients []string) error {
+	if len(text) == 0 {
+		return tgnotifier.ErrMessageEmpty
+	}
+	// the message must fit into a digest part with the title
+	if len(text) > tgnotifier.MaxMsgLen-titleLen-len(separator) ||
+		utf8.RuneCountInString(text) > maxChars-titleLen-len(separator) {
+		return tgnotifier.ErrMessageTooLong
+	}
+	if parseMode != "" && !tgnotifier.IsValidParseMode(parseMode) {
+		return tgnotifier.ErrParseModeInvalid
+	}
+	if len(recipients) == 0 {
+		return tgnotifier.ErrRecipientsEmpty
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, recipient := range recipients {
+		k := key{recipient, parseMode}
+		d.pending[k] = append(d.pending[k], text)
+	}
+	return nil
+}
+
+// Len returns the number of pending messages, counted per recipient.
+func (d *Digest) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	count := 0
+	for _, messages := range d.pending {
+		count += len(messages)
+	}
+	return count
+}
+
+// Flush sends the pending messages combined, split into several messages if
+// they're too long, returning the number of sent ones, counted per recipient.
+// Messages, failed to be sent due to network errors or Telegram's rate limit
+// and server errors, are kept for the next flush; the rest are dropped.
+func (d *Digest) Flush(ctx context.Context) (int, error) {
+	d.mu.Lock()
+	pending := d.pending
+	d.pending = make(map[key][]string)
+	d.mu.Unlock()
+
+	keys := make([]key, 0, len(pending))
+	for k := range pending {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].recipient != keys[j].recipient {
+			return keys[i].recipient < keys[j].recipient
+		}
+		return keys[i].parseMode < keys[j].parseMode
+	})
+
+	sent := 0
+	var errs []error
+	for _, k := range keys {
+		messages := pending[k]
+		unsent, err := d.send(ctx, k, messages)
+		sent += len(messages) - len(unsent)
+		if err != nil {
+			if retryable(err) {
+				d.requeue(k, unsent)
+				errs = append(errs, fmt.Errorf("recipient %s: %w", k.recipient, err))
+			} else {
+				errs = append(errs, fmt.Errorf("recipient %s: %d messages dropped: %w", k.recipient, len(unsent), err))
+			}
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// Run flushes the digest by the schedule, until the context is done.
+func (d *Digest) Run(ctx context.Context, schedule cron.Schedule, logger *slog.Logger) {
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			logger.Warn("Digest schedule has no next run time")
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if d.Len() == 0 {
+			continue
+		}
+		sent, err := d.Flush(ctx)
+		if err != nil {
+			logger.Error("Error sending the digest", slog.Any("error", err))
+		}
+		logger.Info("Digest sent", slog.Int("messages", sent))
+	}
+}
+
+// send sends the messages in one or several parts, returning the unsent ones
+// on error.
+func (d *Digest) send(ctx context.Context, k key, messages []string) ([]string, error) {
+	parts := split(messages)
+	unsent := messages
+	for i, part := range parts {
+		title := fmt.Sprintf("Digest of %d messages", len(messages))
+		if len(messages) == 1 {
+			title = "Digest of 1 message"
+		}
+		if len(parts) > 1 {
+			title += fmt.Sprintf(" (%d/%d)", i+1, len(parts))
+		}
+		text := format.Bold(k.parseMode, title) + separator + strings.Join(part, separator)
+		err := d.Bot.SendMessageWithContext(ctx, text, k.parseMode, []string{k.recipient})
+		if err != nil && !errors.Is(err, quiet.ErrDeferred) {
+			return unsent, err
+		}
+		unsent = unsent[len(part):]
+	}
+	return nil, nil
+}
+
+// retryable reports if the send can succeed on the next flush; Telegram API
+// errors, besides the rate limit and server errors, and validation errors
+// won't go away by themselves.
+func retryable(err error) bool {
+	var apiError tgnotifier.TgApiError
+	if errors.As(err, &apiError) {
+		return apiError.TgCode == http.StatusTooManyRequests || apiError.TgCode >= http.StatusInternalServerError
+	}
+	for _, validationErr := range []error{
+		tgnotifier.ErrMessageEmpty,
+		tgnotifier.ErrMessageTooLong,
+		tgnotifier.ErrParseModeInvalid,
+		tgnotifier.ErrRecipientsEmpty,
+	} {
+		if errors.Is(err, validationErr) {
+			return false
+		}
+	}
+	return true
+}
+
+// requeue returns the messages to the beginning of the pending ones
+func (d *Digest) requeue(k key, messages []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending[k] = slices.Concat(messages, d.pending[k])
+}
+
+// reserved for the digest title
+const titleLen = 64
+
+// split groups the messages into parts, fitting into a single Telegram message
+func split(messages []string) [][]string {
+	var parts [][]string
+	var part []string
+	chars, bytes := titleLen, titleLen
+	for _, message := range messages {
+		messageChars := utf8.RuneCountInString(message) + len(separator)
+		messageBytes := len(message) + len(separator)
+		if len(part) > 0 && (chars+messageChars > maxChars || bytes+messageBytes > tgnotifier.MaxMsgLen) {
+			parts = append(parts, part)
+			part, chars, bytes = nil, titleLen, titleLen
+		}
+		part = append(part, message)
+		chars += messageChars
+		bytes += messageBytes
+	}
+	if len(part) > 0 {
+		parts = append(parts, part)
+	}
+	return parts
+}
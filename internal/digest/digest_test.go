@@ -0,0 +1,128 @@
+package digest_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentMessage struct {
+	text      string
+	parseMode string
+	recipient string
+}
+
+// mockBot fails the calls after the failAfter successful ones, if it's set
+type mockBot struct {
+	tgnotifier.BotInterface
+	sent      []sentMessage
+	err       error
+	failAfter int
+}
+
+func (b *mockBot) SendMessageWithContext(ctx context.Context, message string, parseMode tgnotifier.ParseMode, recipients []string) error {
+	if b.err != nil && len(b.sent) >= b.failAfter {
+		return b.err
+	}
+	b.sent = append(b.sent, sentMessage{message, parseMode, recipients[0]})
+	return nil
+}
+
+func TestDigest_Flush(t *testing.T) {
+	bot := &mockBot{}
+	d := digest.New(bot)
+
+	require.NoError(t, d.Add("one", "HTML", []string{"a", "b"}))
+	require.NoError(t, d.Add("two", "HTML", []string{"a"}))
+	require.NoError(t, d.Add("three", "MarkdownV2", []string{"a"}))
+	assert.Equal(t, 4, d.Len())
+
+	sent, err := d.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, sent)
+	assert.Equal(t, []sentMessage{
+		{"<b>Digest of 2 messages</b>\n\none\n\ntwo", "HTML", "a"},
+		{"*Digest of 1 message*\n\nthree", "MarkdownV2", "a"},
+		{"<b>Digest of 1 message</b>\n\none", "HTML", "b"},
+	}, bot.sent)
+	assert.Equal(t, 0, d.Len())
+}
+
+func TestDigest_Split(t *testing.T) {
+	bot := &mockBot{}
+	d := digest.New(bot)
+
+	message := strings.Repeat("x", 1500)
+	for range 5 {
+		require.NoError(t, d.Add(message, "", []string{"a"}))
+	}
+	sent, err := d.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, sent)
+	require.Len(t, bot.sent, 3)
+	assert.True(t, strings.HasPrefix(bot.sent[0].text, "Digest of 5 messages (1/3)\n\n"))
+	for _, msg := range bot.sent {
+		assert.LessOrEqual(t, len(msg.text), 4096)
+	}
+}
+
+func TestDigest_FlushErrors(t *testing.T) {
+	message := strings.Repeat("x", 3000)
+
+	// network errors keep the unsent messages
+	bot := &mockBot{err: errors.New("timeout"), failAfter: 1}
+	d := digest.New(bot)
+	for range 3 {
+		require.NoError(t, d.Add(message, "", []string{"a"}))
+	}
+	sent, err := d.Flush(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, 2, d.Len())
+
+	// so do the rate limit and server errors on the second part
+	for _, code := range []int{429, 502} {
+		bot.err = tgnotifier.TgApiError{TgCode: code, Method: "sendMessage", Description: "retry later"}
+		bot.failAfter = len(bot.sent) + 1
+		require.NoError(t, d.Add(message, "", []string{"a"}))
+		sent, err = d.Flush(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, 1, sent)
+		assert.Equal(t, 2, d.Len())
+	}
+
+	// other API errors drop them
+	bot.err = tgnotifier.TgApiError{TgCode: 400, Method: "sendMessage", Description: "chat not found"}
+	bot.failAfter = len(bot.sent)
+	_, err = d.Flush(context.Background())
+	assert.ErrorContains(t, err, "2 messages dropped")
+	assert.Equal(t, 0, d.Len())
+}
+
+func TestDigest_ValidationErrorIsNotRequeued(t *testing.T) {
+	bot := &mockBot{err: tgnotifier.ErrMessageTooLong}
+	d := digest.New(bot)
+	require.NoError(t, d.Add("text", "", []string{"a"}))
+
+	_, err := d.Flush(context.Background())
+	assert.ErrorIs(t, err, tgnotifier.ErrMessageTooLong)
+	assert.Equal(t, 0, d.Len())
+}
+
+func TestDigest_AddInvalid(t *testing.T) {
+	d := digest.New(&mockBot{})
+	assert.ErrorIs(t, d.Add("", "", []string{"a"}), tgnotifier.ErrMessageEmpty)
+	assert.ErrorIs(t, d.Add(strings.Repeat("x", tgnotifier.MaxMsgLen+1), "", []string{"a"}), tgnotifier.ErrMessageTooLong)
+	// no room for the title
+	assert.ErrorIs(t, d.Add(strings.Repeat("x", 4090), "", []string{"a"}), tgnotifier.ErrMessageTooLong)
+	assert.ErrorIs(t, d.Add(strings.Repeat("я", 4090), "", []string{"a"}), tgnotifier.ErrMessageTooLong)
+	assert.ErrorIs(t, d.Add("text", "markdown", []string{"a"}), tgnotifier.ErrParseModeInvalid)
+	assert.ErrorIs(t, d.Add("text", "", nil), tgnotifier.ErrRecipientsEmpty)
+	assert.Equal(t, 0, d.Len())
+}
@@ -0,0 +1,136 @@
+package format
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+var discordMarkdown = dialect{
+	delimiters: []delimiter{
+		{"**", styleBold},
+		{"__", styleUnderline},
+		{"~~", styleStrike},
+		{"||", styleSpoiler},
+		{"*", styleItalic},
+		{"_", styleItalic},
+	},
+	entity:   discordEntity,
+	unescape: func(text string) string { return text },
+	quote: func(line string) (string, bool) {
+		if line == ">" {
+			return "", true
+		}
+		return strings.CutPrefix(line, "> ")
+	},
+	heading: func(line string) (string, bool) {
+		for _, marker := range []string{"# ", "## ", "### "} {
+			if inner, ok := strings.CutPrefix(line, marker); ok {
+				return inner, true
+			}
+		}
+		return "", false
+	},
+	fenceLang: true,
+}
+
+// Discord converts Discord markdown into the given parse mode: bold, italic,
+// underline, strikethrough, spoilers, code, links, mentions, headings,
+// quotes and lists. Markup is dropped for messages without parse mode.
+//
+// See: https://support.discord.com/hc/en-us/articles/210298617
+func Discord(text string, parseMode string) string {
+	return discordMarkdown.convert(parseMode, discordQuotes(text))
+}
+
+// discordQuotes replaces ">>> " multiline quote, which lasts until the end of
+// the text, with the single line quotes.
+func discordQuotes(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		inner, ok := strings.CutPrefix(line, ">>> ")
+		if !ok {
+			continue
+		}
+		lines[i] = "> " + inner
+		for j := i + 1; j < len(lines); j++ {
+			lines[j] = "> " + lines[j]
+		}
+		break
+	}
+	return strings.Join(lines, "\n")
+}
+
+// discordEntity renders backslash escapes, [text](url) and <url> links,
+// mentions, custom emojis and timestamps.
+func discordEntity(w markupWriter, d dialect, text string) (string, int) {
+	switch text[0] {
+	case '\\':
+		if len(text) > 1 && isMarkdownPunct(text[1]) {
+			return w.Text(text[1:2]), 2
+		}
+	case '[':
+		closeText := strings.Index(text, "](")
+		if closeText < 1 || strings.Contains(text[:closeText], "\n") {
+			return "", 0
+		}
+		urlStart := closeText + 2
+		urlEnd := closingParen(text[urlStart:])
+		if urlEnd < 1 {
+			return "", 0
+		}
+		url := strings.Trim(text[urlStart:urlStart+urlEnd], "<>")
+		return w.Link(url, d.inline(w, text[1:closeText])), urlStart + urlEnd + 1
+	case '<':
+		end := strings.IndexAny(text, "> \n")
+		if end < 2 || text[end] != '>' {
+			return "", 0
+		}
+		inner := text[1:end]
+		switch {
+		case strings.HasPrefix(inner, "http://") || strings.HasPrefix(inner, "https://"):
+			return w.Link(inner, w.Text(inner)), end + 1
+		case strings.HasPrefix(inner, "@&"):
+			return w.Text("@role"), end + 1
+		case strings.HasPrefix(inner, "@"):
+			return w.Text("@" + strings.TrimPrefix(inner[1:], "!")), end + 1
+		case strings.HasPrefix(inner, "#"):
+			return w.Text(inner), end + 1
+		case strings.HasPrefix(inner, ":") || strings.HasPrefix(inner, "a:"):
+			// custom emoji <:name:id>, animated ones are prefixed with "a"
+			if parts := strings.Split(inner, ":"); len(parts) == 3 {
+				return w.Text(":" + parts[1] + ":"), end + 1
+			}
+		case strings.HasPrefix(inner, "t:"):
+			seconds, _, _ := strings.Cut(inner[2:], ":")
+			if ts, err := strconv.ParseInt(seconds, 10, 64); err == nil {
+				return w.Text(time.Unix(ts, 0).UTC().Format("2006-01-02 15:04 UTC")), end + 1
+			}
+		}
+	}
+	return "", 0
+}
+
+// closingParen returns the index of the closing parenthesis, skipping the
+// balanced ones inside, or -1 if there's none before spaces.
+func closingParen(text string) int {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case ' ', '\n':
+			return -1
+		}
+	}
+	return -1
+}
+
+func isMarkdownPunct(b byte) bool {
+	return strings.IndexByte("\\*_~`|>#-[]()<:", b) >= 0
+}
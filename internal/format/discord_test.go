@@ -0,0 +1,72 @@
+package format_test
+
+import (
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscord(t *testing.T) {
+	cases := []struct {
+		name     string
+		text     string
+		html     string
+		markdown string
+	}{
+		{
+			"styles",
+			"**bold** *italic* _italic_ __underline__ ~~strike~~ ||spoiler|| `a*b`",
+			"<b>bold</b> <i>italic</i> <i>italic</i> <u>underline</u> <s>strike</s> <tg-spoiler>spoiler</tg-spoiler> <code>a*b</code>",
+			"*bold* _italic_ _italic_ __underline__ ~strike~ ||spoiler|| `a*b`",
+		},
+		{
+			"nested styles",
+			"***bold italic*** **bold __underline__**",
+			"<b><i>bold italic</i></b> <b>bold <u>underline</u></b>",
+			"*_bold italic_* *bold __underline__*",
+		},
+		{
+			"escapes",
+			`\*not italic\* 2*3*4 snake_case`,
+			"*not italic* 2*3*4 snake_case",
+			`\*not italic\* 2\*3\*4 snake\_case`,
+		},
+		{
+			"links",
+			"[**docs**](https://example.com/a_(b)) <https://example.com>",
+			`<a href="https://example.com/a_(b)"><b>docs</b></a> <a href="https://example.com">https://example.com</a>`,
+			`[*docs*](https://example.com/a_(b\)) [https://example\.com](https://example.com)`,
+		},
+		{
+			"mentions",
+			"<@123> <@!456> <@&789> <#42> <:party:1234> <t:1700000000:R>",
+			"@123 @456 @role #42 :party: 2023-11-14 22:13 UTC",
+			`@123 @456 @role \#42 :party: 2023\-11\-14 22:13 UTC`,
+		},
+		{
+			"headings and lists",
+			"# Title <1>\n- one\n* two",
+			"<b>Title &lt;1&gt;</b>\n• one\n• two",
+			"*Title <1\\>*\n• one\n• two",
+		},
+		{
+			"code block with language",
+			"```go\nif a < b {}\n```",
+			`<pre><code class="language-go">if a &lt; b {}</code></pre>`,
+			"```go\nif a < b {}\n```",
+		},
+		{
+			"quotes",
+			"> one\ntext\n>>> multi\nline",
+			"<blockquote>one</blockquote>\ntext\n<blockquote>multi\nline</blockquote>",
+			">one\ntext\n>multi\n>line",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.html, format.Discord(tt.text, "HTML"))
+			assert.Equal(t, tt.markdown, format.Discord(tt.text, "MarkdownV2"))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package format contains helpers for rendering text into Telegram messages.
+package format
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Html escapes the text to be used in HTML parse mode messages.
+//
+// See: https://core.telegram.org/bots/api#html-style
+func Html(text string) string {
+	return html.EscapeString(text)
+}
+
+var markdownReplacer = func() *strings.Replacer {
+	const special = "\\_*[]()~`>#+-=|{}.!"
+	pairs := make([]string, 0, 2*len(special))
+	for _, c := range special {
+		pairs = append(pairs, string(c), "\\"+string(c))
+	}
+	return strings.NewReplacer(pairs...)
+}()
+
+// Markdown escapes the text to be used in MarkdownV2 parse mode messages.
+//
+// See: https://core.telegram.org/bots/api#markdownv2-style
+func Markdown(text string) string {
+	return markdownReplacer.Replace(text)
+}
+
+// Link returns HTML link with the escaped text, or just the escaped text if
+// url is empty.
+func Link(url string, text string) string {
+	if url == "" {
+		return Html(text)
+	}
+	return `<a href="` + Html(url) + `">` + Html(text) + `</a>`
+}
+
+// Truncate shortens the text to maxLen bytes, without breaking UTF-8
+// characters, ending it with an ellipsis if it was shortened.
+func Truncate(text string, maxLen int) string {
+	if len(text) <= maxLen {
+		return text
+	}
+	const ellipsis = "…"
+	cut := maxLen - len(ellipsis)
+	if cut < 0 {
+		return ""
+	}
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + ellipsis
+}
+
+// TruncateRunes shortens the text to maxChars characters, ending it with an
+// ellipsis if it was shortened.
+func TruncateRunes(text string, maxChars int) string {
+	if utf8.RuneCountInString(text) <= maxChars {
+		return text
+	}
+	if maxChars < 1 {
+		return ""
+	}
+	runes := []rune(text)
+	return string(runes[:maxChars-1]) + "…"
+}
+
+// Pairs renders a map as sorted "key=value" pairs, joined with sep.
+func Pairs(values map[string]string, sep string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+values[key])
+	}
+	return strings.Join(pairs, sep)
+}
+
+// Duration formats the duration rounded to minutes or seconds, unless it's
+// shorter than a second, without zero minutes and seconds, e.g. "1h" or "5m30s".
+func Duration(d time.Duration) string {
+	if d >= time.Hour {
+		d = d.Round(time.Minute)
+	} else if d >= time.Second {
+		d = d.Round(time.Second)
+	}
+	result := d.String()
+	if strings.HasSuffix(result, "m0s") {
+		result = strings.TrimSuffix(result, "0s")
+	}
+	if strings.HasSuffix(result, "h0m") {
+		result = strings.TrimSuffix(result, "0m")
+	}
+	return result
+}
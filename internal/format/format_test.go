@@ -0,0 +1,50 @@
+package format_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHtml(t *testing.T) {
+	assert.Equal(t, "&lt;b&gt;a &amp; b&lt;/b&gt;", format.Html("<b>a & b</b>"))
+}
+
+func TestMarkdown(t *testing.T) {
+	assert.Equal(t, `1\+1\=2\. \*bold\* \[link\]\(url\) C:\\dir`, format.Markdown(`1+1=2. *bold* [link](url) C:\dir`))
+}
+
+func TestLink(t *testing.T) {
+	assert.Equal(t, `<a href="http://x/?a=1&amp;b=2">a &lt; b</a>`, format.Link("http://x/?a=1&b=2", "a < b"))
+	assert.Equal(t, `a &lt; b`, format.Link("", "a < b"))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "hello", format.Truncate("hello", 5))
+	assert.Equal(t, "he…", format.Truncate("hello world", 5))
+	// doesn't cut the multibyte characters in half
+	assert.Equal(t, "при…", format.Truncate("привет", 10))
+	assert.Equal(t, "", format.Truncate("hello", 1))
+}
+
+func TestTruncateRunes(t *testing.T) {
+	assert.Equal(t, "привет", format.TruncateRunes("привет", 6))
+	assert.Equal(t, "при…", format.TruncateRunes("привет", 4))
+	assert.Equal(t, "", format.TruncateRunes("hello", 0))
+}
+
+func TestPairs(t *testing.T) {
+	assert.Equal(t, "a=1, b=2", format.Pairs(map[string]string{"b": "2", "a": "1"}, ", "))
+	assert.Equal(t, "", format.Pairs(nil, ", "))
+}
+
+func TestDuration(t *testing.T) {
+	assert.Equal(t, "1h", format.Duration(time.Hour))
+	assert.Equal(t, "1h30m", format.Duration(90*time.Minute+10*time.Second))
+	assert.Equal(t, "5m", format.Duration(5*time.Minute))
+	assert.Equal(t, "5m30s", format.Duration(5*time.Minute+30*time.Second+100*time.Millisecond))
+	assert.Equal(t, "45s", format.Duration(45*time.Second))
+	assert.Equal(t, "50ms", format.Duration(50*time.Millisecond))
+}
@@ -0,0 +1,296 @@
+package format
+
+import (
+	"strings"
+)
+
+// style of the inline markup
+type style int
+
+const (
+	styleBold style = iota
+	styleItalic
+	styleStrike
+	styleUnderline
+	styleSpoiler
+)
+
+// markupWriter renders the parsed markup in the target parse mode. Inner
+// values passed to it are already rendered, others are raw text.
+type markupWriter interface {
+	Text(text string) string
+	Styled(st style, inner string) string
+	Code(text string) string
+	Pre(text string, lang string) string
+	Link(url string, inner string) string
+	Quote(inner string) string
+}
+
+func newMarkupWriter(parseMode string) markupWriter {
+	switch parseMode {
+	case "HTML":
+		return htmlWriter{}
+	case "MarkdownV2":
+		return markdownWriter{}
+	default:
+		return plainWriter{}
+	}
+}
+
+// Bold returns the text in bold, escaped for the parse mode.
+func Bold(parseMode string, text string) string {
+	w := newMarkupWriter(parseMode)
+	return w.Styled(styleBold, w.Text(text))
+}
+
+// Anchor returns a link with the text, escaped for the parse mode, or just the
+// escaped text if url is empty.
+func Anchor(parseMode string, url string, text string) string {
+	w := newMarkupWriter(parseMode)
+	if url == "" {
+		return w.Text(text)
+	}
+	return w.Link(url, w.Text(text))
+}
+
+type htmlWriter struct{}
+
+var htmlTags = map[style]string{
+	styleBold:      "b",
+	styleItalic:    "i",
+	styleStrike:    "s",
+	styleUnderline: "u",
+	styleSpoiler:   "tg-spoiler",
+}
+
+func (htmlWriter) Text(text string) string { return Html(text) }
+func (htmlWriter) Styled(st style, inner string) string {
+	tag := htmlTags[st]
+	return "<" + tag + ">" + inner + "</" + tag + ">"
+}
+func (htmlWriter) Code(text string) string { return "<code>" + Html(text) + "</code>" }
+func (htmlWriter) Pre(text string, lang string) string {
+	if lang != "" {
+		return `<pre><code class="language-` + Html(lang) + `">` + Html(text) + "</code></pre>"
+	}
+	return "<pre>" + Html(text) + "</pre>"
+}
+func (htmlWriter) Link(url string, inner string) string {
+	return `<a href="` + Html(url) + `">` + inner + "</a>"
+}
+func (htmlWriter) Quote(inner string) string { return "<blockquote>" + inner + "</blockquote>" }
+
+type markdownWriter struct{}
+
+var (
+	markdownMarkers = map[style]string{
+		styleBold:      "*",
+		styleItalic:    "_",
+		styleStrike:    "~",
+		styleUnderline: "__",
+		styleSpoiler:   "||",
+	}
+	// inside of code entities only ` and \ must be escaped, and ) and \ inside of link urls
+	markdownCodeReplacer = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+	markdownUrlReplacer  = strings.NewReplacer("\\", "\\\\", ")", "\\)")
+)
+
+func (markdownWriter) Text(text string) string { return Markdown(text) }
+func (markdownWriter) Styled(st style, inner string) string {
+	marker := markdownMarkers[st]
+	return marker + inner + marker
+}
+func (markdownWriter) Code(text string) string { return "`" + markdownCodeReplacer.Replace(text) + "`" }
+func (markdownWriter) Pre(text string, lang string) string {
+	return "```" + lang + "\n" + markdownCodeReplacer.Replace(text) + "\n```"
+}
+func (markdownWriter) Link(url string, inner string) string {
+	return "[" + inner + "](" + markdownUrlReplacer.Replace(url) + ")"
+}
+func (markdownWriter) Quote(inner string) string {
+	return ">" + strings.ReplaceAll(inner, "\n", "\n>")
+}
+
+// plainWriter drops the markup, for messages without parse mode
+type plainWriter struct{}
+
+func (plainWriter) Text(text string) string             { return text }
+func (plainWriter) Styled(_ style, inner string) string { return inner }
+func (plainWriter) Code(text string) string             { return text }
+func (plainWriter) Pre(text string, _ string) string    { return text }
+func (plainWriter) Quote(inner string) string           { return "> " + strings.ReplaceAll(inner, "\n", "\n> ") }
+func (plainWriter) Link(url string, inner string) string {
+	if inner == url || inner == "" {
+		return url
+	}
+	return inner + " (" + url + ")"
+}
+
+type delimiter struct {
+	marker string
+	style  style
+}
+
+// dialect describes a markup language of a third party service, to be
+// converted into Telegram's one.
+type dialect struct {
+	// style delimiters, longer markers must go first
+	delimiters []delimiter
+	// entity parses dialect specific constructs at the start of the text, like
+	// links; it returns the rendered entity and its length or 0 if there's none
+	entity func(w markupWriter, d dialect, text string) (string, int)
+	// unescape decodes the dialect's escaping of the plain text
+	unescape func(text string) string
+	// quote returns the line without the quote marker, if it's a quote line
+	quote func(line string) (string, bool)
+	// heading returns the line without the heading marker, if it's a heading
+	heading func(line string) (string, bool)
+	// fenceLang means the first line of a code block can be its language
+	fenceLang bool
+}
+
+// convert renders the whole text: code blocks, quotes, lists and inline markup.
+func (d dialect) convert(parseMode string, text string) string {
+	w := newMarkupWriter(parseMode)
+	var sb strings.Builder
+	for {
+		start := strings.Index(text, "```")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(text[start+3:], "```")
+		if end < 0 {
+			break
+		}
+		sb.WriteString(d.blocks(w, text[:start]))
+		code := text[start+3 : start+3+end]
+		lang := ""
+		if d.fenceLang {
+			if first, rest, ok := strings.Cut(code, "\n"); ok && first != "" && !strings.ContainsAny(first, " \t`") {
+				lang, code = first, rest
+			}
+		}
+		sb.WriteString(w.Pre(d.unescape(strings.Trim(code, "\n")), lang))
+		text = text[start+3+end+3:]
+	}
+	sb.WriteString(d.blocks(w, text))
+	return sb.String()
+}
+
+// blocks renders the lines of the text, grouping consecutive quote lines
+func (d dialect) blocks(w markupWriter, text string) string {
+	var lines, quote []string
+	flushQuote := func() {
+		if len(quote) > 0 {
+			lines = append(lines, w.Quote(strings.Join(quote, "\n")))
+			quote = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if inner, ok := d.quote(line); ok {
+			quote = append(quote, d.line(w, inner))
+			continue
+		}
+		flushQuote()
+		lines = append(lines, d.line(w, line))
+	}
+	flushQuote()
+	return strings.Join(lines, "\n")
+}
+
+var listBullets = []string{"- ", "* ", "• "}
+
+// line renders a single line, replacing list markers with bullets, as
+// Telegram has no lists.
+func (d dialect) line(w markupWriter, line string) string {
+	if d.heading != nil {
+		if inner, ok := d.heading(line); ok {
+			return w.Styled(styleBold, d.inline(w, inner))
+		}
+	}
+	trimmed := strings.TrimLeft(line, " ")
+	indent := line[:len(line)-len(trimmed)]
+	for _, bullet := range listBullets {
+		if strings.HasPrefix(trimmed, bullet) {
+			return w.Text(indent+"• ") + d.inline(w, trimmed[len(bullet):])
+		}
+	}
+	return d.inline(w, line)
+}
+
+// inline renders inline code, entities and styles of the text
+func (d dialect) inline(w markupWriter, text string) string {
+	var sb strings.Builder
+	plain := 0
+	flush := func(end int) {
+		if end > plain {
+			sb.WriteString(w.Text(d.unescape(text[plain:end])))
+		}
+	}
+	for i := 0; i < len(text); {
+		if text[i] == '`' {
+			if end := strings.IndexByte(text[i+1:], '`'); end > 0 {
+				flush(i)
+				sb.WriteString(w.Code(d.unescape(text[i+1 : i+1+end])))
+				i += end + 2
+				plain = i
+				continue
+			}
+		}
+		if d.entity != nil {
+			if rendered, n := d.entity(w, d, text[i:]); n > 0 {
+				flush(i)
+				sb.WriteString(rendered)
+				i += n
+				plain = i
+				continue
+			}
+		}
+		if del, inner, n := d.delimited(text, i); n > 0 {
+			flush(i)
+			sb.WriteString(w.Styled(del.style, d.inline(w, inner)))
+			i += n
+			plain = i
+			continue
+		}
+		i++
+	}
+	flush(len(text))
+	return sb.String()
+}
+
+// delimited finds a styled span starting at i, returning its inner text and
+// the whole length. Markers can't be inside of words or surrounded by spaces.
+func (d dialect) delimited(text string, i int) (delimiter, string, int) {
+	if i > 0 && isWordByte(text[i-1]) {
+		return delimiter{}, "", 0
+	}
+	for _, del := range d.delimiters {
+		m := del.marker
+		if !strings.HasPrefix(text[i:], m) {
+			continue
+		}
+		start := i + len(m)
+		if start >= len(text) || text[start] == ' ' || strings.HasPrefix(text[start:], m) {
+			continue
+		}
+		for j := start + 1; j+len(m) <= len(text); j++ {
+			if text[j] == '\n' {
+				break
+			}
+			// the last one of the repeated markers closes the span, so "***a***" is "*" inside of "**"
+			if strings.HasPrefix(text[j:], m) && text[j-1] != ' ' &&
+				(j+len(m) == len(text) || !isWordByte(text[j+len(m)]) && text[j+len(m)] != m[0]) {
+				return del, text[start:j], j + len(m) - i
+			}
+		}
+		// only the longest matching marker is tried, so "**" isn't parsed as two "*"
+		return delimiter{}, "", 0
+	}
+	return delimiter{}, "", 0
+}
+
+// isWordByte reports letters, digits and non-ASCII bytes
+func isWordByte(b byte) bool {
+	return b >= 0x80 || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
@@ -0,0 +1,17 @@
+package format_test
+
+import (
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoldAndAnchor(t *testing.T) {
+	assert.Equal(t, "<b>a&amp;b</b>", format.Bold("HTML", "a&b"))
+	assert.Equal(t, `*a\.b*`, format.Bold("MarkdownV2", "a.b"))
+	assert.Equal(t, `<a href="https://example.com">a&lt;b</a>`, format.Anchor("HTML", "https://example.com", "a<b"))
+	assert.Equal(t, `[a\.b](https://example.com/(x\))`, format.Anchor("MarkdownV2", "https://example.com/(x)", "a.b"))
+	assert.Equal(t, "a.b (https://example.com)", format.Anchor("", "https://example.com", "a.b"))
+	assert.Equal(t, `a\.b`, format.Anchor("MarkdownV2", "", "a.b"))
+}
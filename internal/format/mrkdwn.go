@@ -0,0 +1,64 @@
+package format
+
+import (
+	"strings"
+)
+
+var mrkdwnUnescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">")
+
+var mrkdwn = dialect{
+	delimiters: []delimiter{
+		{"*", styleBold},
+		{"_", styleItalic},
+		{"~", styleStrike},
+	},
+	entity:   mrkdwnEntity,
+	unescape: mrkdwnUnescaper.Replace,
+	quote: func(line string) (string, bool) {
+		for _, marker := range []string{"&gt; ", "&gt;", "> ", ">"} {
+			if strings.HasPrefix(line, marker) {
+				return line[len(marker):], true
+			}
+		}
+		return "", false
+	},
+}
+
+// Mrkdwn converts Slack mrkdwn text into the given parse mode: bold, italic,
+// strikethrough, code, links, mentions, quotes and lists. Markup is dropped for
+// messages without parse mode.
+//
+// See: https://api.slack.com/reference/surfaces/formatting
+func Mrkdwn(text string, parseMode string) string {
+	return mrkdwn.convert(parseMode, text)
+}
+
+// mrkdwnEntity renders <url|text> links, <@user> and <#channel> mentions and
+// <!special> commands, the latter ones as plain text.
+func mrkdwnEntity(w markupWriter, d dialect, text string) (string, int) {
+	if text[0] != '<' {
+		return "", 0
+	}
+	end := strings.IndexAny(text, ">\n")
+	if end < 2 || text[end] != '>' {
+		return "", 0
+	}
+	target, label, hasLabel := strings.Cut(text[1:end], "|")
+	switch target[0] {
+	case '@', '#':
+		if hasLabel {
+			return w.Text(string(target[0]) + strings.TrimPrefix(d.unescape(label), string(target[0]))), end + 1
+		}
+		return w.Text(d.unescape(target)), end + 1
+	case '!':
+		if hasLabel {
+			return w.Text(d.unescape(label)), end + 1
+		}
+		return w.Text("@" + strings.TrimPrefix(target, "!")), end + 1
+	}
+	url := d.unescape(target)
+	if !hasLabel {
+		return w.Link(url, w.Text(url)), end + 1
+	}
+	return w.Link(url, d.inline(w, label)), end + 1
+}
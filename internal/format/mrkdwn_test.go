@@ -0,0 +1,89 @@
+package format_test
+
+import (
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMrkdwn(t *testing.T) {
+	cases := []struct {
+		name     string
+		text     string
+		html     string
+		markdown string
+		plain    string
+	}{
+		{
+			"styles",
+			"*bold* _italic_ ~strike~ `a*b`",
+			"<b>bold</b> <i>italic</i> <s>strike</s> <code>a*b</code>",
+			"*bold* _italic_ ~strike~ `a*b`",
+			"bold italic strike a*b",
+		},
+		{
+			"nested styles",
+			"*bold _and italic_*",
+			"<b>bold <i>and italic</i></b>",
+			"*bold _and italic_*",
+			"bold and italic",
+		},
+		{
+			"markers inside of words",
+			"snake_case_name 2*3*4",
+			"snake_case_name 2*3*4",
+			`snake\_case\_name 2\*3\*4`,
+			"snake_case_name 2*3*4",
+		},
+		{
+			"links",
+			"See <https://example.com/?a=1&amp;b=2|*the docs*> or <https://example.com>",
+			`See <a href="https://example.com/?a=1&amp;b=2"><b>the docs</b></a> or <a href="https://example.com">https://example.com</a>`,
+			`See [*the docs*](https://example.com/?a=1&b=2) or [https://example\.com](https://example.com)`,
+			"See the docs (https://example.com/?a=1&b=2) or https://example.com",
+		},
+		{
+			"mentions",
+			"<@U123> <#C456|general> <!here> <!subteam^S1|@ops>",
+			"@U123 #general @here @ops",
+			`@U123 \#general @here @ops`,
+			"@U123 #general @here @ops",
+		},
+		{
+			"escaped entities",
+			"a &lt; b &amp;&amp; c &gt; d",
+			"a &lt; b &amp;&amp; c &gt; d",
+			`a < b && c \> d`,
+			"a < b && c > d",
+		},
+		{
+			"code block",
+			"Output:\n```\nif a < b {\n```",
+			"Output:\n<pre>if a &lt; b {</pre>",
+			"Output:\n```\nif a < b {\n```",
+			"Output:\nif a < b {",
+		},
+		{
+			"quote",
+			"&gt; quoted *line*\n&gt; second\nafter",
+			"<blockquote>quoted <b>line</b>\nsecond</blockquote>\nafter",
+			">quoted *line*\n>second\nafter",
+			"> quoted line\n> second\nafter",
+		},
+		{
+			"lists",
+			"Items:\n- first\n• second\n  * nested",
+			"Items:\n• first\n• second\n  • nested",
+			"Items:\n• first\n• second\n  • nested",
+			"Items:\n• first\n• second\n  • nested",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.html, format.Mrkdwn(tt.text, "HTML"))
+			assert.Equal(t, tt.markdown, format.Mrkdwn(tt.text, "MarkdownV2"))
+			assert.Equal(t, tt.plain, format.Mrkdwn(tt.text, ""))
+		})
+	}
+}
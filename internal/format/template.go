@@ -0,0 +1,108 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+var markdownLegacyReplacer = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
+// Escape escapes the text for the given parse mode; text is returned as is
+// for messages without parse mode.
+func Escape(parseMode string, text string) string {
+	switch parseMode {
+	case "HTML":
+		return Html(text)
+	case "MarkdownV2":
+		return Markdown(text)
+	case "Markdown":
+		return markdownLegacyReplacer.Replace(text)
+	default:
+		return text
+	}
+}
+
+// ParseTemplate parses go text/template with the helper functions, escaping
+// values for the given parse mode:
+//
+//   - escape: escapes the value for the parse mode of the message
+//   - html, markdown: escape the value for HTML or MarkdownV2 parse mode
+//   - truncate N: shortens the value to N bytes
+//   - default X: returns X if the value is empty
+//   - join SEP: joins a list with the separator
+//   - json: encodes the value as JSON
+//   - upper, lower, trim: change case or trim spaces of the value
+func ParseTemplate(name string, text string, parseMode string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs(parseMode)).Parse(text)
+}
+
+func templateFuncs(parseMode string) template.FuncMap {
+	return template.FuncMap{
+		"escape": func(value any) string {
+			return Escape(parseMode, toString(value))
+		},
+		"html": func(value any) string {
+			return Html(toString(value))
+		},
+		"markdown": func(value any) string {
+			return Markdown(toString(value))
+		},
+		"truncate": func(maxLen int, value any) string {
+			return Truncate(toString(value), maxLen)
+		},
+		"default": func(def any, value any) any {
+			if isEmpty(value) {
+				return def
+			}
+			return value
+		},
+		"join": func(sep string, value any) string {
+			v := reflect.ValueOf(value)
+			if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+				return toString(value)
+			}
+			items := make([]string, 0, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				items = append(items, toString(v.Index(i).Interface()))
+			}
+			return strings.Join(items, sep)
+		},
+		"json": func(value any) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+		"upper": func(value any) string {
+			return strings.ToUpper(toString(value))
+		},
+		"lower": func(value any) string {
+			return strings.ToLower(toString(value))
+		},
+		"trim": func(value any) string {
+			return strings.TrimSpace(toString(value))
+		},
+	}
+}
+
+func toString(value any) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func isEmpty(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,64 @@
+package format_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscape(t *testing.T) {
+	text := "<b>*a_b*</b>."
+	assert.Equal(t, "&lt;b&gt;*a_b*&lt;/b&gt;.", format.Escape("HTML", text))
+	assert.Equal(t, `<b\>\*a\_b\*</b\>\.`, format.Escape("MarkdownV2", text))
+	assert.Equal(t, `<b>\*a\_b\*</b>.`, format.Escape("Markdown", text))
+	assert.Equal(t, text, format.Escape("", text))
+}
+
+func TestParseTemplate(t *testing.T) {
+	var data any
+	err := json.Unmarshal([]byte(`{
+		"monitor": {"name": "<site>"},
+		"tags": ["a", "b"],
+		"status": 1,
+		"empty": "",
+		"long": "hello world"
+	}`), &data)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		parseMode string
+		template  string
+		want      string
+	}{
+		{"escape html", "HTML", "{{.monitor.name | escape}}", "&lt;site&gt;"},
+		{"escape markdown", "MarkdownV2", "{{.monitor.name | escape}} {{ .status }}", `<site\> 1`},
+		{"explicit html", "", "{{.monitor.name | html}}", "&lt;site&gt;"},
+		{"explicit markdown", "", "{{ markdown \"1.5\" }}", `1\.5`},
+		{"truncate", "", "{{.long | truncate 8}}", "hello…"},
+		{"default", "", "{{.empty | default \"n/a\"}} {{.missing | default \"none\"}} {{.status | default 0}}", "n/a none 1"},
+		{"join", "", "{{.tags | join \", \"}}", "a, b"},
+		{"json", "", "{{.tags | json}}", `["a","b"]`},
+		{"case", "", "{{.long | upper}} {{ \"ABC\" | lower }} [{{ \" x \" | trim }}]", "HELLO WORLD abc [x]"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := format.ParseTemplate("test", tt.template, tt.parseMode)
+			require.NoError(t, err)
+			var sb strings.Builder
+			require.NoError(t, tmpl.Execute(&sb, data))
+			assert.Equal(t, tt.want, sb.String())
+		})
+	}
+}
+
+func TestParseTemplate_Invalid(t *testing.T) {
+	_, err := format.ParseTemplate("test", "{{.x | nonexistent}}", "")
+	assert.Error(t, err)
+	_, err = format.ParseTemplate("test", "{{.x", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,335 @@
+// Package heartbeat monitors the periodic jobs, pinging the service on
+// success, and alerts their recipients when a job is late or failed.
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/atomicfile"
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/quiet"
+)
+
+// ErrNotFound is returned for pings of unknown checks.
+var ErrNotFound = errors.New("Heartbeat not found")
+
+// DefaultGrace is the grace time of the checks without one.
+const DefaultGrace = time.Hour
+
+// maximum length of the failure details in the alert
+const maxDetailsLen = 1000
+
+// Status of a check.
+type Status = string
+
+const (
+	// StatusNew is the status of a check, which wasn't pinged yet; it's not monitored
+	StatusNew Status = "new"
+	// StatusUp is the status of a check, pinged in time
+	StatusUp Status = "up"
+	// StatusDown is the status of a late or failed check, until the next ping
+	StatusDown Status = "down"
+)
+
+// Check is a job, expected to ping every Period. It's late after the Grace
+// time past the Period, or after the Grace time since the start of its run.
+type Check struct {
+	Name   string
+	Period time.Duration
+	Grace  time.Duration
+	// resolved chat ids
+	Recipients []string
+}
+
+// State of a check, persisted between restarts.
+type State struct {
+	Status   Status    `json:"status"`
+	LastPing time.Time `json:"last_ping"`
+	// start of the current run, zero if it's not started
+	Started time.Time `json:"started"`
+	// duration of the last finished run, zero if its start wasn't reported
+	LastDuration time.Duration `json:"last_duration"`
+}
+
+type check struct {
+	Check
+	State
+	timer *time.Timer
+}
+
+// deadline returns the time, when the check is late.
+func (c *check) deadline() time.Time {
+	var deadline time.Time
+	if !c.LastPing.IsZero() {
+		deadline = c.LastPing.Add(c.Period + c.Grace)
+	}
+	if !c.Started.IsZero() {
+		if started := c.Started.Add(c.Grace); deadline.IsZero() || started.Before(deadline) {
+			deadline = started
+		}
+	}
+	return deadline
+}
+
+// Monitor keeps the states of the checks in memory, optionally persisting
+// them into a file.
+type Monitor struct {
+	bot    tgnotifier.BotInterface
+	file   string
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	checks map[string]*check
+	closed bool
+}
+
+// NewChecks creates checks from the config, resolving their recipients;
+// checks without recipients alert the default ones.
+func NewChecks(heartbeats map[string]config.Heartbeat, groups tgnotifier.RecipientGroups, defaultRecipients []string) ([]Check, error) {
+	checks := make([]Check, 0, len(heartbeats))
+	for name, cfg := range heartbeats {
+		recipients := []string(cfg.Recipients)
+		if len(recipients) == 0 {
+			recipients = defaultRecipients
+		}
+		if len(recipients) == 0 {
+			return nil, fmt.Errorf("heartbeat %q: recipients must be set in the heartbeat or default recipients", name)
+		}
+		recipients, err := groups.Resolve(recipients)
+		if err != nil {
+			return nil, fmt.Errorf("heartbeat %q: %w", name, err)
+		}
+		grace := cfg.Grace
+		if grace == 0 {
+			grace = DefaultGrace
+		}
+		checks = append(checks, Check{Name: name, Period: cfg.Period, Grace: grace, Recipients: recipients})
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Name < checks[j].Name })
+	return checks, nil
+}
+
+// NewMonitor starts monitoring the checks, restoring their states from the
+// file, if it's not empty. Checks, which became late meanwhile, are alerted
+// right away.
+func NewMonitor(bot tgnotifier.BotInterface, checks []Check, file string, logger *slog.Logger) (*Monitor, error) {
+	m := &Monitor{bot: bot, file: file, logger: logger, checks: make(map[string]*check, len(checks))}
+	for _, c := range checks {
+		m.checks[c.Name] = &check{Check: c, State: State{Status: StatusNew}}
+	}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("error reading the heartbeats state: %w", err)
+		}
+		if err == nil {
+			var states map[string]State
+			if err := json.Unmarshal(data, &states); err != nil {
+				return nil, fmt.Errorf("error decoding the heartbeats state file %s: %w", file, err)
+			}
+			for name, state := range states {
+				// removed from the config meanwhile otherwise
+				if c, ok := m.checks[name]; ok {
+					c.State = state
+				}
+			}
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.checks {
+		m.schedule(c)
+	}
+	return m, nil
+}
+
+// Ping reports a successful run of the check. Down check is recovered.
+func (m *Monitor) Ping(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.checks[name]
+	if !ok {
+		return ErrNotFound
+	}
+	now := time.Now()
+	wasDown := c.Status == StatusDown
+	c.LastDuration = 0
+	if !c.Started.IsZero() {
+		c.LastDuration = now.Sub(c.Started)
+	}
+	c.Status, c.LastPing, c.Started = StatusUp, now, time.Time{}
+	m.changed(c)
+	if wasDown {
+		text := "✅ <b>" + format.Html(c.Name) + "</b> is up again"
+		if c.LastDuration > 0 {
+			text += ", the run took " + format.Duration(c.LastDuration)
+		}
+		go m.notify(c.Check, text)
+	}
+	return nil
+}
+
+// Start reports the start of the check's run, so it's late, if it doesn't
+// finish in the grace time.
+func (m *Monitor) Start(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.checks[name]
+	if !ok {
+		return ErrNotFound
+	}
+	c.Started = time.Now()
+	if c.Status == StatusNew {
+		c.Status = StatusUp
+	}
+	m.changed(c)
+	return nil
+}
+
+// Fail reports a failed run of the check with optional details, alerting
+// its recipients, unless the check is already down.
+func (m *Monitor) Fail(name string, details string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.checks[name]
+	if !ok {
+		return ErrNotFound
+	}
+	wasDown := c.Status == StatusDown
+	var duration time.Duration
+	if !c.Started.IsZero() {
+		duration = time.Since(c.Started)
+	}
+	c.Status, c.Started = StatusDown, time.Time{}
+	m.changed(c)
+	if !wasDown {
+		text := "🔥 <b>" + format.Html(c.Name) + "</b> failed"
+		if duration > 0 {
+			text += " after " + format.Duration(duration)
+		}
+		if details = strings.TrimSpace(details); details != "" {
+			text += "\n<pre>" + format.Html(format.Truncate(details, maxDetailsLen)) + "</pre>"
+		}
+		go m.notify(c.Check, text)
+	}
+	return nil
+}
+
+// Recipients returns the resolved recipients of the check's alerts.
+func (m *Monitor) Recipients(name string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.checks[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return c.Recipients, nil
+}
+
+// States returns the states of the checks by their names.
+func (m *Monitor) States() map[string]State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	states := make(map[string]State, len(m.checks))
+	for name, c := range m.checks {
+		states[name] = c.State
+	}
+	return states
+}
+
+// Close stops monitoring the checks.
+func (m *Monitor) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	for _, c := range m.checks {
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+	}
+}
+
+// changed saves the states and reschedules the check's deadline; must be
+// called with the lock held.
+func (m *Monitor) changed(c *check) {
+	if err := m.save(); err != nil {
+		m.logger.Error("Error saving the heartbeats state", slog.Any("error", err))
+	}
+	m.schedule(c)
+}
+
+// schedule starts the timer of the check's deadline, if it's monitored; must
+// be called with the lock held.
+func (m *Monitor) schedule(c *check) {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	if c.Status != StatusUp || m.closed {
+		return
+	}
+	deadline := c.deadline()
+	c.timer = time.AfterFunc(time.Until(deadline), func() { m.expire(c, deadline) })
+}
+
+// expire marks the check as down and alerts its recipients, if its deadline
+// didn't change meanwhile.
+func (m *Monitor) expire(c *check, deadline time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed || c.Status != StatusUp || !c.deadline().Equal(deadline) {
+		return
+	}
+	c.Status = StatusDown
+	if err := m.save(); err != nil {
+		m.logger.Error("Error saving the heartbeats state", slog.Any("error", err))
+	}
+	now := time.Now()
+	text := "🔥 <b>" + format.Html(c.Name) + "</b> is late"
+	if !c.Started.IsZero() && c.Started.Add(c.Grace).Equal(deadline) {
+		text += "\nThe run started " + format.Duration(now.Sub(c.Started)) + " ago and didn't finish"
+	} else {
+		text += "\nLast ping was " + format.Duration(now.Sub(c.LastPing)) + " ago, expected every " + format.Duration(c.Period)
+	}
+	go m.notify(c.Check, text)
+}
+
+func (m *Monitor) notify(c Check, text string) {
+	logger := m.logger.With(slog.String("heartbeat", c.Name))
+	err := m.bot.SendMessageWithContext(context.Background(), text, tgnotifier.ParseModeHTML, c.Recipients)
+	if err != nil && !errors.Is(err, quiet.ErrDeferred) {
+		logger.Error("Error sending the heartbeat alert", slog.Any("error", err))
+		return
+	}
+	logger.Info("Heartbeat alert sent", slog.Any("recipients", c.Recipients))
+}
+
+// save writes the states into the file, replacing it atomically; must be
+// called with the lock held.
+func (m *Monitor) save() error {
+	if m.file == "" {
+		return nil
+	}
+	states := make(map[string]State, len(m.checks))
+	for name, c := range m.checks {
+		states[name] = c.State
+	}
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(m.file, data); err != nil {
+		return fmt.Errorf("error saving the heartbeats state: %w", err)
+	}
+	return nil
+}
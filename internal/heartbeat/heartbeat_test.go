@@ -0,0 +1,159 @@
+package heartbeat_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/heartbeat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBot struct {
+	tgnotifier.BotInterface
+	mu   sync.Mutex
+	sent []string
+}
+
+func (b *mockBot) SendMessageWithContext(ctx context.Context, message string, parseMode tgnotifier.ParseMode, recipients []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, message)
+	return nil
+}
+
+func (b *mockBot) messages() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.sent...)
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newMonitor(t *testing.T, bot *mockBot, file string, period time.Duration, grace time.Duration) *heartbeat.Monitor {
+	t.Helper()
+	monitor, err := heartbeat.NewMonitor(bot, []heartbeat.Check{
+		{Name: "backup", Period: period, Grace: grace, Recipients: []string{"1"}},
+	}, file, discardLogger)
+	require.NoError(t, err)
+	t.Cleanup(monitor.Close)
+	return monitor
+}
+
+func TestNewChecks(t *testing.T) {
+	checks, err := heartbeat.NewChecks(map[string]config.Heartbeat{
+		"backup":  {Period: 24 * time.Hour, Recipients: config.StringList{"ops"}},
+		"cleanup": {Period: time.Hour, Grace: 5 * time.Minute},
+	}, tgnotifier.RecipientGroups{"ops": {"1", "2"}}, []string{"3"})
+	require.NoError(t, err)
+	assert.Equal(t, []heartbeat.Check{
+		{Name: "backup", Period: 24 * time.Hour, Grace: heartbeat.DefaultGrace, Recipients: []string{"1", "2"}},
+		{Name: "cleanup", Period: time.Hour, Grace: 5 * time.Minute, Recipients: []string{"3"}},
+	}, checks)
+
+	_, err = heartbeat.NewChecks(map[string]config.Heartbeat{"backup": {Period: time.Hour}}, nil, nil)
+	assert.ErrorContains(t, err, "recipients")
+}
+
+func TestMonitor_LateAndRecovered(t *testing.T) {
+	bot := &mockBot{}
+	monitor := newMonitor(t, bot, "", 50*time.Millisecond, 50*time.Millisecond)
+
+	// new checks aren't monitored
+	time.Sleep(150 * time.Millisecond)
+	assert.Empty(t, bot.messages())
+	assert.Equal(t, heartbeat.StatusNew, monitor.States()["backup"].Status)
+
+	require.NoError(t, monitor.Ping("backup"))
+	assert.Equal(t, heartbeat.StatusUp, monitor.States()["backup"].Status)
+	assert.Eventually(t, func() bool { return len(bot.messages()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Contains(t, bot.messages()[0], "<b>backup</b> is late")
+	assert.Equal(t, heartbeat.StatusDown, monitor.States()["backup"].Status)
+
+	// alerted once, until recovered
+	time.Sleep(150 * time.Millisecond)
+	assert.Len(t, bot.messages(), 1)
+
+	require.NoError(t, monitor.Ping("backup"))
+	assert.Eventually(t, func() bool { return len(bot.messages()) == 2 }, time.Second, 10*time.Millisecond)
+	assert.Contains(t, bot.messages()[1], "<b>backup</b> is up again")
+}
+
+func TestMonitor_PingsInTime(t *testing.T) {
+	bot := &mockBot{}
+	monitor := newMonitor(t, bot, "", 100*time.Millisecond, 50*time.Millisecond)
+
+	for range 4 {
+		require.NoError(t, monitor.Ping("backup"))
+		time.Sleep(80 * time.Millisecond)
+	}
+	assert.Empty(t, bot.messages())
+}
+
+func TestMonitor_StartedRunIsLate(t *testing.T) {
+	bot := &mockBot{}
+	monitor := newMonitor(t, bot, "", time.Hour, 50*time.Millisecond)
+
+	require.NoError(t, monitor.Start("backup"))
+	assert.Eventually(t, func() bool { return len(bot.messages()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Contains(t, bot.messages()[0], "didn't finish")
+}
+
+func TestMonitor_StartedRunDuration(t *testing.T) {
+	bot := &mockBot{}
+	monitor := newMonitor(t, bot, "", time.Hour, time.Hour)
+
+	require.NoError(t, monitor.Start("backup"))
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, monitor.Ping("backup"))
+	state := monitor.States()["backup"]
+	assert.GreaterOrEqual(t, state.LastDuration, 20*time.Millisecond)
+	assert.True(t, state.Started.IsZero())
+}
+
+func TestMonitor_Fail(t *testing.T) {
+	bot := &mockBot{}
+	monitor := newMonitor(t, bot, "", time.Hour, time.Hour)
+
+	require.NoError(t, monitor.Ping("backup"))
+	require.NoError(t, monitor.Fail("backup", "disk <full>"))
+	assert.Eventually(t, func() bool { return len(bot.messages()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "🔥 <b>backup</b> failed\n<pre>disk &lt;full&gt;</pre>", bot.messages()[0])
+
+	// already down
+	require.NoError(t, monitor.Fail("backup", ""))
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, bot.messages(), 1)
+}
+
+func TestMonitor_NotFound(t *testing.T) {
+	monitor := newMonitor(t, &mockBot{}, "", time.Hour, time.Hour)
+	assert.ErrorIs(t, monitor.Ping("unknown"), heartbeat.ErrNotFound)
+	assert.ErrorIs(t, monitor.Start("unknown"), heartbeat.ErrNotFound)
+	assert.ErrorIs(t, monitor.Fail("unknown", ""), heartbeat.ErrNotFound)
+}
+
+func TestMonitor_Persistence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "heartbeats.json")
+	bot := &mockBot{}
+	monitor, err := heartbeat.NewMonitor(bot, []heartbeat.Check{
+		{Name: "backup", Period: 100 * time.Millisecond, Grace: 50 * time.Millisecond, Recipients: []string{"1"}},
+	}, file, discardLogger)
+	require.NoError(t, err)
+	require.NoError(t, monitor.Ping("backup"))
+	monitor.Close()
+
+	// the check became late, while the service was stopped
+	time.Sleep(200 * time.Millisecond)
+	assert.Empty(t, bot.messages())
+	newMonitor(t, bot, file, 100*time.Millisecond, 50*time.Millisecond)
+	assert.Eventually(t, func() bool { return len(bot.messages()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Contains(t, bot.messages()[0], "<b>backup</b> is late")
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+// AlertmanagerPayload is the Prometheus Alertmanager webhook payload.
+//
+// See: https://prometheus.io/docs/alerting/latest/configuration/#webhook_config
+type AlertmanagerPayload struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	TruncatedAlerts   int                 `json:"truncatedAlerts"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []AlertmanagerAlert `json:"alerts"`
+}
+
+type AlertmanagerAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+func (a AlertmanagerAlert) view() alertView {
+	view := alertView{
+		Status:      a.Status,
+		Labels:      a.Labels,
+		Annotations: a.Annotations,
+		StartsAt:    a.StartsAt,
+		EndsAt:      a.EndsAt,
+	}
+	if a.GeneratorURL != "" {
+		view.Links = append(view.Links, format.Link(a.GeneratorURL, "source"))
+	}
+	return view
+}
+
+// Alertmanager receives Prometheus Alertmanager webhooks.
+//
+// Recipients of the alerts are taken from the "recipients" query parameter,
+// or the RecipientsLabel of each alert, or the default Recipients. Alerts with
+// different recipients are sent as separate messages.
+type Alertmanager struct {
+	Bot             tgnotifier.BotInterface
+	Recipients      []string
+	Groups          tgnotifier.RecipientGroups
+	RecipientsLabel string
+}
+
+func (h Alertmanager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.GetLogger(r.Context())
+
+	var payload AlertmanagerPayload
+	if err := decodeHookPayload(w, r, &payload); err != nil {
+		logger.Info("Failed to decode the alertmanager payload", slog.Any("error", err))
+		writeResponse(w, logger, http.StatusBadRequest, err)
+		return
+	}
+	if len(payload.Alerts) == 0 {
+		writeResponse(w, logger, http.StatusBadRequest, errors.New("no alerts in the payload"))
+		return
+	}
+	logger = logger.With(slog.String("group_key", payload.GroupKey))
+
+	alerts := make([]alertView, 0, len(payload.Alerts))
+	for _, alert := range payload.Alerts {
+		alerts = append(alerts, alert.view())
+	}
+	message := alertsMessage{
+		CommonLabels:    payload.CommonLabels,
+		RecipientsLabel: h.RecipientsLabel,
+	}
+	if payload.TruncatedAlerts > 0 {
+		message.Footer = append(message.Footer, fmt.Sprintf("<i>%d more alerts were truncated by Alertmanager</i>", payload.TruncatedAlerts))
+	}
+	if payload.ExternalURL != "" {
+		message.Footer = append(message.Footer, format.Link(payload.ExternalURL, "Alertmanager"))
+	}
+
+	var messages []Message
+	for _, route := range routeAlerts(r, alerts, h.RecipientsLabel, h.Recipients) {
+		messages = append(messages, Message{
+			Text:       message.render(route.Alerts),
+			ParseMode:  tgnotifier.ParseModeHTML,
+			Recipients: route.Recipients,
+		})
+	}
+	statusCode, err := sender{h.Bot, h.Groups}.sendAll(r, logger, messages)
+	writeResponse(w, logger, statusCode, err)
+}
@@ -0,0 +1,171 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeHookRequest(t *testing.T, target string, testdataFile string) (*http.Request, *httptest.ResponseRecorder) {
+	body, err := os.ReadFile(filepath.Join("testdata", testdataFile))
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req, httptest.NewRecorder()
+}
+
+func TestAlertmanager_Render(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Alertmanager{Bot: &mock, Recipients: []string{"user1"}, RecipientsLabel: "tg_recipients"}
+	req, resp := makeHookRequest(t, "/hooks/alertmanager", "alertmanager_firing.json")
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+	assert.Equal(t, tgnotifier.ParseModeHTML, mock.LastCallParseMode)
+	assert.Equal(t, `🔥 <b>[FIRING:1, RESOLVED:1] HighLatency</b>
+<i>job=api, severity=critical</i>
+
+<b>Firing</b>
+• <b>HighLatency</b> instance=api-1:8080
+p99 latency is above 1s
+Latency &lt;b&gt;1.5s&lt;/b&gt; &amp; rising
+<i>since 2024-05-01 10:00:00 UTC · <a href="http://prometheus.local:9090/graph?g0.expr=latency&amp;g0.tab=1">source</a> · <a href="https://runbooks.local/high-latency">runbook</a></i>
+
+<b>Resolved</b>
+• <b>HighLatency</b> instance=api-2:8080
+p99 latency is above 1s
+<i>resolved at 2024-05-01 09:30:00 UTC · <a href="http://prometheus.local:9090/graph?g0.expr=latency&amp;g0.tab=1">source</a></i>
+
+<a href="http://alertmanager.local:9093">Alertmanager</a>`, mock.LastCallMessage)
+}
+
+func TestAlertmanager_RecipientsByLabel(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Alertmanager{
+		Bot:             &mock,
+		Recipients:      []string{"default"},
+		Groups:          tgnotifier.RecipientGroups{"ops": {"1", "2"}, "dev": {"3"}},
+		RecipientsLabel: "tg_recipients",
+	}
+	req, resp := makeHookRequest(t, "/hooks/alertmanager", "alertmanager_routed.json")
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Len(t, mock.Calls, 3)
+	assert.Equal(t, []string{"1", "2"}, mock.Calls[0].Recipients)
+	assert.Contains(t, mock.Calls[0].Message, "<b>DiskFull</b>")
+	assert.Contains(t, mock.Calls[0].Message, "<b>MemoryHigh</b>")
+	assert.NotContains(t, mock.Calls[0].Message, "BuildBroken")
+	assert.NotContains(t, mock.Calls[0].Message, "tg_recipients")
+	assert.Contains(t, mock.Calls[0].Message, "2 more alerts were truncated")
+	assert.Equal(t, []string{"3", "42"}, mock.Calls[1].Recipients)
+	assert.Contains(t, mock.Calls[1].Message, "<b>BuildBroken</b>")
+	assert.Equal(t, []string{"default"}, mock.Calls[2].Recipients)
+	assert.Contains(t, mock.Calls[2].Message, "<b>Unrouted</b>")
+}
+
+func TestAlertmanager_RecipientsByQuery(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Alertmanager{Bot: &mock, Recipients: []string{"default"}, RecipientsLabel: "tg_recipients"}
+	req, resp := makeHookRequest(t, "/hooks/alertmanager?recipients=10,20&recipients=30", "alertmanager_routed.json")
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Len(t, mock.Calls, 1)
+	assert.Equal(t, []string{"10", "20", "30"}, mock.LastCallRecipients)
+	assert.Contains(t, mock.LastCallMessage, "[FIRING:4]")
+}
+
+func TestAlertmanager_NoRecipients(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Alertmanager{Bot: &mock}
+	req, resp := makeHookRequest(t, "/hooks/alertmanager", "alertmanager_firing.json")
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	require.Empty(t, mock.Calls)
+}
+
+func TestAlertmanager_InvalidPayload(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+	}{
+		{"not json", "garbage"},
+		{"no alerts", `{"version": "4", "alerts": []}`},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			handler := handlers.Alertmanager{Bot: &mock, Recipients: []string{"user1"}}
+			req, resp := makeRequest(tt.body)
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+			require.Empty(t, mock.Calls)
+		})
+	}
+}
+
+func TestAlertmanager_RecipientsRestrictedByPrincipal(t *testing.T) {
+	mock := mockBot{}
+	authenticator, err := middleware.ApiKeysAuthenticator([]config.ApiKey{
+		{Name: "restricted", Key: "key", Recipients: []string{"ops"}},
+	})
+	require.NoError(t, err)
+	handler := middleware.WithAuth(authenticator)(handlers.Alertmanager{
+		Bot:             &mock,
+		Recipients:      []string{"default"},
+		Groups:          tgnotifier.RecipientGroups{"ops": {"1", "2"}, "dev": {"3"}},
+		RecipientsLabel: "tg_recipients",
+	})
+	req, resp := makeHookRequest(t, "/hooks/alertmanager", "alertmanager_routed.json")
+	req.Header.Set("x-api-key", "key")
+
+	handler.ServeHTTP(resp, req)
+
+	// allowed part is still delivered
+	require.Equal(t, http.StatusForbidden, resp.Code)
+	require.Len(t, mock.Calls, 1)
+	assert.Equal(t, []string{"1", "2"}, mock.Calls[0].Recipients)
+}
+
+func TestAlertmanager_LongMessageIsShortened(t *testing.T) {
+	payload := handlers.AlertmanagerPayload{Status: "firing"}
+	for range 100 {
+		payload.Alerts = append(payload.Alerts, handlers.AlertmanagerAlert{
+			Status:      "firing",
+			Labels:      map[string]string{"alertname": "Flood"},
+			Annotations: map[string]string{"description": string(bytes.Repeat([]byte("x"), 200))},
+		})
+	}
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	mock := mockBot{}
+	handler := handlers.Alertmanager{Bot: &mock, Recipients: []string{"user1"}}
+	req, resp := makeRequest(string(body))
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.LessOrEqual(t, len(mock.LastCallMessage), 4096)
+	assert.Regexp(t, `…and \d+ more</i>$`, mock.LastCallMessage)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/format"
+)
+
+// alertView is the common part of the alerts from different sources, used
+// for routing and rendering.
+type alertView struct {
+	Status      string
+	Labels      map[string]string
+	Annotations map[string]string
+	StartsAt    time.Time
+	EndsAt      time.Time
+	// extra HTML lines, shown after the annotations
+	Extra []string
+	// HTML links, shown after the alert's time
+	Links []string
+}
+
+type alertsRoute struct {
+	Recipients []string
+	Alerts     []alertView
+}
+
+// routeAlerts groups alerts by their recipients, keeping the order of the
+// alerts. Recipients are taken from the "recipients" query parameter, or the
+// alert's label, or the default ones.
+func routeAlerts(r *http.Request, alerts []alertView, label string, defaults []string) []alertsRoute {
+	if recipients := queryRecipients(r); len(recipients) > 0 {
+		return []alertsRoute{{Recipients: recipients, Alerts: alerts}}
+	}
+	var routes []alertsRoute
+	index := make(map[string]int)
+	for _, alert := range alerts {
+		recipients := defaults
+		if label != "" {
+			if fromLabel := splitList(alert.Labels[label]); len(fromLabel) > 0 {
+				recipients = fromLabel
+			}
+		}
+		key := strings.Join(recipients, ",")
+		i, ok := index[key]
+		if !ok {
+			i = len(routes)
+			index[key] = i
+			routes = append(routes, alertsRoute{Recipients: recipients})
+		}
+		routes[i].Alerts = append(routes[i].Alerts, alert)
+	}
+	return routes
+}
+
+const alertTimeLayout = "2006-01-02 15:04:05 MST"
+
+// maximum length of a single annotation value in the message
+const maxAnnotationLen = 1000
+
+// alertsMessage renders alerts as a HTML message, firing alerts first.
+type alertsMessage struct {
+	// message heading, "[FIRING:n, RESOLVED:m] <alertname>" if empty
+	Heading      string
+	CommonLabels map[string]string
+	// label with the recipients, it's not shown
+	RecipientsLabel string
+	// extra HTML lines after the common labels
+	Subheading []string
+	// HTML lines at the end of the message
+	Footer []string
+}
+
+func (m alertsMessage) render(alerts []alertView) string {
+	var firing, resolved []alertView
+	for _, alert := range alerts {
+		if alert.Status == "resolved" {
+			resolved = append(resolved, alert)
+		} else {
+			firing = append(firing, alert)
+		}
+	}
+
+	icon := "🔥"
+	if len(firing) == 0 {
+		icon = "✅"
+	}
+	heading := m.Heading
+	if heading == "" {
+		var counts []string
+		if len(firing) > 0 {
+			counts = append(counts, fmt.Sprintf("FIRING:%d", len(firing)))
+		}
+		if len(resolved) > 0 {
+			counts = append(counts, fmt.Sprintf("RESOLVED:%d", len(resolved)))
+		}
+		heading = "[" + strings.Join(counts, ", ") + "]"
+		if alertname := m.CommonLabels["alertname"]; alertname != "" {
+			heading += " " + alertname
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(icon + " <b>" + format.Html(heading) + "</b>")
+	if commonLabels := m.visibleLabels(m.CommonLabels, nil); len(commonLabels) > 0 {
+		sb.WriteString("\n<i>" + format.Html(format.Pairs(commonLabels, ", ")) + "</i>")
+	}
+	for _, line := range m.Subheading {
+		sb.WriteString("\n" + line)
+	}
+
+	var footer strings.Builder
+	for _, line := range m.Footer {
+		footer.WriteString("\n\n" + line)
+	}
+
+	var blocks []string
+	for _, section := range []struct {
+		name   string
+		alerts []alertView
+	}{{"Firing", firing}, {"Resolved", resolved}} {
+		for i, alert := range section.alerts {
+			block := "\n\n"
+			if i == 0 && len(firing) > 0 && len(resolved) > 0 {
+				block += "<b>" + section.name + "</b>\n"
+			}
+			blocks = append(blocks, block+m.renderAlert(alert))
+		}
+	}
+	for i, block := range blocks {
+		if sb.Len()+len(block)+footer.Len() > maxRenderedLen {
+			fmt.Fprintf(&sb, "\n\n<i>…and %d more</i>", len(blocks)-i)
+			break
+		}
+		sb.WriteString(block)
+	}
+	sb.WriteString(footer.String())
+	return sb.String()
+}
+
+func (m alertsMessage) renderAlert(alert alertView) string {
+	var sb strings.Builder
+	sb.WriteString("• <b>" + format.Html(alert.Labels["alertname"]) + "</b>")
+	if labels := m.visibleLabels(alert.Labels, m.CommonLabels); len(labels) > 0 {
+		sb.WriteString(" " + format.Html(format.Pairs(labels, ", ")))
+	}
+	for _, name := range []string{"summary", "description"} {
+		if value := alert.Annotations[name]; value != "" {
+			sb.WriteString("\n" + format.Html(format.Truncate(value, maxAnnotationLen)))
+		}
+	}
+	for _, line := range alert.Extra {
+		sb.WriteString("\n" + line)
+	}
+
+	var details []string
+	if alert.Status == "resolved" && !alert.EndsAt.IsZero() {
+		details = append(details, "resolved at "+alert.EndsAt.UTC().Format(alertTimeLayout))
+	} else if !alert.StartsAt.IsZero() {
+		details = append(details, "since "+alert.StartsAt.UTC().Format(alertTimeLayout))
+	}
+	details = append(details, alert.Links...)
+	if runbook := alert.Annotations["runbook_url"]; runbook != "" {
+		details = append(details, format.Link(runbook, "runbook"))
+	}
+	if len(details) > 0 {
+		sb.WriteString("\n<i>" + strings.Join(details, " · ") + "</i>")
+	}
+	return sb.String()
+}
+
+// visibleLabels excludes alertname, recipients label and the common labels
+func (m alertsMessage) visibleLabels(labels map[string]string, common map[string]string) map[string]string {
+	visible := make(map[string]string, len(labels))
+	for name, value := range labels {
+		if name == "alertname" || name == m.RecipientsLabel {
+			continue
+		}
+		if commonValue, ok := common[name]; ok && commonValue == value {
+			continue
+		}
+		visible[name] = value
+	}
+	return visible
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/religiosa1/tgnotifier/internal/http/models"
+	"github.com/religiosa1/tgnotifier/internal/idempotency"
+	"github.com/religiosa1/tgnotifier/internal/scheduled"
+)
+
+// Batch sends several notifications in one request, as a JSON array of the
+// notification payloads with optional channels. Items are sent in order, each
+// of them counts against the caller's rate limit as a separate request.
+//
+// Items with idempotency keys aren't sent again on retries; the batch's
+// "Idempotency-Key" header is a key of each item without its own one,
+// suffixed with the item's index.
+type Batch struct {
+	Notify Notify
+	// maximum number of items in a request, unlimited if zero
+	MaxItems int
+	// limit, the items besides the first one are taken from; the first one is
+	// taken by the request itself
+	RateLimit middleware.KeyRateLimit
+}
+
+type BatchItem struct {
+	RequestPayload
+	// named channel to send the item to, the default one if empty
+	Channel string `json:"channel,omitempty"`
+}
+
+var errEmptyBatch = errors.New("Batch has no items")
+
+func (h Batch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.GetLogger(r.Context())
+
+	var items []BatchItem
+	if err := decodeHookPayload(w, r, &items); err != nil {
+		logger.Info("Failed to decode the batch", slog.Any("error", err))
+		writeResponse(w, logger, http.StatusBadRequest, err)
+		return
+	}
+	if len(items) == 0 {
+		writeResponse(w, logger, http.StatusBadRequest, errEmptyBatch)
+		return
+	}
+	if h.MaxItems > 0 && len(items) > h.MaxItems {
+		logger.Info("Batch is too large", slog.Int("items", len(items)))
+		writeResponse(w, logger, http.StatusRequestEntityTooLarge,
+			fmt.Errorf("Batch has %d items, while the maximum is %d", len(items), h.MaxItems))
+		return
+	}
+	// such batch can't be allowed, no matter how long the caller waits
+	if burst, ok := h.RateLimit.Burst(r); ok && len(items) > burst {
+		logger.Info("Batch exceeds the rate limit burst", slog.Int("items", len(items)), slog.Int("burst", burst))
+		writeResponse(w, logger, http.StatusRequestEntityTooLarge,
+			fmt.Errorf("Batch has %d items, while the rate limit burst is %d", len(items), burst))
+		return
+	}
+	batchKey := r.Header.Get("Idempotency-Key")
+	if err := validateIdempotencyKey(batchKey); err != nil {
+		writeResponse(w, logger, http.StatusBadRequest, err)
+		return
+	}
+	if len(items) > 1 && !h.RateLimit.AllowN(w, r, len(items)-1) {
+		return
+	}
+
+	resp := models.BatchResponsePayload{Success: true, Results: make([]models.BatchItemResult, len(items))}
+	for i, item := range items {
+		key := item.IdempotencyKey
+		if key == "" && batchKey != "" {
+			key = batchKey + "/" + strconv.Itoa(i)
+		}
+		result, replayed := h.send(r, logger.With(slog.Int("item", i)), item, key)
+		resp.Results[i] = models.BatchItemResult{Success: result.Err == nil, Status: result.StatusCode, Replayed: replayed}
+		if result.Err != nil {
+			resp.Success = false
+			resp.Results[i].Error = result.Err.Error()
+		}
+		if msg, ok := result.Value.(scheduled.Message); ok {
+			resp.Results[i].ScheduledId = msg.ID
+		}
+	}
+	statusCode := http.StatusOK
+	if !resp.Success {
+		statusCode = http.StatusMultiStatus
+	}
+	writeJSON(w, logger, statusCode, resp)
+}
+
+func (h Batch) send(r *http.Request, logger *slog.Logger, item BatchItem, key string) (idempotency.Result, bool) {
+	if item.Channel != "" {
+		logger = logger.With(slog.String("channel", item.Channel))
+	}
+	if err := validateIdempotencyKey(item.IdempotencyKey); err != nil {
+		return idempotency.Result{StatusCode: http.StatusBadRequest, Err: err}, false
+	}
+	channel, statusCode, err := h.Notify.channel(r, logger, item.Channel)
+	if err != nil {
+		return idempotency.Result{StatusCode: statusCode, Err: err}, false
+	}
+	return h.Notify.sendOnce(r, logger, channel, item.RequestPayload, key)
+}
@@ -0,0 +1,203 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/religiosa1/tgnotifier/internal/idempotency"
+	"github.com/religiosa1/tgnotifier/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBatch(t *testing.T, mock *mockBot) handlers.Batch {
+	channels, err := handlers.NewChannels(map[string]config.Channel{
+		"ops": {Recipients: config.StringList{"ops1"}, Prefix: "[ops] "},
+	})
+	require.NoError(t, err)
+	return handlers.Batch{
+		Notify:   handlers.Notify{Bot: mock, Recipients: []string{"default"}, Channels: channels},
+		MaxItems: 3,
+	}
+}
+
+func TestBatch(t *testing.T) {
+	mock := mockBot{}
+	handler := newBatch(t, &mock)
+
+	req, resp := makeRequest(`[
+		{"message": "one"},
+		{"message": "two", "recipients": ["user2"]},
+		{"message": "three", "channel": "ops"}
+	]`)
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"success": true, "results": [
+		{"success": true, "status": 200},
+		{"success": true, "status": 200},
+		{"success": true, "status": 200}
+	]}`, resp.Body.String())
+	require.Len(t, mock.Calls, 3)
+	assert.Equal(t, []string{"default"}, mock.Calls[0].Recipients)
+	assert.Equal(t, []string{"user2"}, mock.Calls[1].Recipients)
+	assert.Equal(t, "[ops] three", mock.Calls[2].Message)
+	assert.Equal(t, []string{"ops1"}, mock.Calls[2].Recipients)
+}
+
+func TestBatch_PartialFailure(t *testing.T) {
+	mock := mockBot{}
+	handler := newBatch(t, &mock)
+
+	req, resp := makeRequest(`[{"message": ""}, {"message": "two"}, {"message": "three", "channel": "unknown"}]`)
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusMultiStatus, resp.Code)
+	assert.JSONEq(t, `{"success": false, "results": [
+		{"success": false, "status": 422, "error": "tg message is empty"},
+		{"success": true, "status": 200},
+		{"success": false, "status": 404, "error": "Channel not found"}
+	]}`, resp.Body.String())
+	require.Len(t, mock.Calls, 1)
+}
+
+func TestBatch_InvalidRequests(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"not an array", `{"message": "one"}`, http.StatusBadRequest},
+		{"empty", `[]`, http.StatusBadRequest},
+		{"too many items", `[{"message": "1"}, {"message": "2"}, {"message": "3"}, {"message": "4"}]`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			handler := newBatch(t, &mock)
+
+			req, resp := makeRequest(tt.body)
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, tt.want, resp.Code)
+			assert.Empty(t, mock.Calls)
+		})
+	}
+}
+
+func TestBatch_RateLimit(t *testing.T) {
+	mock := mockBot{}
+	authenticator, err := middleware.ApiKeysAuthenticator([]config.ApiKey{{Name: "key", Key: "key"}})
+	require.NoError(t, err)
+	limiter := ratelimit.NewLimiter()
+	limit := config.RateLimit{Requests: 3, Per: time.Minute}
+	batch := newBatch(t, &mock)
+	batch.RateLimit = middleware.KeyRateLimit{Limiter: limiter, Limit: limit}
+	handler := middleware.Chain(
+		middleware.WithAuth(authenticator),
+		middleware.WithKeyRateLimit(limiter, limit, nil),
+	)(batch)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+		req.Header.Set("x-api-key", "key")
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := send(`[{"message": "1"}, {"message": "2"}]`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	// one token left, while the batch needs two of them
+	resp = send(`[{"message": "3"}, {"message": "4"}]`)
+	require.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+	assert.Len(t, mock.Calls, 2)
+}
+
+func TestBatch_LargerThanBurst(t *testing.T) {
+	mock := mockBot{}
+	authenticator, err := middleware.ApiKeysAuthenticator([]config.ApiKey{{Name: "key", Key: "key"}})
+	require.NoError(t, err)
+	limiter := ratelimit.NewLimiter()
+	limit := config.RateLimit{Requests: 2, Per: time.Minute}
+	batch := newBatch(t, &mock)
+	batch.RateLimit = middleware.KeyRateLimit{Limiter: limiter, Limit: limit}
+	handler := middleware.Chain(
+		middleware.WithAuth(authenticator),
+		middleware.WithKeyRateLimit(limiter, limit, nil),
+	)(batch)
+
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`[{"message": "1"}, {"message": "2"}, {"message": "3"}]`))
+	req.Header.Set("x-api-key", "key")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Contains(t, resp.Body.String(), "burst is 2")
+	assert.Empty(t, mock.Calls)
+}
+
+func TestBatch_Scheduled(t *testing.T) {
+	mock := mockBot{}
+	handler := newBatch(t, &mock)
+	handler.Notify.Scheduled = newScheduledStore(t, &mock)
+
+	req, resp := makeRequest(`[{"message": "now"}, {"message": "later", "delay": "1h", "channel": "ops"}]`)
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	list := handler.Notify.Scheduled.List()
+	require.Len(t, list, 1)
+	assert.JSONEq(t, `{"success": true, "results": [
+		{"success": true, "status": 200},
+		{"success": true, "status": 202, "scheduled_id": "`+list[0].ID+`"}
+	]}`, resp.Body.String())
+	assert.Equal(t, "[ops] later", list[0].Text)
+	assert.Equal(t, "ops", list[0].Channel)
+	require.Len(t, mock.Calls, 1)
+}
+
+func TestBatch_Idempotency(t *testing.T) {
+	mock := mockBot{}
+	handler := newBatch(t, &mock)
+	handler.Notify.Idempotency = idempotency.NewCache(time.Hour)
+	send := func(key string, body string) *httptest.ResponseRecorder {
+		req, resp := makeRequest(body)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		handler.ServeHTTP(resp, req)
+		return resp
+	}
+
+	body := `[{"message": "one"}, {"message": "two", "idempotency_key": "two"}]`
+	resp := send("batch", body)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Len(t, mock.Calls, 2)
+
+	// retried batch isn't sent again
+	resp = send("batch", body)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"success": true, "results": [
+		{"success": true, "status": 200, "replayed": true},
+		{"success": true, "status": 200, "replayed": true}
+	]}`, resp.Body.String())
+	require.Len(t, mock.Calls, 2)
+
+	// item's own key works without the batch one
+	resp = send("", `[{"message": "two", "idempotency_key": "two"}, {"message": "three"}]`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Len(t, mock.Calls, 3)
+	assert.Equal(t, "three", mock.Calls[2].Message)
+
+	resp = send(strings.Repeat("k", 256), body)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/dedup"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+// Channel is a named notification route with its own defaults.
+type Channel struct {
+	Name       string
+	Recipients []string
+	ParseMode  tgnotifier.ParseMode
+	Silent     bool
+	Prefix     string
+	// optional message template, executed with [TemplateData]
+	Template *template.Template
+	// names of callers allowed to use the channel; any if empty
+	ApiKeys []string
+	// optional, suppresses repeats of the same messages
+	Dedup *dedup.Suppressor
+	// default priority of the messages
+	Priority string
+}
+
+// TemplateData is passed to the channel's message template.
+type TemplateData struct {
+	Message string
+	Channel string
+}
+
+// NewChannels validates the channels config and parses their templates.
+func NewChannels(channels map[string]config.Channel) (map[string]Channel, error) {
+	result := make(map[string]Channel, len(channels))
+	for name, cfg := range channels {
+		if name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid channel name %q", name)
+		}
+		if cfg.ParseMode != "" && !tgnotifier.IsValidParseMode(cfg.ParseMode) {
+			return nil, fmt.Errorf("channel %q: %w: %s", name, tgnotifier.ErrParseModeInvalid, cfg.ParseMode)
+		}
+		channel := Channel{
+			Name:       name,
+			Recipients: cfg.Recipients,
+			ParseMode:  cfg.ParseMode,
+			Silent:     cfg.Silent,
+			Prefix:     cfg.Prefix,
+			ApiKeys:    cfg.ApiKeys,
+			Priority:   cfg.Priority,
+		}
+		if !IsValidPriority(cfg.Priority) {
+			return nil, fmt.Errorf("channel %q: invalid priority %q", name, cfg.Priority)
+		}
+		if cfg.Dedup < 0 {
+			return nil, fmt.Errorf("channel %q: dedup window must not be negative", name)
+		}
+		if cfg.Dedup > 0 {
+			channel.Dedup = dedup.NewSuppressor(cfg.Dedup)
+		}
+		if cfg.Template != "" {
+			tmpl, err := format.ParseTemplate(name, cfg.Template, cfg.ParseMode)
+			if err != nil {
+				return nil, fmt.Errorf("channel %q: error parsing template: %w", name, err)
+			}
+			channel.Template = tmpl.Option("missingkey=error")
+		}
+		result[name] = channel
+	}
+	return result, nil
+}
+
+// Allows reports whether the request's caller can use the channel. Names of
+// the callers, authorized by other methods than the API keys, e.g. JWT
+// subjects, aren't matched against the allowed API keys.
+func (c Channel) Allows(r *http.Request) bool {
+	if len(c.ApiKeys) == 0 {
+		return true
+	}
+	principal, ok := middleware.GetPrincipal(r.Context())
+	if !ok || (principal.Method != "api_key" && principal.Method != "hmac") {
+		return false
+	}
+	return slices.Contains(c.ApiKeys, principal.Name)
+}
+
+// Format applies the channel's template and prefix to the message.
+func (c Channel) Format(message string) (string, error) {
+	if c.Template != nil {
+		var sb strings.Builder
+		if err := c.Template.Execute(&sb, TemplateData{Message: message, Channel: c.Name}); err != nil {
+			return "", fmt.Errorf("error executing channel template: %w", err)
+		}
+		message = sb.String()
+	}
+	return c.Prefix + message, nil
+}
@@ -0,0 +1,78 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChannels(t *testing.T) {
+	channels, err := handlers.NewChannels(map[string]config.Channel{
+		"ops": {
+			Recipients: config.StringList{"ops"},
+			ParseMode:  tgnotifier.ParseModeHTML,
+			Silent:     true,
+			Prefix:     "[ops] ",
+			ApiKeys:    []string{"ci"},
+		},
+	})
+	require.NoError(t, err)
+
+	ops := channels["ops"]
+	assert.Equal(t, "ops", ops.Name)
+	assert.Equal(t, []string{"ops"}, ops.Recipients)
+	assert.Equal(t, tgnotifier.ParseModeHTML, ops.ParseMode)
+	assert.True(t, ops.Silent)
+	assert.Equal(t, []string{"ci"}, ops.ApiKeys)
+}
+
+func TestNewChannels_Invalid(t *testing.T) {
+	cases := []struct {
+		name     string
+		channels map[string]config.Channel
+	}{
+		{"parse mode", map[string]config.Channel{"ops": {ParseMode: "markdown"}}},
+		{"template", map[string]config.Channel{"ops": {Template: "{{.Message"}}},
+		{"name", map[string]config.Channel{"ops/dev": {}}},
+		{"dedup", map[string]config.Channel{"ops": {Dedup: -time.Minute}}},
+		{"priority", map[string]config.Channel{"ops": {Priority: "later"}}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handlers.NewChannels(tt.channels)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestChannel_Format(t *testing.T) {
+	channels, err := handlers.NewChannels(map[string]config.Channel{
+		"prefix":   {Prefix: "[ops] "},
+		"template": {Template: "<b>{{.Channel}}</b>: {{.Message}}"},
+		"both":     {Prefix: "! ", Template: "{{.Message}}!"},
+		"none":     {},
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		channel string
+		want    string
+	}{
+		{"prefix", "[ops] hello"},
+		{"template", "<b>template</b>: hello"},
+		{"both", "! hello!"},
+		{"none", "hello"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.channel, func(t *testing.T) {
+			message, err := channels[tt.channel].Format("hello")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, message)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/religiosa1/tgnotifier/internal/digest"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/religiosa1/tgnotifier/internal/http/models"
+)
+
+// DigestFlush sends the pending digest messages without waiting for the
+// schedule.
+type DigestFlush struct {
+	Digest *digest.Digest
+}
+
+func (h DigestFlush) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.GetLogger(r.Context())
+
+	sent, err := h.Digest.Flush(r.Context())
+	resp := models.DigestFlushResponsePayload{
+		ResponsePayload: models.ResponsePayload{Success: err == nil},
+		Messages:        sent,
+	}
+	statusCode := http.StatusOK
+	if err != nil {
+		logger.Error("Error sending the digest", slog.Any("error", err))
+		resp.Error = err.Error()
+		statusCode = mapSendMessageErrorToHttpCode(err)
+	}
+	logger.Info("Digest flushed", slog.Int("messages", sent))
+	writeJSON(w, logger, statusCode, resp)
+}
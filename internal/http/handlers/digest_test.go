@@ -0,0 +1,71 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/digest"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigest(t *testing.T) {
+	mock := mockBot{}
+	messageDigest := digest.New(&mock)
+	mux := newChannelsMux(t, handlers.Notify{Bot: &mock, Recipients: []string{"user1"}, Digest: messageDigest}, map[string]config.Channel{
+		"reports": {Recipients: config.StringList{"user1"}, Priority: handlers.PriorityDigest},
+	})
+	send := func(path string, body string) *httptest.ResponseRecorder {
+		req, resp := makeRequest(body)
+		req.URL.Path = path
+		mux.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := send("/", `{"message": "one", "priority": "digest"}`)
+	require.Equal(t, http.StatusAccepted, resp.Code)
+	require.Equal(t, `{"success":true}`, trimRespBody(resp))
+	resp = send("/notify/reports", `{"message": "two"}`)
+	require.Equal(t, http.StatusAccepted, resp.Code)
+	// explicit priority overrides the channel's one
+	resp = send("/notify/reports", `{"message": "urgent", "priority": "normal"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Len(t, mock.Calls, 1)
+
+	req := httptest.NewRequest(http.MethodPost, "/digest/flush", nil)
+	resp = httptest.NewRecorder()
+	handlers.DigestFlush{Digest: messageDigest}.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"success": true, "messages": 2}`, resp.Body.String())
+	require.Len(t, mock.Calls, 2)
+	assert.Equal(t, "Digest of 2 messages\n\none\n\ntwo", mock.Calls[1].Message)
+	assert.Equal(t, []string{"user1"}, mock.Calls[1].Recipients)
+}
+
+func TestDigest_InvalidPriority(t *testing.T) {
+	cases := []struct {
+		name    string
+		digest  *digest.Digest
+		body    string
+		wantErr string
+	}{
+		{"unknown", digest.New(&mockBot{}), `{"message": "one", "priority": "later"}`, `invalid priority \"later\"`},
+		{"disabled", nil, `{"message": "one", "priority": "digest"}`, "Digest is not enabled"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			handler := handlers.Notify{Bot: &mock, Recipients: []string{"user1"}, Digest: tt.digest}
+			req, resp := makeRequest(tt.body)
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+			require.Equal(t, `{"success":false,"error":"`+tt.wantErr+`"}`, trimRespBody(resp))
+			require.Empty(t, mock.Calls)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+// Discord receives Discord webhook payloads, JSON or multipart with the
+// "payload_json" field, rendering their content and embeds into a message and
+// converting Discord markdown into the parse mode of the message. If Token is
+// set, it must match the "token" path value.
+//
+// See: https://discord.com/developers/docs/resources/webhook#execute-webhook
+type Discord struct {
+	Bot        tgnotifier.BotInterface
+	Recipients []string
+	Groups     tgnotifier.RecipientGroups
+	ParseMode  tgnotifier.ParseMode
+	Token      string
+}
+
+type discordPayload struct {
+	Content string         `json:"content"`
+	Embeds  []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Url         string `json:"url"`
+	Color       int    `json:"color"`
+	Timestamp   string `json:"timestamp"`
+	Author      struct {
+		Name string `json:"name"`
+		Url  string `json:"url"`
+	} `json:"author"`
+	Fields []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"fields"`
+	Footer struct {
+		Text string `json:"text"`
+	} `json:"footer"`
+}
+
+func (h Discord) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.GetLogger(r.Context())
+
+	if h.Token != "" && !tokenEquals(h.Token, r.PathValue("token")) {
+		logger.Info("Invalid Discord webhook token")
+		writeResponse(w, logger, http.StatusForbidden, errors.New("Invalid token"))
+		return
+	}
+
+	var payload discordPayload
+	if err := decodeDiscordPayload(w, r, &payload); err != nil {
+		logger.Info("Failed to decode the Discord payload", slog.Any("error", err))
+		writeResponse(w, logger, http.StatusBadRequest, err)
+		return
+	}
+	statusCode, err := sender{h.Bot, h.Groups}.sendRendered(r, logger, Message{
+		ParseMode:  h.ParseMode,
+		Recipients: hookRecipients(r, h.Recipients),
+	}, payload.render)
+	writeResponse(w, logger, statusCode, err)
+}
+
+// decodeDiscordPayload decodes JSON body or the multipart one, used for the
+// messages with files
+func decodeDiscordPayload(w http.ResponseWriter, r *http.Request, payload *discordPayload) error {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return decodeHookPayload(w, r, payload)
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxHookBodySize)
+	if err := r.ParseMultipartForm(maxHookBodySize); err != nil {
+		return fmt.Errorf("error reading the payload: %w", err)
+	}
+	if payloadJson := r.PostFormValue("payload_json"); payloadJson != "" {
+		if err := json.Unmarshal([]byte(payloadJson), payload); err != nil {
+			return fmt.Errorf("error decoding the payload: %w", err)
+		}
+		return nil
+	}
+	payload.Content = r.PostFormValue("content")
+	return nil
+}
+
+func (p discordPayload) render(parseMode string) string {
+	var parts []string
+	if p.Content != "" {
+		parts = append(parts, format.Discord(p.Content, parseMode))
+	}
+	for _, embed := range p.Embeds {
+		if text := embed.render(parseMode); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func (e discordEmbed) render(parseMode string) string {
+	var lines []string
+	if e.Author.Name != "" {
+		lines = append(lines, format.Anchor(parseMode, e.Author.Url, e.Author.Name))
+	}
+	if e.Title != "" {
+		title := format.Bold(parseMode, e.Title)
+		if e.Url != "" {
+			title = format.Anchor(parseMode, e.Url, e.Title)
+		}
+		lines = append(lines, title)
+	}
+	if e.Description != "" {
+		lines = append(lines, format.Discord(e.Description, parseMode))
+	}
+	for _, field := range e.Fields {
+		lines = append(lines, format.Bold(parseMode, field.Name)+format.Escape(parseMode, ": ")+format.Discord(field.Value, parseMode))
+	}
+	footer := e.Footer.Text
+	if ts, err := time.Parse(time.RFC3339, e.Timestamp); err == nil {
+		if footer != "" {
+			footer += " • "
+		}
+		footer += ts.UTC().Format("2006-01-02 15:04 UTC")
+	}
+	if footer != "" {
+		lines = append(lines, format.Escape(parseMode, footer))
+	}
+	if len(lines) > 0 && e.Color != 0 {
+		lines[0] = discordColorIcon(e.Color) + " " + lines[0]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// discordColorIcon returns colored circle, closest to the embed's RGB color
+func discordColorIcon(color int) string {
+	icons := []struct {
+		icon    string
+		r, g, b int
+	}{
+		{"🔴", 221, 46, 68},
+		{"🟠", 244, 144, 12},
+		{"🟡", 253, 203, 88},
+		{"🟢", 120, 177, 89},
+		{"🔵", 85, 172, 238},
+		{"🟣", 170, 142, 214},
+		{"🟤", 193, 105, 79},
+		{"⚫", 49, 55, 61},
+		{"⚪", 230, 231, 232},
+	}
+	r, g, b := color>>16&0xff, color>>8&0xff, color&0xff
+	closest, minDistance := "", -1
+	for _, icon := range icons {
+		dr, dg, db := r-icon.r, g-icon.g, b-icon.b
+		if distance := dr*dr + dg*dg + db*db; minDistance < 0 || distance < minDistance {
+			closest, minDistance = icon.icon, distance
+		}
+	}
+	return closest
+}
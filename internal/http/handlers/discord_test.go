@@ -0,0 +1,139 @@
+package handlers_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscord_Embeds(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Discord{Bot: &mock, Recipients: []string{"user1"}, ParseMode: tgnotifier.ParseModeHTML}
+	req, resp := makeHookRequest(t, "/hooks/discord", "discord_embeds.json")
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `<b>Service down</b> @role
+
+🔴 <a href="https://status.example.com">Monitor</a>
+<a href="https://status.example.com/api">api &lt;prod&gt;</a>
+Connection <s>refused</s> timed out
+<blockquote>retrying in <code>60s</code></blockquote>
+<b>Status</b>: <u>DOWN</u>
+<b>Ping</b>: n/a
+Uptime Kuma • 2025-01-02 03:04 UTC`, mock.LastCallMessage)
+	assert.Equal(t, tgnotifier.ParseModeHTML, mock.LastCallParseMode)
+	assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+}
+
+func TestDiscord_Markdown(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Discord{Bot: &mock, Recipients: []string{"user1"}, ParseMode: tgnotifier.ParseModeMD}
+	req := httptest.NewRequest(http.MethodPost, "/hooks/discord", strings.NewReader(`{"embeds": [{"title": "Build #42", "fields": [{"name": "Result", "value": "**passed**"}]}]}`))
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "*Build \\#42*\n*Result*: *passed*", mock.LastCallMessage)
+}
+
+func TestDiscord_Multipart(t *testing.T) {
+	cases := []struct {
+		name  string
+		field string
+		value string
+	}{
+		{"payload json", "payload_json", `{"content": "_hello_"}`},
+		{"content field", "content", "_hello_"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			handler := handlers.Discord{Bot: &mock, Recipients: []string{"user1"}, ParseMode: tgnotifier.ParseModeHTML}
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			require.NoError(t, form.WriteField(tt.field, tt.value))
+			require.NoError(t, form.Close())
+			req := httptest.NewRequest(http.MethodPost, "/hooks/discord", &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			resp := httptest.NewRecorder()
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, "<i>hello</i>", mock.LastCallMessage)
+		})
+	}
+}
+
+func TestDiscord_EmptyPayload(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Discord{Bot: &mock, Recipients: []string{"user1"}, ParseMode: tgnotifier.ParseModeHTML}
+	req := httptest.NewRequest(http.MethodPost, "/hooks/discord", strings.NewReader(`{"content": "", "embeds": []}`))
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Empty(t, mock.Calls)
+}
+
+func TestDiscord_Token(t *testing.T) {
+	cases := []struct {
+		name     string
+		target   string
+		wantCode int
+	}{
+		{"valid token", "/hooks/discord/secret", http.StatusOK},
+		{"wrong token", "/hooks/discord/wrong", http.StatusForbidden},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			mux := http.NewServeMux()
+			mux.Handle("POST /hooks/discord/{token}", handlers.Discord{
+				Bot:        &mock,
+				Recipients: []string{"user1"},
+				ParseMode:  tgnotifier.ParseModeHTML,
+				Token:      "secret",
+			})
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(`{"content": "hi"}`))
+			resp := httptest.NewRecorder()
+
+			mux.ServeHTTP(resp, req)
+
+			require.Equal(t, tt.wantCode, resp.Code)
+			if tt.wantCode != http.StatusOK {
+				assert.Empty(t, mock.Calls)
+			}
+		})
+	}
+}
+
+func TestDiscord_RecipientsByQueryIgnoredOnTokenRoute(t *testing.T) {
+	mock := mockBot{}
+	mux := http.NewServeMux()
+	mux.Handle("POST /hooks/discord/{token}", handlers.Discord{
+		Bot:        &mock,
+		Recipients: []string{"user1"},
+		ParseMode:  tgnotifier.ParseModeHTML,
+		Token:      "secret",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/hooks/discord/secret?recipients=user2", strings.NewReader(`{"content": "hi"}`))
+	resp := httptest.NewRecorder()
+
+	mux.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+}
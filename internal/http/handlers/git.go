@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/religiosa1/tgnotifier/internal/format"
+)
+
+// EventFilter limits the events of the git hosting webhooks by their type and
+// branch. Empty lists allow anything.
+type EventFilter struct {
+	Events []string
+	// branch names or glob patterns, e.g. "release/*"
+	Branches []string
+}
+
+func (f EventFilter) allowsEvent(event string) bool {
+	return len(f.Events) == 0 || slices.Contains(f.Events, event)
+}
+
+// allowsBranch checks the branch of the event, events without a branch
+// (e.g. tags) are always allowed.
+func (f EventFilter) allowsBranch(branch string) bool {
+	if len(f.Branches) == 0 || branch == "" {
+		return true
+	}
+	for _, pattern := range f.Branches {
+		if matched, _ := path.Match(pattern, branch); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// gitEvent is a rendered git hosting webhook event
+type gitEvent struct {
+	// event's branch, empty if it's not related to a branch
+	Branch string
+	Text   string
+}
+
+type gitCommit struct {
+	Id      string
+	Message string
+	Url     string
+	Author  string
+}
+
+// maximum number of commits listed in a push message
+const maxListedCommits = 5
+
+// maximum length of titles and commit messages
+const maxTitleLen = 200
+
+// parseRef splits a git ref into the branch or tag name
+func parseRef(ref string) (branch string, tag string) {
+	if tag, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+		return "", tag
+	}
+	return strings.TrimPrefix(ref, "refs/heads/"), ""
+}
+
+func renderPush(repo string, repoUrl string, user string, ref string, commits []gitCommit, total int, deleted bool, compareUrl string) gitEvent {
+	branch, tag := parseRef(ref)
+	var sb strings.Builder
+	sb.WriteString("📦 <b>" + format.Link(repoUrl, repo) + "</b>: " + format.Html(user))
+	switch {
+	case tag != "" && deleted:
+		sb.WriteString(" deleted tag <b>" + format.Html(tag) + "</b>")
+	case tag != "":
+		sb.WriteString(" pushed tag <b>" + format.Html(tag) + "</b>")
+	case deleted:
+		sb.WriteString(" deleted branch <b>" + format.Html(branch) + "</b>")
+	default:
+		if total < len(commits) {
+			total = len(commits)
+		}
+		noun := "commits"
+		if total == 1 {
+			noun = "commit"
+		}
+		fmt.Fprintf(&sb, " pushed %d %s to <b>%s</b>", total, noun, format.Html(branch))
+	}
+	for i, commit := range commits {
+		if i == maxListedCommits {
+			fmt.Fprintf(&sb, "\n<i>…and %d more</i>", total-i)
+			break
+		}
+		id := commit.Id
+		if len(id) > 7 {
+			id = id[:7]
+		}
+		title, _, _ := strings.Cut(commit.Message, "\n")
+		sb.WriteString("\n• <code>" + format.Link(commit.Url, id) + "</code> " + format.Html(format.Truncate(title, maxTitleLen)))
+		if commit.Author != "" {
+			sb.WriteString(" — " + format.Html(commit.Author))
+		}
+	}
+	if compareUrl != "" && tag == "" && !deleted {
+		sb.WriteString("\n" + format.Link(compareUrl, "Compare changes"))
+	}
+	return gitEvent{Branch: branch, Text: sb.String()}
+}
+
+// renderRepoEvent renders a single line event with a link, e.g. a merge request
+func renderRepoEvent(icon string, repo string, repoUrl string, text string, title string, url string) string {
+	message := icon + " <b>" + format.Link(repoUrl, repo) + "</b>: " + text
+	if title != "" {
+		message += "\n" + format.Link(url, format.Truncate(title, maxTitleLen))
+	}
+	return message
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+// GitHub receives GitHub webhooks, verifying their signature in the
+// X-Hub-Signature-256 header. Supported events are "push", "pull_request",
+// "release" and "workflow_run".
+//
+// See: https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries
+type GitHub struct {
+	Bot        tgnotifier.BotInterface
+	Recipients []string
+	Groups     tgnotifier.RecipientGroups
+	Secret     string
+	Filter     EventFilter
+}
+
+type githubRepository struct {
+	FullName string `json:"full_name"`
+	HtmlUrl  string `json:"html_url"`
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubPushEvent struct {
+	Ref     string `json:"ref"`
+	Deleted bool   `json:"deleted"`
+	Compare string `json:"compare"`
+	Commits []struct {
+		Id      string `json:"id"`
+		Message string `json:"message"`
+		Url     string `json:"url"`
+		Author  struct {
+			Name string `json:"name"`
+		} `json:"author"`
+	} `json:"commits"`
+	Repository githubRepository `json:"repository"`
+	Pusher     struct {
+		Name string `json:"name"`
+	} `json:"pusher"`
+}
+
+type githubPullRequestEvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		HtmlUrl string `json:"html_url"`
+		Title   string `json:"title"`
+		Merged  bool   `json:"merged"`
+		Base    struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository githubRepository `json:"repository"`
+	Sender     githubUser       `json:"sender"`
+}
+
+type githubReleaseEvent struct {
+	Action  string `json:"action"`
+	Release struct {
+		HtmlUrl    string     `json:"html_url"`
+		TagName    string     `json:"tag_name"`
+		Name       string     `json:"name"`
+		Prerelease bool       `json:"prerelease"`
+		Author     githubUser `json:"author"`
+	} `json:"release"`
+	Repository githubRepository `json:"repository"`
+}
+
+type githubWorkflowRunEvent struct {
+	Action      string `json:"action"`
+	WorkflowRun struct {
+		Name       string `json:"name"`
+		HtmlUrl    string `json:"html_url"`
+		HeadBranch string `json:"head_branch"`
+		Conclusion string `json:"conclusion"`
+		RunNumber  int    `json:"run_number"`
+	} `json:"workflow_run"`
+	Repository githubRepository `json:"repository"`
+}
+
+func (h GitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.GetLogger(r.Context())
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBodySize))
+	if err != nil {
+		writeResponse(w, logger, http.StatusBadRequest, fmt.Errorf("error reading the payload: %w", err))
+		return
+	}
+	if !h.verify(r.Header.Get("X-Hub-Signature-256"), body) {
+		logger.Info("Invalid GitHub webhook signature")
+		writeResponse(w, logger, http.StatusForbidden, errors.New("Invalid signature"))
+		return
+	}
+
+	eventType := r.Header.Get("X-GitHub-Event")
+	logger = logger.With(slog.String("event", eventType), slog.String("delivery", r.Header.Get("X-GitHub-Delivery")))
+	if !h.Filter.allowsEvent(eventType) {
+		logger.Debug("GitHub event is filtered out")
+		writeResponse(w, logger, http.StatusOK, nil)
+		return
+	}
+
+	event, ok, err := renderGitHubEvent(eventType, body)
+	if err != nil {
+		logger.Info("Failed to decode the GitHub payload", slog.Any("error", err))
+		writeResponse(w, logger, http.StatusBadRequest, err)
+		return
+	}
+	if !ok || !h.Filter.allowsBranch(event.Branch) {
+		logger.Debug("GitHub event is skipped", slog.String("branch", event.Branch))
+		writeResponse(w, logger, http.StatusOK, nil)
+		return
+	}
+
+	statusCode, err := sender{h.Bot, h.Groups}.send(r, logger, Message{
+		Text:       event.Text,
+		ParseMode:  tgnotifier.ParseModeHTML,
+		Recipients: h.Recipients,
+	})
+	writeResponse(w, logger, statusCode, err)
+}
+
+func (h GitHub) verify(signature string, body []byte) bool {
+	if h.Secret == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(h.Secret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// renderGitHubEvent returns false, if the event or its action isn't supported
+func renderGitHubEvent(eventType string, body []byte) (gitEvent, bool, error) {
+	switch eventType {
+	case "push":
+		var event githubPushEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return gitEvent{}, false, err
+		}
+		commits := make([]gitCommit, 0, len(event.Commits))
+		for _, c := range event.Commits {
+			commits = append(commits, gitCommit{Id: c.Id, Message: c.Message, Url: c.Url, Author: c.Author.Name})
+		}
+		repo := event.Repository
+		return renderPush(repo.FullName, repo.HtmlUrl, event.Pusher.Name, event.Ref, commits, len(commits), event.Deleted, event.Compare), true, nil
+
+	case "pull_request":
+		var event githubPullRequestEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return gitEvent{}, false, err
+		}
+		pr := event.PullRequest
+		icon, action := "🔀", ""
+		switch {
+		case event.Action == "opened" || event.Action == "reopened" || event.Action == "ready_for_review":
+			action = event.Action
+		case event.Action == "closed" && pr.Merged:
+			icon, action = "✅", "merged"
+		case event.Action == "closed":
+			icon, action = "🚫", "closed"
+		default:
+			return gitEvent{}, false, nil
+		}
+		action = format.Html(fmt.Sprintf("%s %s pull request #%d into %s", event.Sender.Login, action, event.Number, pr.Base.Ref))
+		repo := event.Repository
+		return gitEvent{
+			Branch: pr.Base.Ref,
+			Text:   renderRepoEvent(icon, repo.FullName, repo.HtmlUrl, action, pr.Title, pr.HtmlUrl),
+		}, true, nil
+
+	case "release":
+		var event githubReleaseEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return gitEvent{}, false, err
+		}
+		if event.Action != "published" {
+			return gitEvent{}, false, nil
+		}
+		release := event.Release
+		kind := "release"
+		if release.Prerelease {
+			kind = "pre-release"
+		}
+		title := release.Name
+		if title == "" {
+			title = release.TagName
+		}
+		text := format.Html(fmt.Sprintf("%s published %s %s", release.Author.Login, kind, release.TagName))
+		repo := event.Repository
+		return gitEvent{Text: renderRepoEvent("🚀", repo.FullName, repo.HtmlUrl, text, title, release.HtmlUrl)}, true, nil
+
+	case "workflow_run":
+		var event githubWorkflowRunEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return gitEvent{}, false, err
+		}
+		if event.Action != "completed" {
+			return gitEvent{}, false, nil
+		}
+		run := event.WorkflowRun
+		text := format.Html(fmt.Sprintf("workflow %s #%d %s on %s", run.Name, run.RunNumber, run.Conclusion, run.HeadBranch))
+		repo := event.Repository
+		return gitEvent{
+			Branch: run.HeadBranch,
+			Text:   renderRepoEvent(conclusionIcon(run.Conclusion), repo.FullName, repo.HtmlUrl, text, "View run", run.HtmlUrl),
+		}, true, nil
+	}
+	return gitEvent{}, false, nil
+}
+
+// conclusionIcon returns icon for CI run result
+func conclusionIcon(conclusion string) string {
+	switch conclusion {
+	case "success":
+		return "✅"
+	case "failure", "failed", "timed_out":
+		return "❌"
+	default:
+		return "⚪"
+	}
+}
@@ -0,0 +1,125 @@
+package handlers_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const githubSecret = "It's a Secret to Everybody"
+
+func makeGitHubRequest(t *testing.T, event string, testdataFile string, secret string) (*http.Request, *httptest.ResponseRecorder) {
+	req, resp := makeHookRequest(t, "/hooks/github", testdataFile)
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("X-GitHub-Event", event)
+	return req, resp
+}
+
+func TestGitHub_Push(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.GitHub{Bot: &mock, Recipients: []string{"user1"}, Secret: githubSecret}
+	req, resp := makeGitHubRequest(t, "push", "github_push.json", githubSecret)
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+	assert.Equal(t, tgnotifier.ParseModeHTML, mock.LastCallParseMode)
+	assert.Equal(t, `📦 <b><a href="https://github.com/octo/app">octo/app</a></b>: mona pushed 2 commits to <b>main</b>
+• <code><a href="https://github.com/octo/app/commit/6dcb09b5b57875f334f61aebed695e2e4193db5e">6dcb09b</a></code> Fix &lt;script&gt; escaping — Mona Lisa
+• <code><a href="https://github.com/octo/app/commit/0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c">0d1a26e</a></code> Update README — Hubot
+<a href="https://github.com/octo/app/compare/9049f1265b7d...0d1a26e67d8f">Compare changes</a>`, mock.LastCallMessage)
+}
+
+func TestGitHub_PullRequest(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.GitHub{Bot: &mock, Recipients: []string{"user1"}, Secret: githubSecret}
+	req, resp := makeGitHubRequest(t, "pull_request", "github_pull_request.json", githubSecret)
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `✅ <b><a href="https://github.com/octo/app">octo/app</a></b>: mona merged pull request #42 into main
+<a href="https://github.com/octo/app/pull/42">Add &amp; improve things</a>`, mock.LastCallMessage)
+}
+
+func TestGitHub_WorkflowRun(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.GitHub{Bot: &mock, Recipients: []string{"user1"}, Secret: githubSecret}
+	req, resp := makeGitHubRequest(t, "workflow_run", "github_workflow_run.json", githubSecret)
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `❌ <b><a href="https://github.com/octo/app">octo/app</a></b>: workflow CI #562 failure on release/1.2
+<a href="https://github.com/octo/app/actions/runs/30433642">View run</a>`, mock.LastCallMessage)
+}
+
+func TestGitHub_InvalidSignature(t *testing.T) {
+	cases := []struct {
+		name          string
+		handlerSecret string
+		requestSecret string
+	}{
+		{"wrong secret", githubSecret, "wrong"},
+		{"secret not configured", "", ""},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			handler := handlers.GitHub{Bot: &mock, Recipients: []string{"user1"}, Secret: tt.handlerSecret}
+			req, resp := makeGitHubRequest(t, "push", "github_push.json", tt.requestSecret)
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusForbidden, resp.Code)
+			require.Empty(t, mock.Calls)
+		})
+	}
+}
+
+func TestGitHub_Filter(t *testing.T) {
+	cases := []struct {
+		name       string
+		event      string
+		file       string
+		filter     handlers.EventFilter
+		wantCalled bool
+	}{
+		{"event allowed", "push", "github_push.json", handlers.EventFilter{Events: []string{"push"}}, true},
+		{"event filtered", "push", "github_push.json", handlers.EventFilter{Events: []string{"release"}}, false},
+		{"branch allowed", "push", "github_push.json", handlers.EventFilter{Branches: []string{"main"}}, true},
+		{"branch filtered", "push", "github_push.json", handlers.EventFilter{Branches: []string{"develop"}}, false},
+		{"branch glob", "workflow_run", "github_workflow_run.json", handlers.EventFilter{Branches: []string{"release/*"}}, true},
+		{"pr base branch", "pull_request", "github_pull_request.json", handlers.EventFilter{Branches: []string{"develop"}}, false},
+		{"unsupported event", "ping", "github_push.json", handlers.EventFilter{}, false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			handler := handlers.GitHub{Bot: &mock, Recipients: []string{"user1"}, Secret: githubSecret, Filter: tt.filter}
+			req, resp := makeGitHubRequest(t, tt.event, tt.file, githubSecret)
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			require.Equal(t, tt.wantCalled, len(mock.Calls) > 0)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+// GitLab receives GitLab webhooks, verifying their secret token in the
+// X-Gitlab-Token header. Supported events (object kinds) are "push",
+// "tag_push", "merge_request", "pipeline" and "release".
+//
+// See: https://docs.gitlab.com/user/project/integrations/webhook_events/
+type GitLab struct {
+	Bot        tgnotifier.BotInterface
+	Recipients []string
+	Groups     tgnotifier.RecipientGroups
+	Token      string
+	Filter     EventFilter
+}
+
+type gitlabProject struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebUrl            string `json:"web_url"`
+}
+
+type gitlabEvent struct {
+	ObjectKind string        `json:"object_kind"`
+	Project    gitlabProject `json:"project"`
+	User       struct {
+		Name string `json:"name"`
+	} `json:"user"`
+
+	// push and tag_push
+	Ref               string `json:"ref"`
+	After             string `json:"after"`
+	UserName          string `json:"user_name"`
+	TotalCommitsCount int    `json:"total_commits_count"`
+	Commits           []struct {
+		Id      string `json:"id"`
+		Message string `json:"message"`
+		Url     string `json:"url"`
+		Author  struct {
+			Name string `json:"name"`
+		} `json:"author"`
+	} `json:"commits"`
+
+	// release
+	Action string `json:"action"`
+	Name   string `json:"name"`
+	Tag    string `json:"tag"`
+	Url    string `json:"url"`
+
+	// merge_request and pipeline
+	ObjectAttributes struct {
+		Id           int64  `json:"id"`
+		Iid          int64  `json:"iid"`
+		Title        string `json:"title"`
+		Url          string `json:"url"`
+		Action       string `json:"action"`
+		TargetBranch string `json:"target_branch"`
+		Ref          string `json:"ref"`
+		Tag          bool   `json:"tag"`
+		Status       string `json:"status"`
+	} `json:"object_attributes"`
+}
+
+func (h GitLab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.GetLogger(r.Context())
+
+	if !h.verify(r.Header.Get("X-Gitlab-Token")) {
+		logger.Info("Invalid GitLab webhook token")
+		writeResponse(w, logger, http.StatusForbidden, errors.New("Invalid token"))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBodySize))
+	if err != nil {
+		writeResponse(w, logger, http.StatusBadRequest, fmt.Errorf("error reading the payload: %w", err))
+		return
+	}
+	var payload gitlabEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		logger.Info("Failed to decode the GitLab payload", slog.Any("error", err))
+		writeResponse(w, logger, http.StatusBadRequest, fmt.Errorf("error decoding the payload: %w", err))
+		return
+	}
+
+	logger = logger.With(slog.String("event", payload.ObjectKind))
+	if !h.Filter.allowsEvent(payload.ObjectKind) {
+		logger.Debug("GitLab event is filtered out")
+		writeResponse(w, logger, http.StatusOK, nil)
+		return
+	}
+	event, ok := payload.render()
+	if !ok || !h.Filter.allowsBranch(event.Branch) {
+		logger.Debug("GitLab event is skipped", slog.String("branch", event.Branch))
+		writeResponse(w, logger, http.StatusOK, nil)
+		return
+	}
+
+	statusCode, err := sender{h.Bot, h.Groups}.send(r, logger, Message{
+		Text:       event.Text,
+		ParseMode:  tgnotifier.ParseModeHTML,
+		Recipients: h.Recipients,
+	})
+	writeResponse(w, logger, statusCode, err)
+}
+
+func (h GitLab) verify(token string) bool {
+	if h.Token == "" {
+		return false
+	}
+	return tokenEquals(h.Token, token)
+}
+
+// render returns false, if the event or its action isn't supported
+func (e gitlabEvent) render() (gitEvent, bool) {
+	project := e.Project
+	switch e.ObjectKind {
+	case "push", "tag_push":
+		commits := make([]gitCommit, 0, len(e.Commits))
+		for _, c := range e.Commits {
+			commits = append(commits, gitCommit{Id: c.Id, Message: c.Message, Url: c.Url, Author: c.Author.Name})
+		}
+		// after is all zeroes for the removed refs
+		deleted := strings.Trim(e.After, "0") == ""
+		return renderPush(project.PathWithNamespace, project.WebUrl, e.UserName, e.Ref, commits, e.TotalCommitsCount, deleted, ""), true
+
+	case "merge_request":
+		mr := e.ObjectAttributes
+		icon, action := "🔀", ""
+		switch mr.Action {
+		case "open":
+			action = "opened"
+		case "reopen":
+			action = "reopened"
+		case "merge":
+			icon, action = "✅", "merged"
+		case "close":
+			icon, action = "🚫", "closed"
+		default:
+			return gitEvent{}, false
+		}
+		text := format.Html(fmt.Sprintf("%s %s merge request !%d into %s", e.User.Name, action, mr.Iid, mr.TargetBranch))
+		return gitEvent{
+			Branch: mr.TargetBranch,
+			Text:   renderRepoEvent(icon, project.PathWithNamespace, project.WebUrl, text, mr.Title, mr.Url),
+		}, true
+
+	case "pipeline":
+		pipeline := e.ObjectAttributes
+		switch pipeline.Status {
+		case "success", "failed", "canceled":
+		default:
+			return gitEvent{}, false
+		}
+		url := pipeline.Url
+		if url == "" && project.WebUrl != "" {
+			url = fmt.Sprintf("%s/-/pipelines/%d", project.WebUrl, pipeline.Id)
+		}
+		branch := pipeline.Ref
+		if pipeline.Tag {
+			branch = ""
+		}
+		text := format.Html(fmt.Sprintf("pipeline #%d %s on %s", pipeline.Id, pipeline.Status, pipeline.Ref))
+		return gitEvent{
+			Branch: branch,
+			Text:   renderRepoEvent(conclusionIcon(pipeline.Status), project.PathWithNamespace, project.WebUrl, text, "View pipeline", url),
+		}, true
+
+	case "release":
+		if e.Action != "create" {
+			return gitEvent{}, false
+		}
+		title := e.Name
+		if title == "" {
+			title = e.Tag
+		}
+		text := format.Html("published release " + e.Tag)
+		return gitEvent{Text: renderRepoEvent("🚀", project.PathWithNamespace, project.WebUrl, text, title, e.Url)}, true
+	}
+	return gitEvent{}, false
+}
@@ -0,0 +1,105 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gitlabToken = "gitlab-secret-token"
+
+func TestGitLab_Events(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+		want string
+	}{
+		{"push", "gitlab_push.json", `📦 <b><a href="http://example.com/mike/diaspora">mike/diaspora</a></b>: John Smith pushed 4 commits to <b>master</b>
+• <code><a href="http://example.com/mike/diaspora/commit/b6568db1bc1dcd7f8b4d5a946b0b91f9dacd7327">b6568db</a></code> Update Catalan translation to e38cb41. — Jordi Mallach`},
+		{"merge request", "gitlab_merge_request.json", `🔀 <b><a href="http://example.com/gitlabhq/gitlab-test">gitlabhq/gitlab-test</a></b>: Administrator opened merge request !1 into master
+<a href="http://example.com/diaspora/merge_requests/1">MS-Viewport</a>`},
+		{"pipeline", "gitlab_pipeline.json", `✅ <b><a href="http://example.com/gitlab-org/gitlab-test">gitlab-org/gitlab-test</a></b>: pipeline #31 success on master
+<a href="http://example.com/gitlab-org/gitlab-test/-/pipelines/31">View pipeline</a>`},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			handler := handlers.GitLab{Bot: &mock, Recipients: []string{"user1"}, Token: gitlabToken}
+			req, resp := makeHookRequest(t, "/hooks/gitlab", tt.file)
+			req.Header.Set("X-Gitlab-Token", gitlabToken)
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+			assert.Equal(t, tt.want, mock.LastCallMessage)
+		})
+	}
+}
+
+func TestGitLab_InvalidToken(t *testing.T) {
+	cases := []struct {
+		name         string
+		handlerToken string
+		requestToken string
+	}{
+		{"wrong token", gitlabToken, "wrong"},
+		{"no token", gitlabToken, ""},
+		{"token not configured", "", ""},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			handler := handlers.GitLab{Bot: &mock, Recipients: []string{"user1"}, Token: tt.handlerToken}
+			req, resp := makeHookRequest(t, "/hooks/gitlab", "gitlab_push.json")
+			req.Header.Set("X-Gitlab-Token", tt.requestToken)
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusForbidden, resp.Code)
+			require.Empty(t, mock.Calls)
+		})
+	}
+}
+
+func TestGitLab_Filter(t *testing.T) {
+	cases := []struct {
+		name       string
+		file       string
+		filter     handlers.EventFilter
+		wantCalled bool
+	}{
+		{"event allowed", "gitlab_pipeline.json", handlers.EventFilter{Events: []string{"pipeline"}}, true},
+		{"event filtered", "gitlab_push.json", handlers.EventFilter{Events: []string{"pipeline"}}, false},
+		{"branch allowed", "gitlab_merge_request.json", handlers.EventFilter{Branches: []string{"master"}}, true},
+		{"branch filtered", "gitlab_push.json", handlers.EventFilter{Branches: []string{"main"}}, false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			handler := handlers.GitLab{Bot: &mock, Recipients: []string{"user1"}, Token: gitlabToken, Filter: tt.filter}
+			req, resp := makeHookRequest(t, "/hooks/gitlab", tt.file)
+			req.Header.Set("X-Gitlab-Token", gitlabToken)
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			require.Equal(t, tt.wantCalled, len(mock.Calls) > 0)
+		})
+	}
+}
+
+func TestGitLab_RecipientsByQueryIgnored(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.GitLab{Bot: &mock, Recipients: []string{"user1"}, Token: gitlabToken}
+	req, resp := makeHookRequest(t, "/hooks/gitlab?recipients=dev", "gitlab_pipeline.json")
+	req.Header.Set("X-Gitlab-Token", gitlabToken)
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+// Gotify is Gotify compatible message endpoint, authorized by the application
+// token in "token" query parameter, "X-Gotify-Key" header or bearer token.
+// Application tokens are mapped to their recipients, messages with the
+// priority at or below SilentPriority are sent without sound notification.
+//
+// See: https://gotify.net/docs/pushmsg
+type Gotify struct {
+	Bot            tgnotifier.BotInterface
+	Groups         tgnotifier.RecipientGroups
+	Apps           map[string][]string
+	SilentPriority int
+}
+
+type gotifyMessage struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	// nil, if the priority isn't set; such messages aren't silent
+	Priority *int `json:"priority"`
+	Extras   struct {
+		Display struct {
+			ContentType string `json:"contentType"`
+		} `json:"client::display"`
+		Notification struct {
+			Click struct {
+				Url string `json:"url"`
+			} `json:"click"`
+		} `json:"client::notification"`
+	} `json:"extras"`
+}
+
+type gotifyResponse struct {
+	AppId    int       `json:"appid"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	Priority int       `json:"priority"`
+	Date     time.Time `json:"date"`
+}
+
+var errInvalidAppToken = errors.New("Invalid application token")
+
+func (h Gotify) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.GetLogger(r.Context())
+
+	appId, recipients, ok := h.app(gotifyToken(r))
+	if !ok {
+		logger.Info("Invalid Gotify application token")
+		writeResponse(w, logger, http.StatusUnauthorized, errInvalidAppToken)
+		return
+	}
+	logger = logger.With(slog.Int("app_id", appId))
+
+	msg, err := readGotifyMessage(w, r)
+	if err != nil {
+		logger.Info("Failed to read the Gotify message", slog.Any("error", err))
+		writeResponse(w, logger, http.StatusBadRequest, err)
+		return
+	}
+	statusCode, err := sender{h.Bot, h.Groups}.sendRendered(r, logger, Message{
+		ParseMode:  tgnotifier.ParseModeHTML,
+		Recipients: recipients,
+		Options:    tgnotifier.SendOptions{DisableNotification: msg.Priority != nil && *msg.Priority <= h.SilentPriority},
+	}, msg.render)
+	if err != nil {
+		writeResponse(w, logger, statusCode, err)
+		return
+	}
+	resp := gotifyResponse{AppId: appId, Title: msg.Title, Message: msg.Message, Date: time.Now()}
+	if msg.Priority != nil {
+		resp.Priority = *msg.Priority
+	}
+	writeJSON(w, logger, http.StatusOK, resp)
+}
+
+func gotifyToken(r *http.Request) string {
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token
+	}
+	if token := r.Header.Get("X-Gotify-Key"); token != "" {
+		return token
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	return ""
+}
+
+// app returns the application id (its position in the sorted tokens) and
+// recipients; all of the tokens are compared, to keep the time constant
+func (h Gotify) app(token string) (int, []string, bool) {
+	tokens := make([]string, 0, len(h.Apps))
+	for appToken := range h.Apps {
+		tokens = append(tokens, appToken)
+	}
+	sort.Strings(tokens)
+	appId := 0
+	for i, appToken := range tokens {
+		if tokenEquals(appToken, token) && token != "" {
+			appId = i + 1
+		}
+	}
+	if appId == 0 {
+		return 0, nil, false
+	}
+	return appId, h.Apps[tokens[appId-1]], true
+}
+
+// readGotifyMessage reads JSON or form encoded message
+func readGotifyMessage(w http.ResponseWriter, r *http.Request) (gotifyMessage, error) {
+	var msg gotifyMessage
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := decodeHookPayload(w, r, &msg); err != nil {
+			return msg, err
+		}
+	} else {
+		r.Body = http.MaxBytesReader(w, r.Body, maxHookBodySize)
+		if err := r.ParseMultipartForm(maxHookBodySize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return msg, fmt.Errorf("error reading the payload: %w", err)
+		}
+		msg.Title = r.PostFormValue("title")
+		msg.Message = r.PostFormValue("message")
+		if value := r.PostFormValue("priority"); value != "" {
+			priority, err := strconv.Atoi(value)
+			if err != nil {
+				return msg, fmt.Errorf("invalid priority %q", value)
+			}
+			msg.Priority = &priority
+		}
+	}
+	if strings.TrimSpace(msg.Message) == "" {
+		return msg, errors.New("Message must not be empty")
+	}
+	return msg, nil
+}
+
+// render returns the message with its title and click link
+func (m gotifyMessage) render(parseMode string) string {
+	var lines []string
+	click := m.Extras.Notification.Click.Url
+	if m.Title != "" {
+		title := format.Bold(parseMode, m.Title)
+		if click != "" {
+			title = format.Anchor(parseMode, click, m.Title)
+		}
+		lines = append(lines, title)
+	}
+	if m.Extras.Display.ContentType == "text/markdown" {
+		// basic markdown is the same as the Discord flavor of it
+		lines = append(lines, format.Discord(m.Message, parseMode))
+	} else {
+		lines = append(lines, format.Escape(parseMode, m.Message))
+	}
+	if click != "" && m.Title == "" {
+		lines = append(lines, format.Anchor(parseMode, click, click))
+	}
+	return strings.Join(lines, "\n")
+}
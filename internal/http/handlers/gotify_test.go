@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGotify(mock *mockBot) handlers.Gotify {
+	return handlers.Gotify{
+		Bot:            mock,
+		Apps:           map[string][]string{"app-token": {"user1"}, "other-token": {"user2"}},
+		SilentPriority: 3,
+	}
+}
+
+func TestGotify_Message(t *testing.T) {
+	mock := mockBot{}
+	req := httptest.NewRequest(http.MethodPost, "/gotify/message?token=app-token", strings.NewReader(`{
+		"title": "Disk <sda>",
+		"message": "**90%** used",
+		"priority": 8,
+		"extras": {
+			"client::display": {"contentType": "text/markdown"},
+			"client::notification": {"click": {"url": "https://example.com/disks"}}
+		}
+	}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+
+	newGotify(&mock).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "<a href=\"https://example.com/disks\">Disk &lt;sda&gt;</a>\n<b>90%</b> used", mock.LastCallMessage)
+	assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+	assert.False(t, mock.LastCallOptions.DisableNotification)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, float64(8), body["priority"])
+	assert.Equal(t, float64(1), body["appid"])
+}
+
+func TestGotify_Form(t *testing.T) {
+	cases := []struct {
+		name       string
+		priority   string
+		wantSilent bool
+	}{
+		{"no priority", "", false},
+		{"low priority", "2", true},
+		{"high priority", "5", false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			form := url.Values{"title": {"Backup"}, "message": {"done"}}
+			if tt.priority != "" {
+				form.Set("priority", tt.priority)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/gotify/message", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("X-Gotify-Key", "other-token")
+			resp := httptest.NewRecorder()
+
+			newGotify(&mock).ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, "<b>Backup</b>\ndone", mock.LastCallMessage)
+			assert.Equal(t, []string{"user2"}, mock.LastCallRecipients)
+			assert.Equal(t, tt.wantSilent, mock.LastCallOptions.DisableNotification)
+		})
+	}
+}
+
+func TestGotify_InvalidToken(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+	}{
+		{"wrong token", "Bearer wrong"},
+		{"no token", ""},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			req := httptest.NewRequest(http.MethodPost, "/gotify/message", strings.NewReader(`{"message": "hi"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", tt.header)
+			resp := httptest.NewRecorder()
+
+			newGotify(&mock).ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusUnauthorized, resp.Code)
+			assert.Empty(t, mock.Calls)
+		})
+	}
+}
+
+func TestGotify_EmptyMessage(t *testing.T) {
+	mock := mockBot{}
+	req := httptest.NewRequest(http.MethodPost, "/gotify/message?token=app-token", strings.NewReader(`{"title": "hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+
+	newGotify(&mock).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Empty(t, mock.Calls)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+// GrafanaPayload is the Grafana unified alerting webhook contact point payload.
+//
+// See: https://grafana.com/docs/grafana/latest/alerting/configure-notifications/manage-contact-points/integrations/webhook-notifier/
+type GrafanaPayload struct {
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	OrgId             int64             `json:"orgId"`
+	Alerts            []GrafanaAlert    `json:"alerts"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Title             string            `json:"title"`
+	State             string            `json:"state"`
+	Message           string            `json:"message"`
+}
+
+type GrafanaAlert struct {
+	Status       string             `json:"status"`
+	Labels       map[string]string  `json:"labels"`
+	Annotations  map[string]string  `json:"annotations"`
+	StartsAt     time.Time          `json:"startsAt"`
+	EndsAt       time.Time          `json:"endsAt"`
+	GeneratorURL string             `json:"generatorURL"`
+	Fingerprint  string             `json:"fingerprint"`
+	SilenceURL   string             `json:"silenceURL"`
+	DashboardURL string             `json:"dashboardURL"`
+	PanelURL     string             `json:"panelURL"`
+	Values       map[string]float64 `json:"values"`
+	ValueString  string             `json:"valueString"`
+}
+
+func (a GrafanaAlert) view() alertView {
+	view := alertView{
+		Status:      a.Status,
+		Labels:      a.Labels,
+		Annotations: a.Annotations,
+		StartsAt:    a.StartsAt,
+		EndsAt:      a.EndsAt,
+	}
+	if len(a.Values) > 0 {
+		names := make([]string, 0, len(a.Values))
+		for name := range a.Values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		values := make([]string, 0, len(names))
+		for _, name := range names {
+			values = append(values, name+"="+strconv.FormatFloat(a.Values[name], 'f', -1, 64))
+		}
+		view.Extra = append(view.Extra, "Values: <code>"+format.Html(strings.Join(values, ", "))+"</code>")
+	}
+	for _, link := range []struct{ url, text string }{
+		{a.GeneratorURL, "source"},
+		{a.DashboardURL, "dashboard"},
+		{a.PanelURL, "panel"},
+		{a.SilenceURL, "silence"},
+	} {
+		if link.url != "" {
+			view.Links = append(view.Links, format.Link(link.url, link.text))
+		}
+	}
+	return view
+}
+
+// Grafana receives Grafana unified alerting webhooks.
+//
+// Recipients are chosen the same way as in [Alertmanager].
+type Grafana struct {
+	Bot             tgnotifier.BotInterface
+	Recipients      []string
+	Groups          tgnotifier.RecipientGroups
+	RecipientsLabel string
+}
+
+func (h Grafana) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.GetLogger(r.Context())
+
+	var payload GrafanaPayload
+	if err := decodeHookPayload(w, r, &payload); err != nil {
+		logger.Info("Failed to decode the grafana payload", slog.Any("error", err))
+		writeResponse(w, logger, http.StatusBadRequest, err)
+		return
+	}
+	if len(payload.Alerts) == 0 {
+		writeResponse(w, logger, http.StatusBadRequest, errors.New("no alerts in the payload"))
+		return
+	}
+	logger = logger.With(slog.String("group_key", payload.GroupKey))
+
+	alerts := make([]alertView, 0, len(payload.Alerts))
+	for _, alert := range payload.Alerts {
+		alerts = append(alerts, alert.view())
+	}
+	message := alertsMessage{
+		CommonLabels:    payload.CommonLabels,
+		RecipientsLabel: h.RecipientsLabel,
+	}
+	if payload.State != "" {
+		message.Subheading = append(message.Subheading, "State: <b>"+format.Html(payload.State)+"</b>")
+	}
+	if payload.TruncatedAlerts > 0 {
+		message.Footer = append(message.Footer, fmt.Sprintf("<i>%d more alerts were truncated by Grafana</i>", payload.TruncatedAlerts))
+	}
+	if payload.ExternalURL != "" {
+		message.Footer = append(message.Footer, format.Link(payload.ExternalURL, "Grafana"))
+	}
+
+	routes := routeAlerts(r, alerts, h.RecipientsLabel, h.Recipients)
+	// Grafana's title counts all of the alerts, so it's only correct for a single message
+	if len(routes) == 1 {
+		message.Heading = strings.Join(strings.Fields(payload.Title), " ")
+	}
+	var messages []Message
+	for _, route := range routes {
+		messages = append(messages, Message{
+			Text:       message.render(route.Alerts),
+			ParseMode:  tgnotifier.ParseModeHTML,
+			Recipients: route.Recipients,
+		})
+	}
+	statusCode, err := sender{h.Bot, h.Groups}.sendAll(r, logger, messages)
+	writeResponse(w, logger, statusCode, err)
+}
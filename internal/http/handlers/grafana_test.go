@@ -0,0 +1,58 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafana_Render(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Grafana{Bot: &mock, Recipients: []string{"user1"}, RecipientsLabel: "tg_recipients"}
+	req, resp := makeHookRequest(t, "/hooks/grafana", "grafana_firing.json")
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+	assert.Equal(t, tgnotifier.ParseModeHTML, mock.LastCallParseMode)
+	assert.Equal(t, `🔥 <b>[FIRING:1] (High memory usage Infra)</b>
+<i>grafana_folder=Infra, instance=db-1, team=blue</i>
+State: <b>alerting</b>
+
+• <b>High memory usage</b>
+Memory usage is above 90%
+Values: <code>B=93.25, C=1</code>
+<i>since 2024-05-01 10:00:00 UTC · <a href="http://grafana.local:3000/alerting/grafana/abc123/view">source</a> · <a href="http://grafana.local:3000/d/dash1">dashboard</a> · <a href="http://grafana.local:3000/d/dash1?viewPanel=2">panel</a> · <a href="http://grafana.local:3000/alerting/silence/new?alertmanager=grafana&amp;matcher=alertname%3DHigh+memory+usage">silence</a> · <a href="https://runbooks.local/memory">runbook</a></i>
+
+<a href="http://grafana.local:3000/">Grafana</a>`, mock.LastCallMessage)
+}
+
+func TestGrafana_RecipientsByQuery(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Grafana{
+		Bot:    &mock,
+		Groups: tgnotifier.RecipientGroups{"ops": {"1", "2"}},
+	}
+	req, resp := makeHookRequest(t, "/hooks/grafana?recipients=ops", "grafana_firing.json")
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, []string{"1", "2"}, mock.LastCallRecipients)
+}
+
+func TestGrafana_InvalidPayload(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Grafana{Bot: &mock, Recipients: []string{"user1"}}
+	req, resp := makeRequest(`{"alerts": []}`)
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	require.Empty(t, mock.Calls)
+}
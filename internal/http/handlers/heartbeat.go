@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/heartbeat"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+// Heartbeat receives the pings of the monitored jobs at "/heartbeat/{name}",
+// with optional "start" or "fail" action path value. Body of the fail ping is
+// included in the alert. The caller must be allowed to send messages to the
+// check's recipients, as its pings alert them.
+type Heartbeat struct {
+	Monitor *heartbeat.Monitor
+	// named recipient groups, resolved in the callers' allowed recipients
+	Groups tgnotifier.RecipientGroups
+}
+
+var errUnknownHeartbeatAction = errors.New("Unknown heartbeat action")
+
+func (h Heartbeat) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	action := r.PathValue("action")
+	logger := middleware.GetLogger(r.Context()).With(slog.String("heartbeat", name))
+
+	recipients, err := h.Monitor.Recipients(name)
+	if err != nil {
+		writeResponse(w, logger, http.StatusNotFound, err)
+		return
+	}
+	if principal, ok := middleware.GetPrincipal(r.Context()); ok && !(sender{Groups: h.Groups}).allowsRecipients(principal, recipients) {
+		logger.Info("Heartbeat recipients are not allowed for the caller", slog.Any("recipients", recipients))
+		writeResponse(w, logger, http.StatusForbidden, errRecipientsNotAllowed)
+		return
+	}
+
+	switch action {
+	case "":
+		err = h.Monitor.Ping(name)
+	case "start":
+		err = h.Monitor.Start(name)
+	case "fail":
+		var details []byte
+		details, err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBodySize))
+		if err != nil {
+			writeResponse(w, logger, http.StatusBadRequest, fmt.Errorf("error reading the payload: %w", err))
+			return
+		}
+		err = h.Monitor.Fail(name, string(details))
+	default:
+		writeResponse(w, logger, http.StatusNotFound, errUnknownHeartbeatAction)
+		return
+	}
+	if errors.Is(err, heartbeat.ErrNotFound) {
+		writeResponse(w, logger, http.StatusNotFound, err)
+		return
+	}
+	logger.Info("Heartbeat received", slog.String("action", cmp.Or(action, "ping")))
+	writeResponse(w, logger, http.StatusOK, nil)
+}
@@ -0,0 +1,97 @@
+package handlers_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/heartbeat"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHeartbeatMux(t *testing.T, mock *mockBot) (*http.ServeMux, *heartbeat.Monitor) {
+	monitor, err := heartbeat.NewMonitor(mock, []heartbeat.Check{
+		{Name: "backup", Period: time.Hour, Grace: time.Hour, Recipients: []string{"user1"}},
+	}, "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	t.Cleanup(monitor.Close)
+	handler := handlers.Heartbeat{Monitor: monitor}
+	mux := http.NewServeMux()
+	mux.Handle("/heartbeat/{name}", handler)
+	mux.Handle("/heartbeat/{name}/{action}", handler)
+	return mux, monitor
+}
+
+func TestHeartbeat(t *testing.T) {
+	mock := mockBot{}
+	mux, monitor := newHeartbeatMux(t, &mock)
+	call := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := call(http.MethodGet, "/heartbeat/backup/start", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.False(t, monitor.States()["backup"].Started.IsZero())
+
+	resp = call(http.MethodPost, "/heartbeat/backup", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `{"success":true}`, trimRespBody(resp))
+	assert.Equal(t, heartbeat.StatusUp, monitor.States()["backup"].Status)
+
+	resp = call(http.MethodPost, "/heartbeat/backup/fail", "exit status 1")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, heartbeat.StatusDown, monitor.States()["backup"].Status)
+	assert.Eventually(t, func() bool { return len(mock.calls()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Contains(t, mock.calls()[0].Message, "exit status 1")
+	assert.Equal(t, []string{"user1"}, mock.calls()[0].Recipients)
+}
+
+func TestHeartbeat_NotFound(t *testing.T) {
+	mock := mockBot{}
+	mux, _ := newHeartbeatMux(t, &mock)
+
+	for _, path := range []string{"/heartbeat/unknown", "/heartbeat/unknown/start", "/heartbeat/backup/finish"} {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusNotFound, resp.Code, path)
+	}
+}
+
+func TestHeartbeat_RestrictedRecipients(t *testing.T) {
+	mock := mockBot{}
+	mux, monitor := newHeartbeatMux(t, &mock)
+	authenticator, err := middleware.ApiKeysAuthenticator([]config.ApiKey{
+		{Name: "restricted", Key: "restricted", Recipients: []string{"user2"}},
+		{Name: "allowed", Key: "allowed", Recipients: []string{"user1"}},
+	})
+	require.NoError(t, err)
+	handler := middleware.WithAuth(authenticator)(mux)
+	call := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/heartbeat/backup/fail", strings.NewReader("exit status 1"))
+		req.Header.Set("x-api-key", key)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := call("restricted")
+	require.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Equal(t, heartbeat.StatusNew, monitor.States()["backup"].Status)
+	assert.Empty(t, mock.calls())
+
+	resp = call("allowed")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, heartbeat.StatusDown, monitor.States()["backup"].Status)
+}
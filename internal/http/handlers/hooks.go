@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+// maximum size of a webhook payload
+const maxHookBodySize = 1 << 20
+
+// maximum length of the messages in characters, rendered from webhook
+// payloads; it's less than Telegram's 4096 characters limit, so markup can be
+// ignored
+const maxRenderedLen = 4000
+
+func decodeHookPayload(w http.ResponseWriter, r *http.Request, payload any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxHookBodySize)
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		return fmt.Errorf("error decoding the payload: %w", err)
+	}
+	return nil
+}
+
+// queryRecipients returns recipients from the "recipients" query parameter,
+// which can be comma separated or repeated.
+func queryRecipients(r *http.Request) []string {
+	return splitList(r.URL.Query()["recipients"]...)
+}
+
+// hookRecipients returns recipients from the query parameter, if the request
+// is authenticated by the service's auth, so they are checked against the
+// caller's allowed recipients, or the configured recipients otherwise. Hooks,
+// verified by their own tokens or not at all, can't override them.
+func hookRecipients(r *http.Request, configured []string) []string {
+	if _, ok := middleware.GetPrincipal(r.Context()); !ok {
+		return configured
+	}
+	if fromQuery := queryRecipients(r); len(fromQuery) > 0 {
+		return fromQuery
+	}
+	return configured
+}
+
+// tokenEquals compares the tokens in constant time
+func tokenEquals(expected string, actual string) bool {
+	// hashing, so values of different length are compared in constant time
+	expectedHash := sha256.Sum256([]byte(expected))
+	actualHash := sha256.Sum256([]byte(actual))
+	return subtle.ConstantTimeCompare(expectedHash[:], actualHash[:]) == 1
+}
+
+func splitList(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+// errEmptyMessage is returned for the payloads, rendered into an empty message
+var errEmptyMessage = errors.New("Payload has no text")
+
+// sendRendered sends the message with the text, rendered by the render
+// function in the message's parse mode. Messages too long for Telegram are
+// rendered as plain text and truncated, as markup can't be truncated safely.
+func (s sender) sendRendered(r *http.Request, logger *slog.Logger, msg Message, render func(parseMode string) string) (int, error) {
+	msg.Text = render(msg.ParseMode)
+	if strings.TrimSpace(msg.Text) == "" {
+		return http.StatusBadRequest, errEmptyMessage
+	}
+	if utf8.RuneCountInString(msg.Text) > maxRenderedLen || len(msg.Text) > tgnotifier.MaxMsgLen {
+		msg.ParseMode = ""
+		msg.Text = format.Truncate(format.TruncateRunes(render(msg.ParseMode), maxRenderedLen), tgnotifier.MaxMsgLen)
+	}
+	return s.send(r, logger, msg)
+}
+
+// sendAll sends all of the messages, returning the status code of the first
+// failed one and all of the errors.
+func (s sender) sendAll(r *http.Request, logger *slog.Logger, messages []Message) (int, error) {
+	statusCode := http.StatusOK
+	var errs []error
+	for _, msg := range messages {
+		code, err := s.send(r, logger, msg)
+		if err != nil {
+			if len(errs) == 0 {
+				statusCode = code
+			}
+			errs = append(errs, err)
+		}
+	}
+	return statusCode, errors.Join(errs...)
+}
@@ -2,6 +2,7 @@ package handlers_test
 
 import (
 	"context"
+	"sync"
 
 	"github.com/religiosa1/tgnotifier"
 )
@@ -10,6 +11,25 @@ type mockBot struct {
 	Err                error
 	GetMeResponse      tgnotifier.GetMeResponse
 	LastCallRecipients []string
+	LastCallMessage    string
+	LastCallParseMode  tgnotifier.ParseMode
+	LastCallOptions    tgnotifier.SendOptions
+	Calls              []mockCall
+	// guards the calls, made in the background
+	mu sync.Mutex
+}
+
+type mockCall struct {
+	Message    string
+	Recipients []string
+	Options    tgnotifier.SendOptions
+}
+
+// calls returns a copy of the calls, for the background ones
+func (b *mockBot) calls() []mockCall {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]mockCall(nil), b.Calls...)
 }
 
 func (b *mockBot) SendMessage(message string, parseMode tgnotifier.ParseMode, recipients []string) error {
@@ -22,7 +42,23 @@ func (b *mockBot) SendMessageWithContext(
 	parseMode tgnotifier.ParseMode,
 	recipients []string,
 ) error {
+	return b.SendMessageWithOptions(ctx, message, parseMode, recipients, tgnotifier.SendOptions{})
+}
+
+func (b *mockBot) SendMessageWithOptions(
+	ctx context.Context,
+	message string,
+	parseMode tgnotifier.ParseMode,
+	recipients []string,
+	opts tgnotifier.SendOptions,
+) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.LastCallRecipients = recipients
+	b.LastCallMessage = message
+	b.LastCallParseMode = parseMode
+	b.LastCallOptions = opts
+	b.Calls = append(b.Calls, mockCall{message, recipients, opts})
 	return b.Err
 }
 
@@ -1,15 +1,24 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/digest"
 	"github.com/religiosa1/tgnotifier/internal/http/middleware"
 	"github.com/religiosa1/tgnotifier/internal/http/models"
+	"github.com/religiosa1/tgnotifier/internal/idempotency"
+	"github.com/religiosa1/tgnotifier/internal/scheduled"
 )
 
 type RequestPayload struct {
@@ -17,72 +26,402 @@ type RequestPayload struct {
 	ParseMode tgnotifier.ParseMode `json:"parse_mode"`
 	// recipients override (uses config values, if not provided)
 	Recipients []string `json:"recipients"`
+	// send the message without sound notification
+	Silent bool `json:"silent,omitempty"`
+	// "normal" (default), "digest" to send the message in the next digest, or
+	// "critical" to send it during the quiet hours of the recipients
+	Priority string `json:"priority,omitempty"`
+	// key of the request, repeated ones with the same key aren't sent again;
+	// alternative to "Idempotency-Key" header
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// time to send the message at, instead of sending it right away
+	SendAt *time.Time `json:"send_at,omitempty"`
+	// delay before sending the message, e.g. "15m"; alternative to SendAt
+	Delay string `json:"delay,omitempty"`
 }
 
+// sendTime returns the time to send the message at, zero if it's to be sent
+// right away.
+func (p RequestPayload) sendTime(now time.Time) (time.Time, error) {
+	if p.SendAt != nil && p.Delay != "" {
+		return time.Time{}, errors.New("send_at and delay can't be used together")
+	}
+	if p.SendAt != nil {
+		return *p.SendAt, nil
+	}
+	if p.Delay == "" {
+		return time.Time{}, nil
+	}
+	delay, err := time.ParseDuration(p.Delay)
+	if err != nil || delay < 0 {
+		return time.Time{}, fmt.Errorf("invalid delay %q", p.Delay)
+	}
+	return now.Add(delay), nil
+}
+
+// Notify sends notifications to the default recipients, or to the channel
+// from the "channel" path value, if it's present. The payload is JSON, plain
+// text or form encoded body, or the query parameters of GET request.
 type Notify struct {
 	Bot        tgnotifier.BotInterface
 	Recipients []string
+	// named recipient groups, resolved in the recipients list
+	Groups tgnotifier.RecipientGroups
+	// named channels, served at "/notify/{channel}"
+	Channels map[string]Channel
+	// results of the requests with idempotency keys; keys are ignored if nil
+	Idempotency *idempotency.Cache
+	// digest of the low priority messages; such messages are rejected if nil
+	Digest *digest.Digest
+	// messages to send later; requests with send time are rejected if nil
+	Scheduled *scheduled.Store
 }
 
+// Priorities of the messages
+const (
+	PriorityNormal   = "normal"
+	PriorityDigest   = "digest"
+	PriorityCritical = "critical"
+)
+
+// IsValidPriority reports whether the priority is a known one or empty.
+func IsValidPriority(priority string) bool {
+	switch priority {
+	case "", PriorityNormal, PriorityDigest, PriorityCritical:
+		return true
+	default:
+		return false
+	}
+}
+
+var (
+	errDigestDisabled     = errors.New("Digest is not enabled")
+	errSchedulingDisabled = errors.New("Scheduled messages are not enabled")
+	errScheduledDigest    = errors.New("Scheduled messages can't have digest priority")
+)
+
+var (
+	errChannelNotFound   = errors.New("Channel not found")
+	errChannelNotAllowed = errors.New("Access to the channel is not allowed")
+)
+
 func (h Notify) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	logger := middleware.GetLogger(r.Context())
 
-	writeResponse := func(statusCode int, payload models.ResponsePayload) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(statusCode)
-		if err := json.NewEncoder(w).Encode(payload); err != nil {
-			logger.Error("Error encoding response", slog.Any("error", err))
+	name := r.PathValue("channel")
+	if name != "" {
+		logger = logger.With(slog.String("channel", name))
+	}
+	channel, statusCode, err := h.channel(r, logger, name)
+	if err != nil {
+		writeResponse(w, logger, statusCode, err)
+		return
+	}
+
+	payload, statusCode, err := readPayload(w, r)
+	if err != nil {
+		logger.Info("Failed to read the payload", slog.Any("error", err))
+		writeResponse(w, logger, statusCode, err)
+		return
+	}
+
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		key = payload.IdempotencyKey
+	}
+	if err := validateIdempotencyKey(key); err != nil {
+		writeResponse(w, logger, http.StatusBadRequest, err)
+		return
+	}
+	result, replayed := h.sendOnce(r, logger, channel, payload, key)
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	if msg, ok := result.Value.(scheduled.Message); ok {
+		w.Header().Set("Location", "/scheduled/"+msg.ID)
+		writeJSON(w, logger, result.StatusCode, models.ScheduledMessageResponsePayload{
+			ResponsePayload: models.ResponsePayload{Success: true},
+			Message:         msg,
+		})
+		return
+	}
+	writeResponse(w, logger, result.StatusCode, result.Err)
+}
+
+// maximum length of an idempotency key
+const maxIdempotencyKeyLen = 255
+
+func validateIdempotencyKey(key string) error {
+	if len(key) > maxIdempotencyKeyLen {
+		return fmt.Errorf("Idempotency key must be at most %d characters long", maxIdempotencyKeyLen)
+	}
+	return nil
+}
+
+// sendOnce sends the payload, unless a request with the same idempotency key
+// was already made, returning its result in that case and reporting, if the
+// result is replayed.
+func (h Notify) sendOnce(r *http.Request, logger *slog.Logger, channel Channel, payload RequestPayload, key string) (idempotency.Result, bool) {
+	if key == "" || h.Idempotency == nil {
+		return h.deliver(r, logger, channel, payload), false
+	}
+	logger = logger.With(slog.String("idempotency_key", key))
+
+	// keys are scoped to the caller, so they can't clash between different ones
+	principal, _ := middleware.GetPrincipal(r.Context())
+	scopedKey := principalOwner(principal) + ":" + key
+	payload.IdempotencyKey = ""
+	fingerprint, err := json.Marshal(struct {
+		Channel string
+		Payload RequestPayload
+	}{channel.Name, payload})
+	if err != nil {
+		return idempotency.Result{StatusCode: http.StatusInternalServerError, Err: err}, false
+	}
+
+	result, replayed, err := h.Idempotency.Do(r.Context(), scopedKey, string(fingerprint), func() idempotency.Result {
+		return h.deliver(r, logger, channel, payload)
+	})
+	if errors.Is(err, idempotency.ErrKeyReused) {
+		logger.Info("Idempotency key is reused for a different request")
+		return idempotency.Result{StatusCode: http.StatusUnprocessableEntity, Err: err}, false
+	}
+	if err != nil {
+		logger.Info("Failed to wait for the request with the same idempotency key", slog.Any("error", err))
+		return idempotency.Result{StatusCode: http.StatusConflict, Err: err}, false
+	}
+	if replayed {
+		logger.Info("Replaying the result of the request with the same idempotency key")
+	}
+	return result, replayed
+}
+
+// deliver sends the payload or schedules it, if it has the send time; the
+// result's value is the [scheduled.Message] in the latter case.
+func (h Notify) deliver(r *http.Request, logger *slog.Logger, channel Channel, payload RequestPayload) idempotency.Result {
+	sendAt, err := payload.sendTime(time.Now())
+	if err != nil {
+		return idempotency.Result{StatusCode: http.StatusBadRequest, Err: err}
+	}
+	if sendAt.IsZero() {
+		statusCode, err := h.send(r, logger, channel, payload)
+		return idempotency.Result{StatusCode: statusCode, Err: err}
+	}
+	msg, statusCode, err := h.schedule(r, logger, channel, payload, sendAt)
+	if err != nil {
+		return idempotency.Result{StatusCode: statusCode, Err: err}
+	}
+	return idempotency.Result{StatusCode: statusCode, Value: msg}
+}
+
+// channel returns the named channel or the default one, if the name is empty,
+// checking if it's allowed for the caller.
+func (h Notify) channel(r *http.Request, logger *slog.Logger, name string) (Channel, int, error) {
+	channel := Channel{Recipients: h.Recipients}
+	if name != "" {
+		var ok bool
+		if channel, ok = h.Channels[name]; !ok {
+			return channel, http.StatusNotFound, errChannelNotFound
 		}
 	}
+	if !channel.Allows(r) {
+		logger.Info("Channel is not allowed for the caller")
+		return channel, http.StatusForbidden, errChannelNotAllowed
+	}
+	return channel, http.StatusOK, nil
+}
 
-	resp := models.ResponsePayload{}
+// send sends the payload with the channel's defaults applied.
+func (h Notify) send(r *http.Request, logger *slog.Logger, channel Channel, payload RequestPayload) (int, error) {
+	msg, statusCode, err := h.message(logger, channel, payload)
+	if err != nil {
+		return statusCode, err
+	}
+	return sender{h.Bot, h.Groups}.send(r, logger, msg)
+}
 
-	var payload RequestPayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		if errors.Is(err, io.EOF) {
-			resp.Error = "no body was provided"
-			logger.Info("No body was provided")
-			writeResponse(http.StatusBadRequest, resp)
-			return
+// schedule adds the payload with the channel's defaults applied to the
+// scheduled messages.
+func (h Notify) schedule(r *http.Request, logger *slog.Logger, channel Channel, payload RequestPayload, sendAt time.Time) (scheduled.Message, int, error) {
+	if h.Scheduled == nil {
+		return scheduled.Message{}, http.StatusBadRequest, errSchedulingDisabled
+	}
+	msg, statusCode, err := h.message(logger, channel, payload)
+	if err != nil {
+		return scheduled.Message{}, statusCode, err
+	}
+	if msg.Digest != nil {
+		return scheduled.Message{}, http.StatusBadRequest, errScheduledDigest
+	}
+	recipients, statusCode, err := sender{h.Bot, h.Groups}.recipients(r, logger, msg.Recipients)
+	if err != nil {
+		return scheduled.Message{}, statusCode, err
+	}
+	owner := ""
+	if principal, ok := middleware.GetPrincipal(r.Context()); ok {
+		owner = principalOwner(principal)
+	}
+	result, err := h.Scheduled.Add(scheduled.Message{
+		SendAt:     sendAt.UTC(),
+		Text:       msg.Text,
+		ParseMode:  msg.ParseMode,
+		Recipients: recipients,
+		Silent:     msg.Options.DisableNotification,
+		Critical:   msg.Critical,
+		Channel:    channel.Name,
+		Owner:      owner,
+	})
+	if err != nil {
+		logger.Error("Error scheduling the notification", slog.Any("error", err))
+		if errors.Is(err, scheduled.ErrTooMany) {
+			return result, http.StatusTooManyRequests, err
 		}
-		resp.Error = err.Error()
-		logger.Info("Failed to decode the body", slog.Any("error", err))
-		writeResponse(http.StatusBadRequest, resp)
-		return
+		return result, mapSendMessageErrorToHttpCode(err), err
 	}
+	logger.Info("Notification scheduled", slog.String("scheduled_id", result.ID), slog.Time("send_at", result.SendAt))
+	return result, http.StatusAccepted, nil
+}
 
-	var recipients []string
+// message returns the message of the payload with the channel's defaults applied.
+func (h Notify) message(logger *slog.Logger, channel Channel, payload RequestPayload) (Message, int, error) {
+	recipients := channel.Recipients
 	if payload.Recipients != nil {
 		recipients = payload.Recipients
-	} else {
-		recipients = h.Recipients
 	}
 	if len(recipients) == 0 {
-		resp.Error = "Recipients list not provided in the request, and default recipient is not set in the config"
-		writeResponse(400, resp)
-		return
+		return Message{}, http.StatusBadRequest, errNoRecipients
 	}
 
-	if err := h.Bot.SendMessageWithContext(r.Context(), payload.Message, payload.ParseMode, recipients); err != nil {
-		logger.Error("Error sending the notification", slog.Any("error", err))
-		resp.Error = err.Error()
-		writeResponse(mapSendMessageErrorToHttpCode(err), resp)
-		return
+	if payload.Message == "" {
+		// checking here, as the channel's prefix or template can make it non-empty
+		return Message{}, mapSendMessageErrorToHttpCode(tgnotifier.ErrMessageEmpty), tgnotifier.ErrMessageEmpty
 	}
-	resp.Success = true
-	writeResponse(http.StatusOK, resp)
+	text, err := channel.Format(payload.Message)
+	if err != nil {
+		logger.Error("Error formatting the message", slog.Any("error", err))
+		return Message{}, http.StatusInternalServerError, err
+	}
+	parseMode := payload.ParseMode
+	if parseMode == "" {
+		parseMode = channel.ParseMode
+	}
+	priority := payload.Priority
+	if priority == "" {
+		priority = channel.Priority
+	}
+	if !IsValidPriority(priority) {
+		return Message{}, http.StatusBadRequest, fmt.Errorf("invalid priority %q", priority)
+	}
+	var messageDigest *digest.Digest
+	if priority == PriorityDigest {
+		if h.Digest == nil {
+			return Message{}, http.StatusBadRequest, errDigestDisabled
+		}
+		messageDigest = h.Digest
+	}
+
+	return Message{
+		Text:       text,
+		ParseMode:  parseMode,
+		Recipients: recipients,
+		Options:    tgnotifier.SendOptions{DisableNotification: payload.Silent || channel.Silent},
+		Dedup:      channel.Dedup,
+		Digest:     messageDigest,
+		Critical:   priority == PriorityCritical,
+	}, http.StatusOK, nil
+}
+
+// supported content types of the payload, as reported in 415 errors
+const supportedContentTypes = "application/json, text/plain, application/x-www-form-urlencoded, multipart/form-data"
+
+// readPayload reads the payload according to the request's method and content
+// type, returning the status code to respond with on error.
+func readPayload(w http.ResponseWriter, r *http.Request) (RequestPayload, int, error) {
+	if r.Method == http.MethodGet {
+		payload, err := valuesPayload(r.URL.Query(), r.URL.Query().Get("message"))
+		return payload, http.StatusBadRequest, err
+	}
+	mediaType := ""
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return RequestPayload{}, http.StatusUnsupportedMediaType, fmt.Errorf("Invalid content type: %w", err)
+		}
+	}
+	switch mediaType {
+	case "", "application/json":
+		payload, err := jsonPayload(r.Body)
+		return payload, http.StatusBadRequest, err
+	case "text/plain":
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBodySize))
+		if err != nil {
+			return RequestPayload{}, http.StatusBadRequest, fmt.Errorf("error reading the payload: %w", err)
+		}
+		payload, err := valuesPayload(r.URL.Query(), string(body))
+		return payload, http.StatusBadRequest, err
+	case "application/x-www-form-urlencoded":
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBodySize))
+		if err != nil {
+			return RequestPayload{}, http.StatusBadRequest, fmt.Errorf("error reading the payload: %w", err)
+		}
+		// curl's "-d" sends JSON with this content type, unless it's set explicitly
+		if bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+			payload, err := jsonPayload(bytes.NewReader(body))
+			return payload, http.StatusBadRequest, err
+		}
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return RequestPayload{}, http.StatusBadRequest, fmt.Errorf("error decoding the form: %w", err)
+		}
+		payload, err := valuesPayload(form, form.Get("message"))
+		return payload, http.StatusBadRequest, err
+	case "multipart/form-data":
+		r.Body = http.MaxBytesReader(w, r.Body, maxHookBodySize)
+		if err := r.ParseMultipartForm(maxHookBodySize); err != nil {
+			return RequestPayload{}, http.StatusBadRequest, fmt.Errorf("error decoding the form: %w", err)
+		}
+		form := url.Values(r.MultipartForm.Value)
+		payload, err := valuesPayload(form, form.Get("message"))
+		return payload, http.StatusBadRequest, err
+	}
+	return RequestPayload{}, http.StatusUnsupportedMediaType,
+		fmt.Errorf("Unsupported content type %q, expected one of: %s", mediaType, supportedContentTypes)
 }
 
-func mapSendMessageErrorToHttpCode(err error) int {
-	var apiError tgnotifier.TgApiError
-	if errors.As(err, &apiError) {
-		return http.StatusBadRequest
+func jsonPayload(body io.Reader) (RequestPayload, error) {
+	var payload RequestPayload
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			return payload, errors.New("no body was provided")
+		}
+		return payload, err
 	}
-	if errors.Is(err, tgnotifier.ErrMessageTooLong) {
-		return http.StatusRequestEntityTooLarge
+	return payload, nil
+}
+
+// valuesPayload reads the payload options from the form or query parameters,
+// recipients can be comma separated or repeated.
+func valuesPayload(values url.Values, message string) (RequestPayload, error) {
+	payload := RequestPayload{
+		Message:        message,
+		ParseMode:      values.Get("parse_mode"),
+		Recipients:     splitList(values["recipients"]...),
+		Priority:       values.Get("priority"),
+		IdempotencyKey: values.Get("idempotency_key"),
+		Delay:          values.Get("delay"),
 	}
-	if errors.Is(err, tgnotifier.ErrMessageEmpty) || errors.Is(err, tgnotifier.ErrParseModeInvalid) {
-		return http.StatusUnprocessableEntity
+	if sendAt := values.Get("send_at"); sendAt != "" {
+		t, err := time.Parse(time.RFC3339, sendAt)
+		if err != nil {
+			return payload, fmt.Errorf("invalid send_at value %q, expected RFC 3339 time", sendAt)
+		}
+		payload.SendAt = &t
+	}
+	if silent := values.Get("silent"); silent != "" {
+		var err error
+		if payload.Silent, err = strconv.ParseBool(silent); err != nil {
+			return payload, fmt.Errorf("invalid silent value %q", silent)
+		}
 	}
-	return http.StatusInternalServerError
+	return payload, nil
 }
@@ -4,13 +4,20 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/config"
 	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/religiosa1/tgnotifier/internal/idempotency"
+	"github.com/religiosa1/tgnotifier/internal/quiet"
 	"github.com/stretchr/testify/require"
 )
 
@@ -194,3 +201,467 @@ func TestNotify_TgApiError_BadRequest(t *testing.T) {
 	expectedBody := fmt.Sprintf(`{"success":false,"error":"%s"}`, err.Error())
 	require.Equal(t, expectedBody, trimRespBody(resp))
 }
+
+func TestNotify_RecipientsRestrictedByPrincipal(t *testing.T) {
+	cases := []struct {
+		name       string
+		body       string
+		want       int
+		wantCalled bool
+	}{
+		{"allowed", `{"message": "hello", "recipients": ["user1"]}`, http.StatusOK, true},
+		{"default allowed", `{"message": "hello"}`, http.StatusOK, true},
+		{"not allowed", `{"message": "hello", "recipients": ["user1", "user2"]}`, http.StatusForbidden, false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			authenticator, err := middleware.ApiKeysAuthenticator([]config.ApiKey{
+				{Name: "restricted", Key: "key", Recipients: []string{"user1"}},
+			})
+			require.NoError(t, err)
+			handler := middleware.WithAuth(authenticator)(handlers.Notify{
+				Bot:        &mock,
+				Recipients: []string{"user1"},
+			})
+			req, resp := makeRequest(tt.body)
+			req.Header.Set("x-api-key", "key")
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, tt.want, resp.Code)
+			require.Equal(t, tt.wantCalled, mock.LastCallRecipients != nil)
+		})
+	}
+}
+
+func TestNotify_RecipientGroups(t *testing.T) {
+	groups := tgnotifier.RecipientGroups{
+		"alice": {"1001"},
+		"ops":   {"alice", "1002"},
+	}
+	cases := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"group in payload", `{"message": "hello", "recipients": ["ops", "1001", "1003"]}`, []string{"1001", "1002", "1003"}},
+		{"group in defaults", `{"message": "hello"}`, []string{"1001"}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			handler := handlers.Notify{
+				Bot:        &mock,
+				Recipients: []string{"alice"},
+				Groups:     groups,
+			}
+			req, resp := makeRequest(tt.body)
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			require.Equal(t, tt.want, mock.LastCallRecipients)
+		})
+	}
+}
+
+func TestNotify_RecipientGroupsRestrictedByPrincipal(t *testing.T) {
+	groups := tgnotifier.RecipientGroups{
+		"ops": {"1001", "1002"},
+		"dev": {"1003"},
+	}
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"allowed group", `{"message": "hello", "recipients": ["ops"]}`, http.StatusOK},
+		{"allowed group member", `{"message": "hello", "recipients": ["1002"]}`, http.StatusOK},
+		{"not allowed group", `{"message": "hello", "recipients": ["dev"]}`, http.StatusForbidden},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			authenticator, err := middleware.ApiKeysAuthenticator([]config.ApiKey{
+				{Name: "restricted", Key: "key", Recipients: []string{"ops"}},
+			})
+			require.NoError(t, err)
+			handler := middleware.WithAuth(authenticator)(handlers.Notify{
+				Bot:    &mock,
+				Groups: groups,
+			})
+			req, resp := makeRequest(tt.body)
+			req.Header.Set("x-api-key", "key")
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, tt.want, resp.Code)
+		})
+	}
+}
+
+func newChannelsMux(t *testing.T, notify handlers.Notify, channels map[string]config.Channel) *http.ServeMux {
+	var err error
+	notify.Channels, err = handlers.NewChannels(channels)
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	mux.Handle("POST /", notify)
+	mux.Handle("POST /notify/{channel}", notify)
+	mux.Handle("GET /send", notify)
+	mux.Handle("GET /send/{channel}", notify)
+	return mux
+}
+
+func TestNotify_Channels(t *testing.T) {
+	mock := mockBot{}
+	mux := newChannelsMux(t, handlers.Notify{Bot: &mock, Recipients: []string{"default"}}, map[string]config.Channel{
+		"ops": {
+			Recipients: config.StringList{"ops1", "ops2"},
+			ParseMode:  tgnotifier.ParseModeHTML,
+			Silent:     true,
+			Prefix:     "[ops] ",
+		},
+	})
+
+	req, resp := makeRequest(`{"message": "hello"}`)
+	req.URL.Path = "/notify/ops"
+	mux.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, []string{"ops1", "ops2"}, mock.LastCallRecipients)
+	require.Equal(t, "[ops] hello", mock.LastCallMessage)
+	require.Equal(t, tgnotifier.ParseModeHTML, mock.LastCallParseMode)
+	require.True(t, mock.LastCallOptions.DisableNotification)
+
+	req, resp = makeRequest(`{"message": "hello", "parse_mode": "MarkdownV2", "recipients": ["other"]}`)
+	req.URL.Path = "/notify/ops"
+	mux.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, []string{"other"}, mock.LastCallRecipients)
+	require.Equal(t, tgnotifier.ParseModeMD, mock.LastCallParseMode)
+
+	// default channel is unaffected
+	req, resp = makeRequest(`{"message": "hello"}`)
+	mux.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, []string{"default"}, mock.LastCallRecipients)
+	require.Equal(t, "hello", mock.LastCallMessage)
+	require.False(t, mock.LastCallOptions.DisableNotification)
+}
+
+func TestNotify_ChannelNotFound(t *testing.T) {
+	mock := mockBot{}
+	mux := newChannelsMux(t, handlers.Notify{Bot: &mock}, nil)
+
+	req, resp := makeRequest(`{"message": "hello", "recipients": ["user1"]}`)
+	req.URL.Path = "/notify/unknown"
+	mux.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusNotFound, resp.Code)
+	require.Equal(t, `{"success":false,"error":"Channel not found"}`, trimRespBody(resp))
+	require.Nil(t, mock.LastCallRecipients)
+}
+
+func TestNotify_ChannelEmptyMessageWithPrefix(t *testing.T) {
+	mock := mockBot{}
+	mux := newChannelsMux(t, handlers.Notify{Bot: &mock}, map[string]config.Channel{
+		"ops": {Recipients: config.StringList{"ops1"}, Prefix: "[ops] "},
+	})
+
+	req, resp := makeRequest(`{"message": ""}`)
+	req.URL.Path = "/notify/ops"
+	mux.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	require.Nil(t, mock.LastCallRecipients)
+}
+
+func TestNotify_ChannelApiKeys(t *testing.T) {
+	cases := []struct {
+		name string
+		key  string
+		want int
+	}{
+		{"allowed key", "ci-key", http.StatusOK},
+		{"other key", "other-key", http.StatusForbidden},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			mux := newChannelsMux(t, handlers.Notify{Bot: &mock}, map[string]config.Channel{
+				"ops": {Recipients: config.StringList{"ops1"}, ApiKeys: []string{"ci"}},
+			})
+			authenticator, err := middleware.ApiKeysAuthenticator([]config.ApiKey{
+				{Name: "ci", Key: "ci-key"},
+				{Name: "other", Key: "other-key"},
+			})
+			require.NoError(t, err)
+			handler := middleware.WithAuth(authenticator)(mux)
+
+			req, resp := makeRequest(`{"message": "hello"}`)
+			req.URL.Path = "/notify/ops"
+			req.Header.Set("x-api-key", tt.key)
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, tt.want, resp.Code)
+		})
+	}
+}
+
+func TestNotify_ChannelApiKeysOtherAuthMethod(t *testing.T) {
+	mock := mockBot{}
+	mux := newChannelsMux(t, handlers.Notify{Bot: &mock}, map[string]config.Channel{
+		"ops": {Recipients: config.StringList{"ops1"}, ApiKeys: []string{"ci"}},
+	})
+	// JWT subject, matching the name of the allowed API key
+	authenticator := middleware.AuthenticatorFunc(func(r *http.Request) (middleware.Principal, error) {
+		return middleware.Principal{Name: "ci", Method: "jwt"}, nil
+	})
+	handler := middleware.WithAuth(authenticator)(mux)
+
+	req, resp := makeRequest(`{"message": "hello"}`)
+	req.URL.Path = "/notify/ops"
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusForbidden, resp.Code)
+	require.Empty(t, mock.Calls)
+}
+
+func TestNotify_PlainText(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Notify{Bot: &mock, Recipients: []string{"user1"}}
+
+	req := httptest.NewRequest(http.MethodPost, "/?parse_mode=HTML&recipients=a,b&recipients=c&silent=1", strings.NewReader("<b>disk</b> is full"))
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "<b>disk</b> is full", mock.LastCallMessage)
+	require.Equal(t, tgnotifier.ParseModeHTML, mock.LastCallParseMode)
+	require.Equal(t, []string{"a", "b", "c"}, mock.LastCallRecipients)
+	require.True(t, mock.LastCallOptions.DisableNotification)
+}
+
+func TestNotify_Form(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Notify{Bot: &mock, Recipients: []string{"user1"}}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("message=hello+world&silent=true"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "hello world", mock.LastCallMessage)
+	require.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+	require.True(t, mock.LastCallOptions.DisableNotification)
+}
+
+func TestNotify_FormWithJsonBody(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Notify{Bot: &mock, Recipients: []string{"user1"}}
+
+	// curl -d without an explicit content type
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"message": "hello", "recipients": ["user2"]}`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "hello", mock.LastCallMessage)
+	require.Equal(t, []string{"user2"}, mock.LastCallRecipients)
+}
+
+func TestNotify_QueryString(t *testing.T) {
+	mock := mockBot{}
+	mux := newChannelsMux(t, handlers.Notify{Bot: &mock, Recipients: []string{"default"}}, map[string]config.Channel{
+		"ops": {Recipients: config.StringList{"ops1"}},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/send?message=door+opened", nil)
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "door opened", mock.LastCallMessage)
+	require.Equal(t, []string{"default"}, mock.LastCallRecipients)
+
+	req = httptest.NewRequest(http.MethodGet, "/send/ops?message=hi", nil)
+	resp = httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, []string{"ops1"}, mock.LastCallRecipients)
+
+	req = httptest.NewRequest(http.MethodGet, "/send?message=hi&silent=maybe", nil)
+	resp = httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	require.Equal(t, `{"success":false,"error":"invalid silent value \"maybe\""}`, trimRespBody(resp))
+}
+
+func TestNotify_UnsupportedContentType(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Notify{Bot: &mock, Recipients: []string{"user1"}}
+
+	for _, contentType := range []string{"application/xml", "text/"} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("<message>hello</message>"))
+		req.Header.Set("Content-Type", contentType)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		require.Equal(t, http.StatusUnsupportedMediaType, resp.Code, contentType)
+	}
+	require.Nil(t, mock.LastCallRecipients)
+}
+
+func TestNotify_IdempotencyKey(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Notify{
+		Bot:         &mock,
+		Recipients:  []string{"user1"},
+		Idempotency: idempotency.NewCache(time.Hour),
+	}
+
+	for i := range 2 {
+		req, resp := makeRequest(`{"message": "hello"}`)
+		req.Header.Set("Idempotency-Key", "abc")
+		handler.ServeHTTP(resp, req)
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, `{"success":true}`, trimRespBody(resp))
+		require.Equal(t, i == 1, resp.Header().Get("Idempotent-Replayed") == "true")
+	}
+	require.Len(t, mock.Calls, 1)
+
+	// the same key in the payload
+	req, resp := makeRequest(`{"message": "hello", "idempotency_key": "abc"}`)
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Len(t, mock.Calls, 1)
+
+	// different message with the same key
+	req, resp = makeRequest(`{"message": "bye", "idempotency_key": "abc"}`)
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	require.Len(t, mock.Calls, 1)
+
+	// different key
+	req, resp = makeRequest(`{"message": "hello", "idempotency_key": "def"}`)
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Len(t, mock.Calls, 2)
+}
+
+func TestNotify_IdempotencyKeyReplaysErrors(t *testing.T) {
+	mock := mockBot{Err: tgnotifier.TgApiError{TgCode: 400, Method: "sendMessage", Description: "chat not found"}}
+	handler := handlers.Notify{
+		Bot:         &mock,
+		Recipients:  []string{"user1"},
+		Idempotency: idempotency.NewCache(time.Hour),
+	}
+
+	for range 2 {
+		req, resp := makeRequest(`{"message": "hello", "idempotency_key": "abc"}`)
+		handler.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	}
+	require.Len(t, mock.Calls, 1)
+}
+
+func TestNotify_ChannelDedup(t *testing.T) {
+	mock := mockBot{}
+	mux := newChannelsMux(t, handlers.Notify{Bot: &mock, Recipients: []string{"default"}}, map[string]config.Channel{
+		"flaky": {Recipients: config.StringList{"ops1"}, ParseMode: tgnotifier.ParseModeMD, Dedup: 50 * time.Millisecond},
+	})
+	send := func(body string) {
+		req, resp := makeRequest(body)
+		req.URL.Path = "/notify/flaky"
+		mux.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+	}
+
+	send(`{"message": "down"}`)
+	send(`{"message": "down"}`)
+	send(`{"message": "down"}`)
+	// new recipient gets the message
+	send(`{"message": "down", "recipients": ["ops1", "ops2"]}`)
+	send(`{"message": "up"}`)
+
+	calls := mock.calls()
+	require.Len(t, calls, 3)
+	require.Equal(t, []string{"ops2"}, calls[1].Recipients)
+	require.Equal(t, "up", calls[2].Message)
+
+	require.Eventually(t, func() bool { return len(mock.calls()) == 4 }, time.Second, 10*time.Millisecond)
+	followUp := mock.calls()[3]
+	require.Equal(t, "down\n\n\\(repeated 3 times in 50ms\\)", followUp.Message)
+	require.Equal(t, []string{"ops1"}, followUp.Recipients)
+	require.True(t, followUp.Options.DisableNotification)
+}
+
+func TestNotify_ChannelDedupRetriesFailed(t *testing.T) {
+	mock := mockBot{Err: errors.New("connection refused")}
+	mux := newChannelsMux(t, handlers.Notify{Bot: &mock, Recipients: []string{"default"}}, map[string]config.Channel{
+		"flaky": {Recipients: config.StringList{"ops1"}, Dedup: time.Minute},
+	})
+	send := func() int {
+		req, resp := makeRequest(`{"message": "down"}`)
+		req.URL.Path = "/notify/flaky"
+		mux.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	require.Equal(t, http.StatusInternalServerError, send())
+	mock.Err = nil
+	// the retry isn't suppressed
+	require.Equal(t, http.StatusOK, send())
+	require.Len(t, mock.calls(), 2)
+	require.Equal(t, http.StatusOK, send())
+	require.Len(t, mock.calls(), 2)
+}
+
+func TestNotify_CriticalIgnoresQuietHours(t *testing.T) {
+	mock := mockBot{}
+	allDay := quiet.Rule{Recipients: []string{"user1"}, From: 0, To: 23*60 + 59, Location: time.UTC, Policy: quiet.PolicySilent}
+	handler := handlers.Notify{
+		Bot:        quiet.NewBot(&mock, []quiet.Rule{allDay}, slog.New(slog.NewTextHandler(io.Discard, nil))),
+		Recipients: []string{"user1"},
+	}
+
+	req, resp := makeRequest(`{"message": "hello"}`)
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.True(t, mock.LastCallOptions.DisableNotification)
+
+	req, resp = makeRequest(`{"message": "hello", "priority": "critical"}`)
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.False(t, mock.LastCallOptions.DisableNotification)
+}
+
+func TestNotify_DeferredByQuietHours(t *testing.T) {
+	mock := mockBot{}
+	night := quiet.Rule{Recipients: []string{"user1"}, From: 0, To: 8 * 60, Location: time.UTC, Policy: quiet.PolicyDefer}
+	bot := quiet.NewBot(&mock, []quiet.Rule{night}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	bot.Now = func() time.Time { return time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC) }
+	handler := handlers.Notify{Bot: bot, Recipients: []string{"user1"}}
+
+	req, resp := makeRequest(`{"message": "hello"}`)
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusAccepted, resp.Code)
+	require.Equal(t, `{"success":true}`, trimRespBody(resp))
+	require.Empty(t, mock.calls())
+	require.Equal(t, 1, bot.Deferred())
+
+	// invalid messages are rejected right away, instead of being deferred
+	req, resp = makeRequest(`{"message": "hello", "parse_mode": "Plain"}`)
+	handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	require.Equal(t, 1, bot.Deferred())
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+// Ntfy is ntfy compatible publishing API. Message is the raw body of
+// PUT/POST request to the "topic" path value, with its options in headers or
+// query parameters, or JSON with the topic field, if there's no path value.
+// Topics are mapped to their recipients, messages with the priority at or
+// below SilentPriority are sent without sound notification.
+//
+// See: https://docs.ntfy.sh/publish/
+type Ntfy struct {
+	Bot            tgnotifier.BotInterface
+	Groups         tgnotifier.RecipientGroups
+	Topics         map[string][]string
+	SilentPriority int
+}
+
+// ntfyMessage is a published message, it's also returned in the response.
+type ntfyMessage struct {
+	Topic    string   `json:"topic"`
+	Message  string   `json:"message"`
+	Title    string   `json:"title,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+	Click    string   `json:"click,omitempty"`
+	Markdown bool     `json:"markdown,omitempty"`
+}
+
+type ntfyResponse struct {
+	Id    string `json:"id"`
+	Time  int64  `json:"time"`
+	Event string `json:"event"`
+	ntfyMessage
+}
+
+// default priority of ntfy messages
+const ntfyDefaultPriority = 3
+
+var errTopicNotFound = errors.New("Topic not found")
+
+func (h Ntfy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.GetLogger(r.Context())
+
+	msg, err := readNtfyMessage(w, r)
+	if err != nil {
+		logger.Info("Failed to read the ntfy message", slog.Any("error", err))
+		writeResponse(w, logger, http.StatusBadRequest, err)
+		return
+	}
+	logger = logger.With(slog.String("topic", msg.Topic))
+	recipients, ok := h.Topics[msg.Topic]
+	if !ok {
+		logger.Info("Unknown ntfy topic")
+		writeResponse(w, logger, http.StatusNotFound, errTopicNotFound)
+		return
+	}
+
+	statusCode, err := sender{h.Bot, h.Groups}.sendRendered(r, logger, Message{
+		ParseMode:  tgnotifier.ParseModeHTML,
+		Recipients: recipients,
+		Options:    tgnotifier.SendOptions{DisableNotification: msg.Priority <= h.SilentPriority},
+	}, msg.render)
+	if err != nil {
+		writeResponse(w, logger, statusCode, err)
+		return
+	}
+	writeJSON(w, logger, http.StatusOK, ntfyResponse{
+		Id:          middleware.GetRequestId(r.Context()),
+		Time:        time.Now().Unix(),
+		Event:       "message",
+		ntfyMessage: msg,
+	})
+}
+
+// readNtfyMessage reads the message from the request, with the defaults applied
+func readNtfyMessage(w http.ResponseWriter, r *http.Request) (ntfyMessage, error) {
+	var msg ntfyMessage
+	if topic := r.PathValue("topic"); topic != "" {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBodySize))
+		if err != nil {
+			return msg, fmt.Errorf("error reading the payload: %w", err)
+		}
+		msg = ntfyMessage{
+			Topic:   topic,
+			Message: string(body),
+			Title:   ntfyParam(r, "X-Title", "Title", "ti", "t"),
+			Tags:    splitList(ntfyParam(r, "X-Tags", "Tags", "Tag", "ta")),
+			Click:   ntfyParam(r, "X-Click", "Click"),
+		}
+		if msg.Message == "" {
+			msg.Message = ntfyParam(r, "X-Message", "Message", "m")
+		}
+		switch strings.ToLower(ntfyParam(r, "X-Markdown", "Markdown", "md")) {
+		case "1", "yes", "true":
+			msg.Markdown = true
+		}
+		msg.Priority, err = parseNtfyPriority(ntfyParam(r, "X-Priority", "Priority", "prio", "p"))
+		if err != nil {
+			return msg, err
+		}
+	} else if err := decodeHookPayload(w, r, &msg); err != nil {
+		return msg, err
+	}
+	if msg.Priority == 0 {
+		msg.Priority = ntfyDefaultPriority
+	}
+	if msg.Priority < 1 || msg.Priority > 5 {
+		return msg, fmt.Errorf("invalid priority %d", msg.Priority)
+	}
+	if strings.TrimSpace(msg.Message) == "" {
+		// the same default as ntfy's one
+		msg.Message = "triggered"
+	}
+	return msg, nil
+}
+
+// ntfyParam returns the first found header or query parameter of the names,
+// case insensitive, as in ntfy.
+func ntfyParam(r *http.Request, names ...string) string {
+	for _, name := range names {
+		if value := r.Header.Get(name); value != "" {
+			return value
+		}
+	}
+	query := r.URL.Query()
+	for _, name := range names {
+		for key, values := range query {
+			if strings.EqualFold(key, name) && len(values) > 0 && values[0] != "" {
+				return values[0]
+			}
+		}
+	}
+	return ""
+}
+
+func parseNtfyPriority(value string) (int, error) {
+	switch strings.ToLower(value) {
+	case "":
+		return ntfyDefaultPriority, nil
+	case "min":
+		return 1, nil
+	case "low":
+		return 2, nil
+	case "default":
+		return 3, nil
+	case "high":
+		return 4, nil
+	case "max", "urgent":
+		return 5, nil
+	}
+	priority, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid priority %q", value)
+	}
+	return priority, nil
+}
+
+// render returns the message with its title, emoji tags and click link
+func (m ntfyMessage) render(parseMode string) string {
+	var emojis, tags []string
+	for _, tag := range m.Tags {
+		if emoji, ok := ntfyEmojis[tag]; ok {
+			emojis = append(emojis, emoji)
+		} else {
+			tags = append(tags, tag)
+		}
+	}
+	prefix := ""
+	if len(emojis) > 0 {
+		prefix = strings.Join(emojis, "") + " "
+	}
+
+	var lines []string
+	if m.Title != "" {
+		title := format.Bold(parseMode, m.Title)
+		if m.Click != "" {
+			title = format.Anchor(parseMode, m.Click, m.Title)
+		}
+		lines = append(lines, prefix+title)
+		prefix = ""
+	}
+	message := format.Escape(parseMode, m.Message)
+	if m.Markdown {
+		// basic markdown is the same as the Discord flavor of it
+		message = format.Discord(m.Message, parseMode)
+	}
+	lines = append(lines, prefix+message)
+	if m.Click != "" && m.Title == "" {
+		lines = append(lines, format.Anchor(parseMode, m.Click, m.Click))
+	}
+	if len(tags) > 0 {
+		lines = append(lines, format.Escape(parseMode, "Tags: "+strings.Join(tags, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ntfyEmojis are the commonly used tags, shown by ntfy as emojis
+var ntfyEmojis = map[string]string{
+	"+1":                      "👍",
+	"-1":                      "👎",
+	"bell":                    "🔔",
+	"bug":                     "🐛",
+	"calendar":                "📅",
+	"cd":                      "💿",
+	"computer":                "💻",
+	"facepalm":                "🤦",
+	"fire":                    "🔥",
+	"floppy_disk":             "💾",
+	"heavy_check_mark":        "✔️",
+	"hourglass":               "⌛",
+	"information_source":      "ℹ️",
+	"key":                     "🔑",
+	"lock":                    "🔒",
+	"loudspeaker":             "📢",
+	"no_entry":                "⛔",
+	"no_entry_sign":           "🚫",
+	"package":                 "📦",
+	"partying_face":           "🥳",
+	"rocket":                  "🚀",
+	"rotating_light":          "🚨",
+	"skull":                   "💀",
+	"stop_sign":               "🛑",
+	"tada":                    "🎉",
+	"triangular_flag_on_post": "🚩",
+	"warning":                 "⚠️",
+	"white_check_mark":        "✅",
+	"x":                       "❌",
+	"zap":                     "⚡",
+}
@@ -0,0 +1,118 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newNtfyMux(mock *mockBot) *http.ServeMux {
+	handler := handlers.Ntfy{
+		Bot:            mock,
+		Topics:         map[string][]string{"backups": {"user1"}},
+		SilentPriority: 2,
+	}
+	mux := http.NewServeMux()
+	mux.Handle("PUT /ntfy/{topic}", handler)
+	mux.Handle("POST /ntfy/{topic}", handler)
+	mux.Handle("POST /ntfy", handler)
+	return mux
+}
+
+func TestNtfy_Publish(t *testing.T) {
+	mock := mockBot{}
+	req := httptest.NewRequest(http.MethodPut, "/ntfy/backups", strings.NewReader("Backup of <db> failed"))
+	req.Header.Set("Title", "Backup")
+	req.Header.Set("Tags", "warning,db")
+	req.Header.Set("X-Click", "https://example.com/backups")
+	resp := httptest.NewRecorder()
+
+	newNtfyMux(&mock).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `⚠️ <a href="https://example.com/backups">Backup</a>
+Backup of &lt;db&gt; failed
+Tags: db`, mock.LastCallMessage)
+	assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+	assert.False(t, mock.LastCallOptions.DisableNotification)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, "message", body["event"])
+	assert.Equal(t, "backups", body["topic"])
+	assert.Equal(t, "Backup of <db> failed", body["message"])
+}
+
+func TestNtfy_Priority(t *testing.T) {
+	cases := []struct {
+		name       string
+		target     string
+		priority   string
+		wantCode   int
+		wantSilent bool
+	}{
+		{"default", "/ntfy/backups", "", http.StatusOK, false},
+		{"low by name", "/ntfy/backups", "low", http.StatusOK, true},
+		{"min by number", "/ntfy/backups", "1", http.StatusOK, true},
+		{"urgent", "/ntfy/backups", "urgent", http.StatusOK, false},
+		{"query parameter", "/ntfy/backups?p=2", "", http.StatusOK, true},
+		{"invalid", "/ntfy/backups", "6", http.StatusBadRequest, false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader("done"))
+			if tt.priority != "" {
+				req.Header.Set("X-Priority", tt.priority)
+			}
+			resp := httptest.NewRecorder()
+
+			newNtfyMux(&mock).ServeHTTP(resp, req)
+
+			require.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantSilent, mock.LastCallOptions.DisableNotification)
+		})
+	}
+}
+
+func TestNtfy_PublishJson(t *testing.T) {
+	mock := mockBot{}
+	req := httptest.NewRequest(http.MethodPost, "/ntfy", strings.NewReader(
+		`{"topic": "backups", "message": "**done**", "title": "Backup", "priority": 2, "markdown": true}`,
+	))
+	resp := httptest.NewRecorder()
+
+	newNtfyMux(&mock).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "<b>Backup</b>\n<b>done</b>", mock.LastCallMessage)
+	assert.True(t, mock.LastCallOptions.DisableNotification)
+}
+
+func TestNtfy_EmptyMessage(t *testing.T) {
+	mock := mockBot{}
+	req := httptest.NewRequest(http.MethodPost, "/ntfy/backups", nil)
+	resp := httptest.NewRecorder()
+
+	newNtfyMux(&mock).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "triggered", mock.LastCallMessage)
+}
+
+func TestNtfy_UnknownTopic(t *testing.T) {
+	mock := mockBot{}
+	req := httptest.NewRequest(http.MethodPost, "/ntfy/other", strings.NewReader("hi"))
+	resp := httptest.NewRecorder()
+
+	newNtfyMux(&mock).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Empty(t, mock.Calls)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/religiosa1/tgnotifier/internal/http/models"
+	"github.com/religiosa1/tgnotifier/internal/scheduled"
+)
+
+// Scheduled lists the pending scheduled messages, or returns and cancels the
+// one from the "id" path value, if it's present. Callers see only their own
+// messages, unless they have admin scope.
+type Scheduled struct {
+	Store *scheduled.Store
+}
+
+func (h Scheduled) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.GetLogger(r.Context())
+
+	id := r.PathValue("id")
+	if id == "" {
+		messages := []scheduled.Message{}
+		for _, msg := range h.Store.List() {
+			if canAccessScheduled(r, msg) {
+				messages = append(messages, msg)
+			}
+		}
+		writeJSON(w, logger, http.StatusOK, models.ScheduledListResponsePayload{
+			ResponsePayload: models.ResponsePayload{Success: true},
+			Scheduled:       messages,
+		})
+		return
+	}
+	logger = logger.With(slog.String("scheduled_id", id))
+
+	msg, err := h.Store.Get(id)
+	if err == nil && !canAccessScheduled(r, msg) {
+		// not revealing the messages of the others
+		err = scheduled.ErrNotFound
+	}
+	if err == nil && r.Method == http.MethodDelete {
+		msg, err = h.Store.Cancel(id)
+		if err == nil {
+			logger.Info("Scheduled message canceled")
+		}
+	}
+	if errors.Is(err, scheduled.ErrNotFound) {
+		writeResponse(w, logger, http.StatusNotFound, err)
+		return
+	}
+	if errors.Is(err, scheduled.ErrSending) {
+		logger.Info("Scheduled message is being sent, can't cancel it")
+		writeResponse(w, logger, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, logger, http.StatusOK, models.ScheduledMessageResponsePayload{
+		ResponsePayload: models.ResponsePayload{Success: true},
+		Message:         msg,
+	})
+}
+
+// canAccessScheduled reports whether the caller is allowed to see and cancel the message.
+func canAccessScheduled(r *http.Request, msg scheduled.Message) bool {
+	principal, ok := middleware.GetPrincipal(r.Context())
+	return !ok || principal.HasScope(middleware.ScopeAdmin) || msg.Owner == principalOwner(principal)
+}
+
+// principalOwner identifies the caller as the owner of the created resources.
+func principalOwner(principal middleware.Principal) string {
+	return principal.Method + ":" + principal.Name
+}
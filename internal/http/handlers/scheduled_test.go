@@ -0,0 +1,153 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/religiosa1/tgnotifier/internal/http/models"
+	"github.com/religiosa1/tgnotifier/internal/scheduled"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newScheduledStore(t *testing.T, mock *mockBot) *scheduled.Store {
+	store, err := scheduled.Open(mock, "", 0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	t.Cleanup(store.Close)
+	return store
+}
+
+func TestNotify_Scheduled(t *testing.T) {
+	mock := mockBot{}
+	store := newScheduledStore(t, &mock)
+	handler := handlers.Notify{Bot: &mock, Recipients: []string{"user1"}, Scheduled: store}
+
+	req, resp := makeRequest(`{"message": "hello", "delay": "1h", "silent": true}`)
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusAccepted, resp.Code)
+	var body models.ScheduledMessageResponsePayload
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.True(t, body.Success)
+	assert.Equal(t, "/scheduled/"+body.ID, resp.Header().Get("Location"))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), body.SendAt, time.Minute)
+	assert.Empty(t, mock.calls())
+
+	msg, err := store.Get(body.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", msg.Text)
+	assert.Equal(t, []string{"user1"}, msg.Recipients)
+	assert.True(t, msg.Silent)
+}
+
+func TestNotify_ScheduledSendAt(t *testing.T) {
+	mock := mockBot{}
+	store := newScheduledStore(t, &mock)
+	handler := handlers.Notify{Bot: &mock, Recipients: []string{"user1"}, Scheduled: store}
+
+	req, resp := makeRequest(`{"message": "hello", "send_at": "2100-01-01T09:00:00+02:00"}`)
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusAccepted, resp.Code)
+	list := store.List()
+	require.Len(t, list, 1)
+	assert.Equal(t, time.Date(2100, 1, 1, 7, 0, 0, 0, time.UTC), list[0].SendAt)
+}
+
+func TestNotify_ScheduledErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"send_at and delay", `{"message": "hello", "send_at": "2100-01-01T09:00:00Z", "delay": "1h"}`, http.StatusBadRequest},
+		{"invalid delay", `{"message": "hello", "delay": "tomorrow"}`, http.StatusBadRequest},
+		{"negative delay", `{"message": "hello", "delay": "-1h"}`, http.StatusBadRequest},
+		{"digest priority", `{"message": "hello", "delay": "1h", "priority": "digest"}`, http.StatusBadRequest},
+		{"empty message", `{"message": "", "delay": "1h"}`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			store := newScheduledStore(t, &mock)
+			handler := handlers.Notify{Bot: &mock, Recipients: []string{"user1"}, Scheduled: store}
+
+			req, resp := makeRequest(tt.body)
+			handler.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.want, resp.Code)
+			assert.Equal(t, 0, store.Len())
+		})
+	}
+}
+
+func TestNotify_ScheduledDisabled(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Notify{Bot: &mock, Recipients: []string{"user1"}}
+
+	req, resp := makeRequest(`{"message": "hello", "delay": "1h"}`)
+	handler.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Empty(t, mock.calls())
+}
+
+func TestScheduled(t *testing.T) {
+	mock := mockBot{}
+	store := newScheduledStore(t, &mock)
+	authenticator, err := middleware.ApiKeysAuthenticator([]config.ApiKey{
+		{Name: "alice", Key: "alice-key", Scopes: []string{middleware.ScopeNotify}},
+		{Name: "bob", Key: "bob-key", Scopes: []string{middleware.ScopeNotify}},
+		{Name: "admin", Key: "admin-key"},
+	})
+	require.NoError(t, err)
+	withAuth := middleware.WithAuth(authenticator)
+	mux := http.NewServeMux()
+	mux.Handle("POST /", withAuth(handlers.Notify{Bot: &mock, Recipients: []string{"user1"}, Scheduled: store}))
+	scheduledHandler := withAuth(handlers.Scheduled{Store: store})
+	mux.Handle("GET /scheduled", scheduledHandler)
+	mux.Handle("GET /scheduled/{id}", scheduledHandler)
+	mux.Handle("DELETE /scheduled/{id}", scheduledHandler)
+	call := func(method string, path string, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("x-api-key", key)
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, req)
+		return resp
+	}
+	list := func(key string) []scheduled.Message {
+		resp := call(http.MethodGet, "/scheduled", key)
+		require.Equal(t, http.StatusOK, resp.Code)
+		var body models.ScheduledListResponsePayload
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		return body.Scheduled
+	}
+
+	req, resp := makeRequest(`{"message": "hello", "delay": "1h"}`)
+	req.Header.Set("x-api-key", "alice-key")
+	mux.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusAccepted, resp.Code)
+	location := resp.Header().Get("Location")
+
+	assert.Len(t, list("alice-key"), 1)
+	assert.Len(t, list("admin-key"), 1)
+	assert.Empty(t, list("bob-key"))
+
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, location, "alice-key").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodGet, location, "bob-key").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodDelete, location, "bob-key").Code)
+	assert.Equal(t, 1, store.Len())
+
+	assert.Equal(t, http.StatusOK, call(http.MethodDelete, location, "alice-key").Code)
+	assert.Equal(t, 0, store.Len())
+	assert.Equal(t, http.StatusNotFound, call(http.MethodDelete, location, "alice-key").Code)
+	assert.Empty(t, mock.calls())
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/dedup"
+	"github.com/religiosa1/tgnotifier/internal/digest"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/religiosa1/tgnotifier/internal/http/models"
+	"github.com/religiosa1/tgnotifier/internal/quiet"
+)
+
+var (
+	errNoRecipients         = errors.New("Recipients list not provided in the request, and default recipient is not set in the config")
+	errRecipientsNotAllowed = errors.New("Sending messages to some of the recipients is not allowed")
+)
+
+// Message is a notification, sent on behalf of the request's caller.
+type Message struct {
+	Text       string
+	ParseMode  tgnotifier.ParseMode
+	Recipients []string
+	Options    tgnotifier.SendOptions
+	// optional, suppresses repeats of the message to the same recipients
+	Dedup *dedup.Suppressor
+	// optional, the message is added to the digest instead of being sent
+	Digest *digest.Digest
+	// the message is sent, ignoring the quiet hours of the recipients
+	Critical bool
+}
+
+// sender is the part shared by the handlers, sending messages.
+type sender struct {
+	Bot    tgnotifier.BotInterface
+	Groups tgnotifier.RecipientGroups
+}
+
+// send resolves recipient groups, checks the recipients against the caller's
+// restrictions and sends the message, returning the response status code.
+func (s sender) send(r *http.Request, logger *slog.Logger, msg Message) (int, error) {
+	recipients, statusCode, err := s.recipients(r, logger, msg.Recipients)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if msg.Dedup != nil {
+		if recipients = s.dedup(logger, msg, recipients); len(recipients) == 0 {
+			logger.Info("Repeated notification suppressed")
+			return http.StatusOK, nil
+		}
+	}
+
+	if msg.Digest != nil {
+		if err := msg.Digest.Add(msg.Text, msg.ParseMode, recipients); err != nil {
+			s.forget(msg, recipients)
+			return mapSendMessageErrorToHttpCode(err), err
+		}
+		logger.Info("Notification added to the digest", slog.Any("recipients", recipients))
+		return http.StatusAccepted, nil
+	}
+
+	ctx := r.Context()
+	if msg.Critical {
+		ctx = quiet.WithBypass(ctx)
+	}
+	err = tgnotifier.SendWithOptions(ctx, s.Bot, msg.Text, msg.ParseMode, recipients, msg.Options)
+	var deferred *quiet.DeferredError
+	if errors.As(err, &deferred) {
+		logger.Info("Notification deferred until the end of quiet hours",
+			slog.Any("recipients", recipients),
+			slog.Any("deferred", deferred.Recipients),
+		)
+		return http.StatusAccepted, nil
+	}
+	if err != nil {
+		logger.Error("Error sending the notification", slog.Any("error", err))
+		s.forget(msg, recipients)
+		return mapSendMessageErrorToHttpCode(err), err
+	}
+	logger.Info("Notification sent", slog.Any("recipients", recipients))
+	return http.StatusOK, nil
+}
+
+// recipients resolves recipient groups and checks the result against the
+// caller's restrictions.
+func (s sender) recipients(r *http.Request, logger *slog.Logger, recipients []string) ([]string, int, error) {
+	if len(recipients) == 0 {
+		return nil, http.StatusBadRequest, errNoRecipients
+	}
+	resolved, err := s.Groups.Resolve(recipients)
+	if err != nil {
+		logger.Error("Error resolving recipient groups", slog.Any("error", err))
+		return nil, http.StatusInternalServerError, err
+	}
+	if principal, ok := middleware.GetPrincipal(r.Context()); ok && !s.allowsRecipients(principal, resolved) {
+		logger.Info("Recipients are not allowed for the caller", slog.Any("recipients", resolved))
+		return nil, http.StatusForbidden, errRecipientsNotAllowed
+	}
+	return resolved, http.StatusOK, nil
+}
+
+// dedup returns the recipients, which haven't got the same message in the
+// dedup window yet. The others get a follow-up with the number of repeats at
+// the window close.
+func (s sender) dedup(logger *slog.Logger, msg Message, recipients []string) []string {
+	var fresh []string
+	for _, recipient := range recipients {
+		if msg.Dedup.Allow(dedupKey(msg, recipient), func(repeats int) { s.sendRepeats(logger, msg, recipient, repeats) }) {
+			fresh = append(fresh, recipient)
+		}
+	}
+	return fresh
+}
+
+// forget removes the failed message from the dedup windows of the recipients,
+// so its retries aren't suppressed.
+func (s sender) forget(msg Message, recipients []string) {
+	if msg.Dedup == nil {
+		return
+	}
+	for _, recipient := range recipients {
+		msg.Dedup.Forget(dedupKey(msg, recipient))
+	}
+}
+
+func dedupKey(msg Message, recipient string) string {
+	key := sha256.Sum256([]byte(msg.ParseMode + "\x00" + msg.Text + "\x00" + recipient))
+	return string(key[:])
+}
+
+// sendRepeats sends the message again, silently, with the number of its
+// suppressed repeats.
+func (s sender) sendRepeats(logger *slog.Logger, msg Message, recipient string, repeats int) {
+	times := "times"
+	if repeats == 1 {
+		times = "time"
+	}
+	note := format.Escape(msg.ParseMode, fmt.Sprintf("(repeated %d %s in %s)", repeats, times, format.Duration(msg.Dedup.Window)))
+	text := msg.Text + "\n\n" + note
+	if len(text) > tgnotifier.MaxMsgLen {
+		text = note
+	}
+	err := tgnotifier.SendWithOptions(context.Background(), s.Bot, text, msg.ParseMode, []string{recipient}, tgnotifier.SendOptions{DisableNotification: true})
+	if err != nil && !errors.Is(err, quiet.ErrDeferred) {
+		logger.Error("Error sending the repeats of the notification", slog.String("recipient", recipient), slog.Any("error", err))
+		return
+	}
+	logger.Info("Repeats of the notification sent", slog.String("recipient", recipient), slog.Int("repeats", repeats))
+}
+
+// allowsRecipients checks the resolved recipients against the caller's
+// allowed ones, which can be groups as well.
+func (s sender) allowsRecipients(principal middleware.Principal, recipients []string) bool {
+	allowed, err := s.Groups.Resolve(principal.Recipients)
+	if err != nil {
+		return false
+	}
+	principal.Recipients = allowed
+	return principal.AllowsRecipients(recipients)
+}
+
+func writeResponse(w http.ResponseWriter, logger *slog.Logger, statusCode int, err error) {
+	resp := models.ResponsePayload{Success: err == nil}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	writeJSON(w, logger, statusCode, resp)
+}
+
+// writeJSON writes the response as JSON, for the APIs with their own response format
+func writeJSON(w http.ResponseWriter, logger *slog.Logger, statusCode int, resp any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("Error encoding response", slog.Any("error", err))
+	}
+}
+
+func mapSendMessageErrorToHttpCode(err error) int {
+	var apiError tgnotifier.TgApiError
+	if errors.As(err, &apiError) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, tgnotifier.ErrMessageTooLong) {
+		return http.StatusRequestEntityTooLarge
+	}
+	if errors.Is(err, tgnotifier.ErrMessageEmpty) || errors.Is(err, tgnotifier.ErrParseModeInvalid) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
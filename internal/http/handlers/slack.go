@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+// Slack receives Slack incoming webhook payloads, JSON or form encoded in the
+// "payload" field, converting their mrkdwn markup into the parse mode of the
+// message. Supported block types are "header", "section", "context",
+// "divider", "image" and "actions". If Token is set, it must match the
+// "token" path value.
+//
+// See: https://api.slack.com/messaging/webhooks
+type Slack struct {
+	Bot        tgnotifier.BotInterface
+	Recipients []string
+	Groups     tgnotifier.RecipientGroups
+	ParseMode  tgnotifier.ParseMode
+	Token      string
+}
+
+type slackPayload struct {
+	Text string `json:"text"`
+	// text isn't formatted, if mrkdwn is explicitly disabled
+	Mrkdwn      *bool             `json:"mrkdwn"`
+	Blocks      []slackBlock      `json:"blocks"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackText struct {
+	// "plain_text" or "mrkdwn"
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string         `json:"type"`
+	Text     *slackText     `json:"text"`
+	Fields   []slackText    `json:"fields"`
+	Elements []slackElement `json:"elements"`
+	ImageUrl string         `json:"image_url"`
+	AltText  string         `json:"alt_text"`
+	Title    *slackText     `json:"title"`
+}
+
+// slackElement is a text object or an image in context blocks, or a button
+// in actions blocks, their text field has different types.
+type slackElement struct {
+	Type string          `json:"type"`
+	Text json.RawMessage `json:"text"`
+	Url  string          `json:"url"`
+}
+
+type slackAttachment struct {
+	Fallback   string `json:"fallback"`
+	Color      string `json:"color"`
+	Pretext    string `json:"pretext"`
+	AuthorName string `json:"author_name"`
+	AuthorLink string `json:"author_link"`
+	Title      string `json:"title"`
+	TitleLink  string `json:"title_link"`
+	Text       string `json:"text"`
+	Fields     []struct {
+		Title string `json:"title"`
+		Value string `json:"value"`
+	} `json:"fields"`
+	Footer string       `json:"footer"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+func (h Slack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.GetLogger(r.Context())
+
+	if h.Token != "" && !tokenEquals(h.Token, r.PathValue("token")) {
+		logger.Info("Invalid Slack webhook token")
+		writeResponse(w, logger, http.StatusForbidden, errors.New("Invalid token"))
+		return
+	}
+
+	var payload slackPayload
+	if err := decodeSlackPayload(w, r, &payload); err != nil {
+		logger.Info("Failed to decode the Slack payload", slog.Any("error", err))
+		writeResponse(w, logger, http.StatusBadRequest, err)
+		return
+	}
+	statusCode, err := sender{h.Bot, h.Groups}.sendRendered(r, logger, Message{
+		ParseMode:  h.ParseMode,
+		Recipients: hookRecipients(r, h.Recipients),
+	}, payload.render)
+	writeResponse(w, logger, statusCode, err)
+}
+
+// decodeSlackPayload decodes JSON body or the legacy form encoded one
+func decodeSlackPayload(w http.ResponseWriter, r *http.Request, payload *slackPayload) error {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		return decodeHookPayload(w, r, payload)
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxHookBodySize)
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("error reading the payload: %w", err)
+	}
+	if err := json.Unmarshal([]byte(r.PostForm.Get("payload")), payload); err != nil {
+		return fmt.Errorf("error decoding the payload: %w", err)
+	}
+	return nil
+}
+
+// render returns the blocks or the text, if there are no blocks, followed by
+// the attachments.
+func (p slackPayload) render(parseMode string) string {
+	var parts []string
+	if len(p.Blocks) > 0 {
+		parts = append(parts, renderSlackBlocks(p.Blocks, parseMode))
+	} else if p.Text != "" {
+		textType := "mrkdwn"
+		if p.Mrkdwn != nil && !*p.Mrkdwn {
+			textType = "plain_text"
+		}
+		parts = append(parts, slackText{Type: textType, Text: p.Text}.render(parseMode))
+	}
+	for _, attachment := range p.Attachments {
+		if text := attachment.render(parseMode); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func (t slackText) render(parseMode string) string {
+	if t.Type == "plain_text" {
+		return format.Escape(parseMode, t.Text)
+	}
+	return format.Mrkdwn(t.Text, parseMode)
+}
+
+func renderSlackBlocks(blocks []slackBlock, parseMode string) string {
+	lines := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if text := block.render(parseMode); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// render returns an empty string for unsupported blocks
+func (b slackBlock) render(parseMode string) string {
+	switch b.Type {
+	case "header":
+		if b.Text != nil {
+			return format.Bold(parseMode, b.Text.Text)
+		}
+	case "section":
+		var lines []string
+		if b.Text != nil {
+			lines = append(lines, b.Text.render(parseMode))
+		}
+		for _, field := range b.Fields {
+			lines = append(lines, field.render(parseMode))
+		}
+		return strings.Join(lines, "\n")
+	case "context":
+		var items []string
+		for _, element := range b.Elements {
+			if text, ok := element.text(); ok && element.Type != "button" {
+				items = append(items, slackText{Type: element.Type, Text: text}.render(parseMode))
+			}
+		}
+		return strings.Join(items, " ")
+	case "divider":
+		return "———"
+	case "image":
+		title := b.AltText
+		if b.Title != nil && b.Title.Text != "" {
+			title = b.Title.Text
+		}
+		return format.Anchor(parseMode, b.ImageUrl, title)
+	case "actions":
+		var links []string
+		for _, element := range b.Elements {
+			if element.Type != "button" || element.Url == "" {
+				continue
+			}
+			var text slackText
+			if err := json.Unmarshal(element.Text, &text); err == nil {
+				links = append(links, format.Anchor(parseMode, element.Url, text.Text))
+			}
+		}
+		return strings.Join(links, format.Escape(parseMode, " | "))
+	}
+	return ""
+}
+
+// text returns the text of a text object element
+func (e slackElement) text() (string, bool) {
+	var text string
+	if err := json.Unmarshal(e.Text, &text); err != nil {
+		return "", false
+	}
+	return text, true
+}
+
+func (a slackAttachment) render(parseMode string) string {
+	var lines []string
+	if a.AuthorName != "" {
+		lines = append(lines, format.Anchor(parseMode, a.AuthorLink, a.AuthorName))
+	}
+	if a.Title != "" {
+		title := format.Bold(parseMode, a.Title)
+		if a.TitleLink != "" {
+			title = format.Anchor(parseMode, a.TitleLink, a.Title)
+		}
+		lines = append(lines, title)
+	}
+	if a.Text != "" {
+		lines = append(lines, format.Mrkdwn(a.Text, parseMode))
+	}
+	for _, field := range a.Fields {
+		lines = append(lines, format.Bold(parseMode, field.Title)+format.Escape(parseMode, ": ")+format.Mrkdwn(field.Value, parseMode))
+	}
+	if len(a.Blocks) > 0 {
+		lines = append(lines, renderSlackBlocks(a.Blocks, parseMode))
+	}
+	if a.Footer != "" {
+		lines = append(lines, format.Mrkdwn(a.Footer, parseMode))
+	}
+	if len(lines) == 0 && a.Fallback != "" {
+		lines = append(lines, format.Escape(parseMode, a.Fallback))
+	}
+	if len(lines) > 0 {
+		if icon := slackColorIcon(a.Color); icon != "" {
+			lines[0] = icon + " " + lines[0]
+		}
+	}
+	if a.Pretext != "" {
+		lines = append([]string{format.Mrkdwn(a.Pretext, parseMode)}, lines...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// slackColorIcon returns icon for the predefined attachment colors
+func slackColorIcon(color string) string {
+	switch color {
+	case "good":
+		return "🟢"
+	case "warning":
+		return "🟡"
+	case "danger":
+		return "🔴"
+	}
+	return ""
+}
@@ -0,0 +1,172 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlack_Blocks(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Slack{Bot: &mock, Recipients: []string{"user1"}, ParseMode: tgnotifier.ParseModeHTML}
+	req, resp := makeHookRequest(t, "/hooks/slack", "slack_blocks.json")
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `<b>Deploy &lt;prod&gt;</b>
+<b>api</b> deployed by @U123 to <a href="https://example.com/app?x=1&amp;y=2">production</a>
+<b>Version:</b>
+<code>v1.2.3</code>
+———
+<i>took 42s</i>
+<a href="https://example.com/deploys/1">Open</a>
+
+🔴 <a href="https://example.com/checks">1 check failed</a>
+<s>smoke</s> test timed out
+<b>Region</b>: eu-west
+ci`, mock.LastCallMessage)
+	assert.Equal(t, tgnotifier.ParseModeHTML, mock.LastCallParseMode)
+	assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+}
+
+func TestSlack_Text(t *testing.T) {
+	cases := []struct {
+		name      string
+		parseMode tgnotifier.ParseMode
+		body      string
+		want      string
+	}{
+		{"html", tgnotifier.ParseModeHTML, `{"text": "*Build* <https://ci.example.com|#42> passed"}`, `<b>Build</b> <a href="https://ci.example.com">#42</a> passed`},
+		{"markdown", tgnotifier.ParseModeMD, `{"text": "*Build* <https://ci.example.com|#42> passed"}`, `*Build* [\#42](https://ci.example.com) passed`},
+		{"mrkdwn disabled", tgnotifier.ParseModeHTML, `{"text": "*Build* passed", "mrkdwn": false}`, `*Build* passed`},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			handler := handlers.Slack{Bot: &mock, Recipients: []string{"user1"}, ParseMode: tt.parseMode}
+			req := httptest.NewRequest(http.MethodPost, "/hooks/slack", strings.NewReader(tt.body))
+			resp := httptest.NewRecorder()
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, tt.want, mock.LastCallMessage)
+			assert.Equal(t, tt.parseMode, mock.LastCallParseMode)
+		})
+	}
+}
+
+func TestSlack_FormPayload(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Slack{Bot: &mock, Recipients: []string{"user1"}, ParseMode: tgnotifier.ParseModeHTML}
+	form := url.Values{"payload": {`{"text": "_hello_"}`}}
+	req := httptest.NewRequest(http.MethodPost, "/hooks/slack", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "<i>hello</i>", mock.LastCallMessage)
+}
+
+func TestSlack_EmptyPayload(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Slack{Bot: &mock, Recipients: []string{"user1"}, ParseMode: tgnotifier.ParseModeHTML}
+	req := httptest.NewRequest(http.MethodPost, "/hooks/slack", strings.NewReader(`{"text": "", "attachments": []}`))
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Empty(t, mock.Calls)
+}
+
+func TestSlack_LongMessageIsSentAsPlainText(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Slack{Bot: &mock, Recipients: []string{"user1"}, ParseMode: tgnotifier.ParseModeHTML}
+	body := `{"text": "*` + strings.Repeat("a", 5000) + `*"}`
+	req := httptest.NewRequest(http.MethodPost, "/hooks/slack", strings.NewReader(body))
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, tgnotifier.ParseMode(""), mock.LastCallParseMode)
+	assert.LessOrEqual(t, utf8.RuneCountInString(mock.LastCallMessage), 4000)
+	assert.True(t, strings.HasPrefix(mock.LastCallMessage, "aaa"))
+}
+
+func TestSlack_LongMultibyteMessageKeepsFormatting(t *testing.T) {
+	mock := mockBot{}
+	handler := handlers.Slack{Bot: &mock, Recipients: []string{"user1"}, ParseMode: tgnotifier.ParseModeHTML}
+	// 3000 characters, but 6000 bytes
+	body := `{"text": "*` + strings.Repeat("я", 3000) + `*"}`
+	req := httptest.NewRequest(http.MethodPost, "/hooks/slack", strings.NewReader(body))
+	resp := httptest.NewRecorder()
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, tgnotifier.ParseModeHTML, mock.LastCallParseMode)
+	assert.Equal(t, "<b>"+strings.Repeat("я", 3000)+"</b>", mock.LastCallMessage)
+}
+
+func TestSlack_Token(t *testing.T) {
+	cases := []struct {
+		name     string
+		target   string
+		wantCode int
+	}{
+		{"valid token", "/hooks/slack/secret", http.StatusOK},
+		{"wrong token", "/hooks/slack/wrong", http.StatusForbidden},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			mux := http.NewServeMux()
+			mux.Handle("POST /hooks/slack/{token}", handlers.Slack{
+				Bot:        &mock,
+				Recipients: []string{"user1"},
+				ParseMode:  tgnotifier.ParseModeHTML,
+				Token:      "secret",
+			})
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(`{"text": "hi"}`))
+			resp := httptest.NewRecorder()
+
+			mux.ServeHTTP(resp, req)
+
+			require.Equal(t, tt.wantCode, resp.Code)
+			if tt.wantCode != http.StatusOK {
+				assert.Empty(t, mock.Calls)
+			}
+		})
+	}
+}
+
+func TestSlack_RecipientsByQueryIgnoredOnTokenRoute(t *testing.T) {
+	mock := mockBot{}
+	mux := http.NewServeMux()
+	mux.Handle("POST /hooks/slack/{token}", handlers.Slack{
+		Bot:        &mock,
+		Recipients: []string{"user1"},
+		ParseMode:  tgnotifier.ParseModeHTML,
+		Token:      "secret",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/hooks/slack/secret?recipients=user2", strings.NewReader(`{"text": "hi"}`))
+	resp := httptest.NewRecorder()
+
+	mux.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+)
+
+// TemplateHook renders the request's JSON body into a message with the
+// template. Requests without a body (e.g. GET) are rendered with their query
+// parameters. Message, rendered to an empty string, is not sent.
+type TemplateHook struct {
+	Bot        tgnotifier.BotInterface
+	Recipients []string
+	Groups     tgnotifier.RecipientGroups
+	Name       string
+	Template   *template.Template
+	ParseMode  tgnotifier.ParseMode
+	Silent     bool
+	// if set, requests must have it in "X-Hook-Token" header or "token" query parameter
+	Token string
+}
+
+// NewTemplateHook creates the hook from its config, the caller is responsible
+// for setting Bot, Groups and default Recipients.
+func NewTemplateHook(hook config.TemplateHook) (TemplateHook, error) {
+	if err := hook.Validate(); err != nil {
+		return TemplateHook{}, err
+	}
+	tmpl, err := format.ParseTemplate(hook.Name, hook.Template, hook.ParseMode)
+	if err != nil {
+		return TemplateHook{}, err
+	}
+	h := TemplateHook{
+		Recipients: hook.Recipients,
+		Name:       hook.Name,
+		Template:   tmpl,
+		ParseMode:  hook.ParseMode,
+		Silent:     hook.Silent,
+	}
+	if hook.Auth == config.HookAuthToken {
+		h.Token = hook.Token
+	}
+	return h, nil
+}
+
+func (h TemplateHook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.GetLogger(r.Context()).With(slog.String("hook", h.Name))
+
+	if h.Token != "" && !h.verify(r) {
+		logger.Info("Invalid hook token")
+		writeResponse(w, logger, http.StatusForbidden, errors.New("Invalid token"))
+		return
+	}
+
+	data, err := h.data(w, r)
+	if err != nil {
+		logger.Info("Failed to decode the hook payload", slog.Any("error", err))
+		writeResponse(w, logger, http.StatusBadRequest, err)
+		return
+	}
+	var sb strings.Builder
+	if err := h.Template.Execute(&sb, data); err != nil {
+		logger.Info("Error executing the hook template", slog.Any("error", err))
+		writeResponse(w, logger, http.StatusUnprocessableEntity, fmt.Errorf("error executing the template: %w", err))
+		return
+	}
+	text := strings.TrimSpace(sb.String())
+	if text == "" {
+		logger.Debug("Hook template rendered an empty message, skipping")
+		writeResponse(w, logger, http.StatusOK, nil)
+		return
+	}
+
+	statusCode, err := sender{h.Bot, h.Groups}.send(r, logger, Message{
+		Text:       text,
+		ParseMode:  h.ParseMode,
+		Recipients: hookRecipients(r, h.Recipients),
+		Options:    tgnotifier.SendOptions{DisableNotification: h.Silent},
+	})
+	writeResponse(w, logger, statusCode, err)
+}
+
+func (h TemplateHook) verify(r *http.Request) bool {
+	token := r.Header.Get("X-Hook-Token")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	return tokenEquals(h.Token, token)
+}
+
+// data returns the decoded JSON body or the query parameters, if the body is empty
+func (h TemplateHook) data(w http.ResponseWriter, r *http.Request) (any, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("error reading the payload: %w", err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		query := make(map[string]any)
+		for name, values := range r.URL.Query() {
+			if name != "token" && len(values) > 0 {
+				query[name] = values[0]
+			}
+		}
+		return query, nil
+	}
+	// keeping numbers as is, so they're printed without float formatting
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var data any
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("error decoding the payload: %w", err)
+	}
+	return data, nil
+}
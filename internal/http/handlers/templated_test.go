@@ -0,0 +1,206 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/http/handlers"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const uptimeTemplate = `{{ if eq .heartbeat.status "1" }}✅{{ else }}❌{{ end }} <b>{{ .monitor.name | escape }}</b>: {{ .msg | default "no message" | escape }}`
+
+func makeTemplateHook(t *testing.T, hook config.TemplateHook, bot tgnotifier.BotInterface) handlers.TemplateHook {
+	if hook.Name == "" {
+		hook.Name = "uptime"
+	}
+	if hook.Path == "" {
+		hook.Path = "/hooks/uptime"
+	}
+	h, err := handlers.NewTemplateHook(hook)
+	require.NoError(t, err)
+	h.Bot = bot
+	return h
+}
+
+func makeTemplateHookRequest(method string, target string, body string) (*http.Request, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req, httptest.NewRecorder()
+}
+
+func TestTemplateHook_Render(t *testing.T) {
+	mock := mockBot{}
+	handler := makeTemplateHook(t, config.TemplateHook{
+		Template:   uptimeTemplate,
+		ParseMode:  "HTML",
+		Recipients: config.StringList{"user1"},
+		Silent:     true,
+	}, &mock)
+	req, resp := makeTemplateHookRequest(http.MethodPost, "/hooks/uptime",
+		`{"heartbeat": {"status": 1}, "monitor": {"name": "<site>"}, "msg": "Up & running"}`)
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "✅ <b>&lt;site&gt;</b>: Up &amp; running", mock.LastCallMessage)
+	assert.Equal(t, tgnotifier.ParseModeHTML, mock.LastCallParseMode)
+	assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+	assert.True(t, mock.LastCallOptions.DisableNotification)
+}
+
+func TestTemplateHook_QueryData(t *testing.T) {
+	mock := mockBot{}
+	handler := makeTemplateHook(t, config.TemplateHook{
+		Method:     http.MethodGet,
+		Template:   "{{ .name }} is {{ .status | default \"unknown\" }}",
+		Recipients: config.StringList{"user1"},
+	}, &mock)
+	req, resp := makeTemplateHookRequest(http.MethodGet, "/hooks/uptime?name=backup", "")
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "backup is unknown", mock.LastCallMessage)
+}
+
+func TestTemplateHook_EmptyMessageIsSkipped(t *testing.T) {
+	mock := mockBot{}
+	handler := makeTemplateHook(t, config.TemplateHook{
+		Template:   `{{ if eq .heartbeat.status "0" }}down{{ end }}`,
+		Recipients: config.StringList{"user1"},
+	}, &mock)
+	req, resp := makeTemplateHookRequest(http.MethodPost, "/hooks/uptime", `{"heartbeat": {"status": 1}}`)
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, mock.Calls)
+}
+
+func TestTemplateHook_RecipientsByQuery(t *testing.T) {
+	mock := mockBot{}
+	handler := makeTemplateHook(t, config.TemplateHook{
+		Template:   "{{ .msg }}",
+		Recipients: config.StringList{"user1"},
+	}, &mock)
+	authenticator, err := middleware.ApiKeysAuthenticator([]config.ApiKey{{Name: "key", Key: "key"}})
+	require.NoError(t, err)
+	req, resp := makeTemplateHookRequest(http.MethodPost, "/hooks/uptime?recipients=user2,user3", `{"msg": "hi"}`)
+	req.Header.Set("x-api-key", "key")
+
+	middleware.WithAuth(authenticator)(handler).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, []string{"user2", "user3"}, mock.LastCallRecipients)
+}
+
+func TestTemplateHook_RecipientsByQueryRestrictedByPrincipal(t *testing.T) {
+	mock := mockBot{}
+	handler := makeTemplateHook(t, config.TemplateHook{
+		Template:   "{{ .msg }}",
+		Recipients: config.StringList{"user1"},
+	}, &mock)
+	authenticator, err := middleware.ApiKeysAuthenticator([]config.ApiKey{
+		{Name: "restricted", Key: "key", Recipients: []string{"user1"}},
+	})
+	require.NoError(t, err)
+	req, resp := makeTemplateHookRequest(http.MethodPost, "/hooks/uptime?recipients=user2", `{"msg": "hi"}`)
+	req.Header.Set("x-api-key", "key")
+
+	middleware.WithAuth(authenticator)(handler).ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Empty(t, mock.Calls)
+}
+
+func TestTemplateHook_RecipientsByQueryIgnoredWithoutAuth(t *testing.T) {
+	for _, auth := range []string{config.HookAuthNone, config.HookAuthToken} {
+		t.Run(auth, func(t *testing.T) {
+			mock := mockBot{}
+			handler := makeTemplateHook(t, config.TemplateHook{
+				Auth:       auth,
+				Token:      "secret",
+				Template:   "{{ .msg }}",
+				Recipients: config.StringList{"user1"},
+			}, &mock)
+			req, resp := makeTemplateHookRequest(http.MethodPost, "/hooks/uptime?token=secret&recipients=user2", `{"msg": "hi"}`)
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, []string{"user1"}, mock.LastCallRecipients)
+		})
+	}
+}
+
+func TestTemplateHook_Token(t *testing.T) {
+	cases := []struct {
+		name     string
+		target   string
+		header   string
+		wantCode int
+	}{
+		{"header", "/hooks/uptime", "secret", http.StatusOK},
+		{"query", "/hooks/uptime?token=secret", "", http.StatusOK},
+		{"wrong token", "/hooks/uptime", "wrong", http.StatusForbidden},
+		{"no token", "/hooks/uptime", "", http.StatusForbidden},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockBot{}
+			handler := makeTemplateHook(t, config.TemplateHook{
+				Auth:       config.HookAuthToken,
+				Token:      "secret",
+				Template:   "{{ .msg }}",
+				Recipients: config.StringList{"user1"},
+			}, &mock)
+			req, resp := makeTemplateHookRequest(http.MethodPost, tt.target, `{"msg": "hi"}`)
+			if tt.header != "" {
+				req.Header.Set("X-Hook-Token", tt.header)
+			}
+
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, tt.wantCode, resp.Code)
+			if tt.wantCode != http.StatusOK {
+				assert.Empty(t, mock.Calls)
+			}
+		})
+	}
+}
+
+func TestTemplateHook_InvalidPayload(t *testing.T) {
+	mock := mockBot{}
+	handler := makeTemplateHook(t, config.TemplateHook{
+		Template:   "{{ .msg }}",
+		Recipients: config.StringList{"user1"},
+	}, &mock)
+	req, resp := makeTemplateHookRequest(http.MethodPost, "/hooks/uptime", `{"msg":`)
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Empty(t, mock.Calls)
+}
+
+func TestTemplateHook_TemplateError(t *testing.T) {
+	mock := mockBot{}
+	handler := makeTemplateHook(t, config.TemplateHook{
+		Template:   "{{ .msg.text }}",
+		Recipients: config.StringList{"user1"},
+	}, &mock)
+	// msg is a string, so it has no fields
+	req, resp := makeTemplateHookRequest(http.MethodPost, "/hooks/uptime", `{"msg": "hi"}`)
+
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.Empty(t, mock.Calls)
+}
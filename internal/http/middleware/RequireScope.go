@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Endpoint scopes, API keys can be restricted to.
+const (
+	ScopeNotify      = "notify"
+	ScopeHealthcheck = "healthcheck"
+	ScopeAdmin       = "admin"
+)
+
+// Scopes is the list of all supported scopes.
+var Scopes = []string{ScopeNotify, ScopeHealthcheck, ScopeAdmin}
+
+// RequireScope rejects requests of authenticated callers, who aren't allowed
+// to access the scope. Requests without a [Principal] (auth is disabled) pass.
+func RequireScope(scope string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if principal, ok := GetPrincipal(r.Context()); ok && !principal.HasScope(scope) {
+				GetLogger(r.Context()).Info("Access to the scope is denied", slog.String("scope", scope))
+				writeErrorResponse(w, r, http.StatusForbidden, "Access to the endpoint is not allowed")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
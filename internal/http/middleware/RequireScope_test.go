@@ -0,0 +1,45 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireScope(t *testing.T) {
+	authenticator, err := middleware.ApiKeysAuthenticator([]config.ApiKey{
+		{Name: "notifier", Key: "notify-key", Scopes: []string{middleware.ScopeNotify}},
+		{Name: "monitoring", Key: "health-key", Scopes: []string{middleware.ScopeHealthcheck}},
+	})
+	require.NoError(t, err)
+	handler := middleware.Chain(
+		middleware.WithAuth(authenticator),
+		middleware.RequireScope(middleware.ScopeHealthcheck),
+	)(testHandler())
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("x-api-key", "health-key")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("x-api-key", "notify-key")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestRequireScope_AuthDisabled(t *testing.T) {
+	handler := middleware.RequireScope(middleware.ScopeAdmin)(testHandler())
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+)
+
+// WithAllowedNetworks rejects requests from client IPs, resolved by
+// [WithClientIp], outside of the networks. Requests without a client IP
+// (e.g. through a unix socket) are allowed, as they're local. If networks
+// list is empty, all requests are allowed.
+func WithAllowedNetworks(networks []netip.Prefix) Middleware {
+	if len(networks) == 0 {
+		return noopHandler
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := GetClientIp(r)
+			if ip.IsValid() && !containsAddr(networks, ip) {
+				GetLogger(r.Context()).Info("Request from a not allowed network")
+				writeErrorResponse(w, r, http.StatusForbidden, "Access from your network is not allowed")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
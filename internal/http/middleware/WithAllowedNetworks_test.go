@@ -0,0 +1,63 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAllowedNetworks(t *testing.T) {
+	networks, err := middleware.ParsePrefixes([]string{"192.168.0.0/16", "2001:db8::/32", "203.0.113.7"})
+	require.NoError(t, err)
+	trusted, err := middleware.ParsePrefixes([]string{"127.0.0.1"})
+	require.NoError(t, err)
+	handler := middleware.Chain(
+		middleware.WithClientIp(trusted),
+		middleware.WithAllowedNetworks(networks),
+	)(testHandler())
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		want       int
+	}{
+		{"ipv4 network", "192.168.10.20:1234", "", http.StatusOK},
+		{"ipv6 network", "[2001:db8::5]:1234", "", http.StatusOK},
+		{"single address", "203.0.113.7:1234", "", http.StatusOK},
+		{"outside", "203.0.113.8:1234", "", http.StatusForbidden},
+		{"allowed client behind trusted proxy", "127.0.0.1:1234", "192.168.1.1", http.StatusOK},
+		{"denied client behind trusted proxy", "127.0.0.1:1234", "198.51.100.1", http.StatusForbidden},
+		{"spoofed header from untrusted address", "198.51.100.1:1234", "192.168.1.1", http.StatusForbidden},
+		{"no address (unix socket)", "@", "", http.StatusOK},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+}
+
+func TestWithAllowedNetworks_EmptyAllowsAll(t *testing.T) {
+	handler := middleware.WithAllowedNetworks(nil)(testHandler())
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "198.51.100.1:1234"
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
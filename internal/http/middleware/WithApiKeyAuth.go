@@ -3,44 +3,121 @@ package middleware
 import (
 	"crypto/sha256"
 	"crypto/subtle"
-	"encoding/json"
-	"log/slog"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/religiosa1/tgnotifier/internal/http/models"
+	"github.com/religiosa1/tgnotifier/internal/config"
 )
 
 func WithApiKeyAuth(configKey string) Middleware {
 	if configKey == "" {
 		return noopHandler
 	}
-	ctComparer := newConstantTimeComparer(configKey)
-	return func(next http.Handler) http.Handler {
-		resp := models.ResponsePayload{}
-
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			logger := GetLogger(r.Context())
-			requestKey := getRequestKey(r)
-
-			if !ctComparer.Eq(requestKey) {
-				w.Header().Set("Content-Type", "application/json")
-				if requestKey == "" {
-					resp.Error = "Authentication Required"
-					logger.Info("No authorization header is supplied")
-					w.WriteHeader(http.StatusUnauthorized)
-				} else {
-					resp.Error = "Authorization failed"
-					logger.Info("Invalid authorization key supplied")
-					w.WriteHeader(http.StatusForbidden)
-				}
-				if err := json.NewEncoder(w).Encode(resp); err != nil {
-					logger.Error("Error while writing response to client", slog.Any("error", err))
-				}
-				return
+	return WithAuth(ApiKeyAuthenticator(configKey))
+}
+
+// ApiKeyAuthenticator checks the key passed in 'x-api-key' header, cookie or
+// Authorization header against the configured one, which must not be empty.
+func ApiKeyAuthenticator(configKey string) Authenticator {
+	// a single unrestricted plain text key is always valid
+	authenticator, _ := ApiKeysAuthenticator([]config.ApiKey{{Name: "default", Key: configKey}})
+	return authenticator
+}
+
+// ApiKeysAuthenticator checks the key passed in 'x-api-key' header, cookie or
+// Authorization header against the list of named keys, enforcing their expiration. Key's scopes
+// and recipients restrictions are passed along in the [Principal].
+func ApiKeysAuthenticator(keys []config.ApiKey) (Authenticator, error) {
+	type namedKey struct {
+		config.ApiKey
+		comparer constantTimeComparer
+	}
+	namedKeys := make([]namedKey, 0, len(keys))
+	names := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if err := validateApiKey(key); err != nil {
+			return nil, err
+		}
+		if names[key.Name] {
+			return nil, fmt.Errorf("duplicate api key name %q", key.Name)
+		}
+		names[key.Name] = true
+		var comparer constantTimeComparer
+		if key.Key != "" {
+			comparer = newConstantTimeComparer(key.Key)
+		} else {
+			hash, err := parseApiKeyHash(key.KeyHash)
+			if err != nil {
+				return nil, fmt.Errorf("api key %q: %w", key.Name, err)
 			}
-			next.ServeHTTP(w, r)
-		})
+			comparer = constantTimeComparer{hash}
+		}
+		namedKeys = append(namedKeys, namedKey{key, comparer})
 	}
+
+	return AuthenticatorFunc(func(r *http.Request) (Principal, error) {
+		requestKey := getRequestKey(r)
+		if requestKey == "" {
+			return Principal{}, ErrNoCredentials
+		}
+		for _, key := range namedKeys {
+			if !key.comparer.Eq(requestKey) {
+				continue
+			}
+			if !key.ExpiresAt.IsZero() && time.Now().After(key.ExpiresAt) {
+				return Principal{}, fmt.Errorf("api key %q expired at %s", key.Name, key.ExpiresAt)
+			}
+			return Principal{
+				Name:       key.Name,
+				Method:     "api_key",
+				Scopes:     key.Scopes,
+				Recipients: key.Recipients,
+			}, nil
+		}
+		return Principal{}, errors.New("invalid api key")
+	}), nil
+}
+
+func validateApiKey(key config.ApiKey) error {
+	if key.Name == "" {
+		return errors.New("api key name must not be empty")
+	}
+	if (key.Key == "") == (key.KeyHash == "") {
+		return fmt.Errorf("api key %q: exactly one of key or key_hash must be provided", key.Name)
+	}
+	for _, scope := range key.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			return fmt.Errorf("api key %q: unknown scope %q, supported values are %s",
+				key.Name, scope, strings.Join(Scopes, ", "))
+		}
+	}
+	return nil
+}
+
+// HashApiKey returns the representation of the key to store in the config
+// instead of the plain text value.
+func HashApiKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+func parseApiKeyHash(keyHash string) ([32]byte, error) {
+	var hash [32]byte
+	hexHash, ok := strings.CutPrefix(keyHash, "sha256:")
+	if !ok {
+		return hash, errors.New(`key hash must be in the form of "sha256:<hex>"`)
+	}
+	decoded, err := hex.DecodeString(hexHash)
+	if err != nil || len(decoded) != len(hash) {
+		return hash, errors.New("key hash must be a hex encoded sha256 value")
+	}
+	copy(hash[:], decoded)
+	return hash, nil
 }
 
 func getRequestKey(r *http.Request) string {
@@ -50,9 +127,27 @@ func getRequestKey(r *http.Request) string {
 			requestKey = cookieKey.Value
 		}
 	}
+	if requestKey == "" {
+		requestKey = getAuthorizationKey(r)
+	}
 	return requestKey
 }
 
+// getAuthorizationKey returns the key from the Authorization header, for the
+// clients which can't set custom headers: either the basic auth password or
+// the bearer token. Tokens in the JWT form are left for [JwtAuthenticator].
+func getAuthorizationKey(r *http.Request) string {
+	if _, password, ok := r.BasicAuth(); ok {
+		return password
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.Count(token, ".") == 2 {
+		return ""
+	}
+	return token
+}
+
 // constantTimeComparer of string or []bytes values, with hashing of
 // provided values, so we're comparing against the same values length
 //
@@ -1,14 +1,19 @@
 package middleware_test
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/religiosa1/tgnotifier/internal/config"
 	"github.com/religiosa1/tgnotifier/internal/http/middleware"
 	"github.com/religiosa1/tgnotifier/internal/http/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const validKey = "superSecretApiKey"
@@ -60,6 +65,34 @@ func TestApiKeyAuth_Success_Cookie(t *testing.T) {
 	}
 }
 
+func TestApiKeyAuth_Authorization(t *testing.T) {
+	cases := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"bearer", "Bearer " + validKey, http.StatusOK},
+		{"bearer lowercase", "bearer " + validKey, http.StatusOK},
+		{"basic", "Basic " + base64.StdEncoding.EncodeToString([]byte("grafana:"+validKey)), http.StatusOK},
+		{"invalid bearer", "Bearer invalid", http.StatusForbidden},
+		{"invalid basic", "Basic " + base64.StdEncoding.EncodeToString([]byte(validKey+":invalid")), http.StatusForbidden},
+		{"jwt is left alone", "Bearer aaa.bbb.ccc", http.StatusUnauthorized},
+		{"unknown scheme", "Digest " + validKey, http.StatusUnauthorized},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.WithApiKeyAuth(validKey)(testHandler())
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", tt.authorization)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+}
+
 func TestApiKeyAuth_MissingKey(t *testing.T) {
 	mw := middleware.WithApiKeyAuth(validKey)
 	handler := mw(testHandler())
@@ -112,3 +145,85 @@ func TestApiKeyAuth_Disabled(t *testing.T) {
 		t.Errorf("Expected status OK with no API key required, got %d", rr.Code)
 	}
 }
+
+func TestApiKeysAuthenticator(t *testing.T) {
+	keys := []config.ApiKey{
+		{Name: "plain", Key: "plain-key", Scopes: []string{middleware.ScopeNotify}, Recipients: []string{"123"}},
+		{Name: "hashed", KeyHash: middleware.HashApiKey("hashed-key")},
+		{Name: "expired", Key: "expired-key", ExpiresAt: time.Now().Add(-time.Hour)},
+		{Name: "not-expired", Key: "not-expired-key", ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	authenticator, err := middleware.ApiKeysAuthenticator(keys)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		key      string
+		want     int
+		wantName string
+	}{
+		{"plain", "plain-key", http.StatusOK, "plain"},
+		{"hashed", "hashed-key", http.StatusOK, "hashed"},
+		{"expired", "expired-key", http.StatusForbidden, ""},
+		{"not expired", "not-expired-key", http.StatusOK, "not-expired"},
+		{"unknown", "unknown-key", http.StatusForbidden, ""},
+		{"hash instead of key", middleware.HashApiKey("hashed-key"), http.StatusForbidden, ""},
+		{"missing", "", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var principal middleware.Principal
+			handler := middleware.WithAuth(authenticator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				principal, _ = middleware.GetPrincipal(r.Context())
+			}))
+			req := httptest.NewRequest("POST", "/", nil)
+			if tt.key != "" {
+				req.Header.Set("x-api-key", tt.key)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code)
+			assert.Equal(t, tt.wantName, principal.Name)
+		})
+	}
+}
+
+func TestApiKeysAuthenticator_PassesRestrictions(t *testing.T) {
+	authenticator, err := middleware.ApiKeysAuthenticator([]config.ApiKey{
+		{Name: "ci", Key: "ci-key", Scopes: []string{middleware.ScopeNotify}, Recipients: []string{"123"}},
+	})
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("x-api-key", "ci-key")
+
+	principal, err := authenticator.Authenticate(req)
+	require.NoError(t, err)
+
+	assert.True(t, principal.HasScope(middleware.ScopeNotify))
+	assert.False(t, principal.HasScope(middleware.ScopeAdmin))
+	assert.True(t, principal.AllowsRecipients([]string{"123"}))
+	assert.False(t, principal.AllowsRecipients([]string{"123", "456"}))
+}
+
+func TestApiKeysAuthenticator_InvalidConfig(t *testing.T) {
+	cases := []struct {
+		name string
+		keys []config.ApiKey
+	}{
+		{"no name", []config.ApiKey{{Key: "key"}}},
+		{"no key", []config.ApiKey{{Name: "name"}}},
+		{"both key and hash", []config.ApiKey{{Name: "name", Key: "key", KeyHash: middleware.HashApiKey("key")}}},
+		{"bad hash prefix", []config.ApiKey{{Name: "name", KeyHash: "md5:abc"}}},
+		{"bad hash value", []config.ApiKey{{Name: "name", KeyHash: "sha256:xyz"}}},
+		{"unknown scope", []config.ApiKey{{Name: "name", Key: "key", Scopes: []string{"everything"}}}},
+		{"duplicate name", []config.ApiKey{{Name: "name", Key: "key1"}, {Name: "name", Key: "key2"}}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := middleware.ApiKeysAuthenticator(tt.keys)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/religiosa1/tgnotifier/internal/http/models"
+)
+
+// Principal describes the authenticated caller of the request.
+type Principal struct {
+	// Name identifying the caller in logs, e.g. a certificate subject
+	Name string
+	// Authentication method that was used, e.g. "api_key" or "client_cert"
+	Method string
+	// Endpoint scopes the caller is allowed to access, all if empty
+	Scopes []string
+	// Recipients the caller is allowed to send messages to, any if empty
+	Recipients []string
+}
+
+// HasScope reports whether the caller is allowed to access the scope.
+func (p Principal) HasScope(scope string) bool {
+	return len(p.Scopes) == 0 || slices.Contains(p.Scopes, scope)
+}
+
+// AllowsRecipients reports whether the caller is allowed to send messages
+// to all of the recipients.
+func (p Principal) AllowsRecipients(recipients []string) bool {
+	if len(p.Recipients) == 0 {
+		return true
+	}
+	for _, recipient := range recipients {
+		if !slices.Contains(p.Recipients, recipient) {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrNoCredentials is returned by an [Authenticator] if request doesn't carry
+// the credentials of its kind, so the next authenticator can be tried.
+var ErrNoCredentials = errors.New("no credentials supplied")
+
+// Authenticator verifies credentials of one kind, supplied with the request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// AuthenticatorFunc is an adapter to use ordinary functions as [Authenticator].
+type AuthenticatorFunc func(r *http.Request) (Principal, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (Principal, error) {
+	return f(r)
+}
+
+const authContextPrincipal = LoggingContextKey("auth_context.principal")
+
+// WithAuth authorizes requests by any of the provided authenticators.
+//
+// Authenticators are tried in order, the first one which finds its credentials
+// in the request decides the outcome. If none of them does, request is
+// rejected with 401. If no authenticators are provided, auth is disabled.
+func WithAuth(authenticators ...Authenticator) Middleware {
+	if len(authenticators) == 0 {
+		return noopHandler
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := GetLogger(r.Context())
+
+			for _, authenticator := range authenticators {
+				principal, err := authenticator.Authenticate(r)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					logger.Info("Invalid credentials supplied", slog.Any("error", err))
+					writeErrorResponse(w, r, http.StatusForbidden, "Authorization failed")
+					return
+				}
+				logger = logger.With(slog.String("principal", principal.Name), slog.String("auth_method", principal.Method))
+				ctx := context.WithValue(r.Context(), authContextPrincipal, principal)
+				ctx = context.WithValue(ctx, loggingContextLogger, logger)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			logger.Info("No authorization credentials are supplied")
+			writeErrorResponse(w, r, http.StatusUnauthorized, "Authentication Required")
+		})
+	}
+}
+
+// GetPrincipal returns the caller authenticated by [WithAuth], if any.
+func GetPrincipal(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(authContextPrincipal).(Principal)
+	return principal, ok
+}
+
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	resp := models.ResponsePayload{Error: message}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		GetLogger(r.Context()).Error("Error while writing response to client", slog.Any("error", err))
+	}
+}
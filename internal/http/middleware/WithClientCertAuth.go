@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+)
+
+func WithClientCertAuth(allowedSubjects []string) Middleware {
+	return WithAuth(ClientCertAuthenticator(allowedSubjects))
+}
+
+// ClientCertAuthenticator authorizes requests by the subject of the client
+// certificate, verified during the TLS handshake.
+//
+// Subject is matched either by its common name or its full distinguished name
+// (e.g. "CN=backup,O=Home"). If allowed subjects list is empty, any verified
+// certificate is accepted.
+func ClientCertAuthenticator(allowedSubjects []string) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (Principal, error) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			return Principal{}, ErrNoCredentials
+		}
+		cert := r.TLS.VerifiedChains[0][0]
+		subject := cert.Subject.String()
+		if len(allowedSubjects) > 0 &&
+			!slices.Contains(allowedSubjects, cert.Subject.CommonName) &&
+			!slices.Contains(allowedSubjects, subject) {
+			return Principal{}, errors.New("client certificate subject is not allowed")
+		}
+		return Principal{Name: subject, Method: "client_cert"}, nil
+	})
+}
@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func withClientCert(req *http.Request, subject pkix.Name) *http.Request {
+	cert := &x509.Certificate{Subject: subject}
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	return req
+}
+
+func TestClientCertAuth(t *testing.T) {
+	cases := []struct {
+		name    string
+		allowed []string
+		subject *pkix.Name
+		want    int
+	}{
+		{"any verified cert", nil, &pkix.Name{CommonName: "backup"}, http.StatusOK},
+		{"allowed by CN", []string{"backup"}, &pkix.Name{CommonName: "backup"}, http.StatusOK},
+		{"allowed by DN", []string{"CN=backup,O=Home"}, &pkix.Name{CommonName: "backup", Organization: []string{"Home"}}, http.StatusOK},
+		{"not allowed", []string{"backup"}, &pkix.Name{CommonName: "intruder"}, http.StatusForbidden},
+		{"no cert", []string{"backup"}, nil, http.StatusUnauthorized},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.WithClientCertAuth(tt.allowed)(testHandler())
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.subject != nil {
+				req = withClientCert(req, *tt.subject)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+}
+
+func TestWithAuth_AnyAuthenticatorSucceeds(t *testing.T) {
+	var principal middleware.Principal
+	handler := middleware.WithAuth(
+		middleware.ClientCertAuthenticator([]string{"backup"}),
+		middleware.ApiKeyAuthenticator(validKey),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = middleware.GetPrincipal(r.Context())
+	}))
+
+	req := withClientCert(httptest.NewRequest("GET", "/", nil), pkix.Name{CommonName: "backup"})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "client_cert", principal.Method)
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("x-api-key", validKey)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "api_key", principal.Method)
+
+	req = httptest.NewRequest("GET", "/", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
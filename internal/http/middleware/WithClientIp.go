@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const clientIpContextKey = LoggingContextKey("client_ip")
+
+// ParsePrefixes parses a list of CIDRs or single IP addresses.
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IP address %q: %w", value, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", value, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithClientIp resolves the client IP address of the request, honoring
+// Forwarded and X-Forwarded-For headers only if the request came from one of
+// the trusted proxies. Resolved address is available through [GetClientIp].
+func WithClientIp(trustedProxies []netip.Prefix) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolveClientIp(r, trustedProxies)
+			ctx := context.WithValue(r.Context(), clientIpContextKey, ip)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetClientIp returns the address resolved by [WithClientIp] or the address
+// from the connection, if the middleware wasn't applied. The address is
+// invalid for connections without one, such as unix sockets.
+func GetClientIp(r *http.Request) netip.Addr {
+	if ip, ok := r.Context().Value(clientIpContextKey).(netip.Addr); ok {
+		return ip
+	}
+	return remoteAddrIp(r)
+}
+
+func remoteAddrIp(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// resolveClientIp walks the proxies chain from the closest one to the client,
+// the first untrusted address is the client.
+func resolveClientIp(r *http.Request, trustedProxies []netip.Prefix) netip.Addr {
+	ip := remoteAddrIp(r)
+	if !ip.IsValid() || !containsAddr(trustedProxies, ip) {
+		return ip
+	}
+	chain := forwardedChain(r)
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip = chain[i]
+		if !containsAddr(trustedProxies, ip) {
+			break
+		}
+	}
+	return ip
+}
+
+// forwardedChain returns the addresses from Forwarded or, if it's absent,
+// X-Forwarded-For headers, ordered from the client to the closest proxy.
+func forwardedChain(r *http.Request) []netip.Addr {
+	if forwarded := r.Header.Values("Forwarded"); len(forwarded) > 0 {
+		return parseChain(forwarded, parseForwardedElement)
+	}
+	return parseChain(r.Header.Values("X-Forwarded-For"), func(value string) (netip.Addr, error) {
+		return netip.ParseAddr(strings.TrimSpace(value))
+	})
+}
+
+func parseChain(headers []string, parse func(string) (netip.Addr, error)) []netip.Addr {
+	var chain []netip.Addr
+	for _, header := range headers {
+		for _, value := range strings.Split(header, ",") {
+			addr, err := parse(value)
+			if err != nil {
+				// chain can't be trusted past the garbage or obfuscated identifiers
+				chain = nil
+				continue
+			}
+			chain = append(chain, addr.Unmap())
+		}
+	}
+	return chain
+}
+
+// parseForwardedElement extracts the "for" address from a Forwarded header
+// element, such as `for="[2001:db8::1]:4711";proto=https`
+//
+// See: https://datatracker.ietf.org/doc/html/rfc7239#section-4
+func parseForwardedElement(element string) (netip.Addr, error) {
+	for _, pair := range strings.Split(element, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !strings.EqualFold(key, "for") {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		if host, _, err := net.SplitHostPort(value); err == nil {
+			value = host
+		}
+		return netip.ParseAddr(strings.Trim(value, "[]"))
+	}
+	return netip.Addr{}, fmt.Errorf("no address in Forwarded element %q", element)
+}
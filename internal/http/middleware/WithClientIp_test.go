@@ -0,0 +1,106 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrefixes(t *testing.T) {
+	prefixes, err := middleware.ParsePrefixes([]string{"10.0.0.0/8", "192.168.1.5", "::1", "2001:db8::1/32"})
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.5/32"),
+		netip.MustParsePrefix("::1/128"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}, prefixes)
+
+	_, err = middleware.ParsePrefixes([]string{"not-an-ip"})
+	assert.Error(t, err)
+	_, err = middleware.ParsePrefixes([]string{"10.0.0.0/99"})
+	assert.Error(t, err)
+}
+
+func TestWithClientIp(t *testing.T) {
+	trusted, err := middleware.ParsePrefixes([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{"direct", "203.0.113.5:1234", nil, "203.0.113.5"},
+		{"untrusted proxy header ignored", "203.0.113.5:1234", []string{"198.51.100.1"}, "203.0.113.5"},
+		{"trusted proxy", "10.0.0.1:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"proxies chain", "10.0.0.1:1234", []string{"198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"spoofed header before untrusted hop", "10.0.0.1:1234", []string{"1.1.1.1, 198.51.100.1"}, "198.51.100.1"},
+		{"multiple headers", "10.0.0.1:1234", []string{"198.51.100.1", "10.0.0.2"}, "198.51.100.1"},
+		{"trusted proxy without header", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"ipv4 mapped ipv6", "[::ffff:203.0.113.5]:1234", nil, "203.0.113.5"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var ip netip.Addr
+			handler := middleware.WithClientIp(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ip = middleware.GetClientIp(r)
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, xff := range tt.xff {
+				req.Header.Add("X-Forwarded-For", xff)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, ip.String())
+		})
+	}
+}
+
+func TestWithClientIp_ForwardedHeader(t *testing.T) {
+	trusted, err := middleware.ParsePrefixes([]string{"10.0.0.0/8", "fd00::/8"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		xff        string
+		want       string
+	}{
+		{"ipv4", "10.0.0.1:1234", "for=198.51.100.1", "", "198.51.100.1"},
+		{"quoted ipv6 with port", "10.0.0.1:1234", `for="[2001:db8::1]:4711";proto=https`, "", "2001:db8::1"},
+		{"chain", "10.0.0.1:1234", "for=198.51.100.1, for=10.0.0.2;by=10.0.0.1", "", "198.51.100.1"},
+		{"case insensitive key", "10.0.0.1:1234", "For=198.51.100.1", "", "198.51.100.1"},
+		{"takes priority over xff", "10.0.0.1:1234", "for=198.51.100.1", "198.51.100.2", "198.51.100.1"},
+		{"obfuscated identifier", "10.0.0.1:1234", "for=_hidden, for=10.0.0.2", "", "10.0.0.2"},
+		{"untrusted proxy", "203.0.113.5:1234", "for=198.51.100.1", "", "203.0.113.5"},
+		{"trusted ipv6 proxy", "[fd00::1]:1234", "for=198.51.100.1", "", "198.51.100.1"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var ip netip.Addr
+			handler := middleware.WithClientIp(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ip = middleware.GetClientIp(r)
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Forwarded", tt.forwarded)
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, ip.String())
+		})
+	}
+}
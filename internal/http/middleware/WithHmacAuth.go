@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/config"
+)
+
+const (
+	SignatureHeader = "X-Signature"
+	TimestampHeader = "X-Timestamp"
+)
+
+// DefaultHmacWindow is the default maximum allowed difference between the
+// request timestamp and the server time.
+const DefaultHmacWindow = 5 * time.Minute
+
+// maximum size of a signed body, read into memory for verification
+const maxSignedBodySize = 1 << 20
+
+func WithHmacAuth(secret string, window time.Duration) Middleware {
+	if secret == "" {
+		return noopHandler
+	}
+	authenticator, _ := HmacAuthenticator([]config.ApiKey{{Name: "default", Key: secret}}, window)
+	return WithAuth(authenticator)
+}
+
+// SignRequest returns the value of [SignatureHeader] for the request with the
+// method, request URI (path with the query) and body, sent with the given unix
+// timestamp in [TimestampHeader].
+//
+// Signature is HMAC-SHA256 of "<timestamp>.<method>.<request URI>.<body>", so
+// the timestamp can't be altered to replay the request later, and the
+// signature can't be replayed against another endpoint.
+func SignRequest(secret string, timestamp int64, method string, requestUri string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write([]byte(method))
+	mac.Write([]byte("."))
+	mac.Write([]byte(requestUri))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HmacAuthenticator verifies request signature in [SignatureHeader], made
+// with one of the plain text API keys as a secret. Hashed keys can't be used
+// for signing. Requests with timestamps outside of the window or repeating an
+// already seen signature are rejected.
+func HmacAuthenticator(keys []config.ApiKey, window time.Duration) (Authenticator, error) {
+	var signingKeys []config.ApiKey
+	for _, key := range keys {
+		if err := validateApiKey(key); err != nil {
+			return nil, err
+		}
+		if key.Key != "" {
+			signingKeys = append(signingKeys, key)
+		}
+	}
+	if window <= 0 {
+		window = DefaultHmacWindow
+	}
+	seen := newSeenSignatures(window)
+
+	return AuthenticatorFunc(func(r *http.Request) (Principal, error) {
+		signature := r.Header.Get(SignatureHeader)
+		if signature == "" {
+			return Principal{}, ErrNoCredentials
+		}
+		timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		if err != nil {
+			return Principal{}, fmt.Errorf("invalid or missing %s header", TimestampHeader)
+		}
+		if diff := time.Since(time.Unix(timestamp, 0)); diff > window || diff < -window {
+			return Principal{}, errors.New("request timestamp is outside of the allowed window")
+		}
+		if !strings.HasPrefix(signature, "sha256=") {
+			return Principal{}, errors.New(`signature must be in the form of "sha256=<hex>"`)
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+		if err != nil {
+			return Principal{}, fmt.Errorf("error reading request body: %w", err)
+		}
+		if len(body) > maxSignedBodySize {
+			return Principal{}, errors.New("signed request body is too large")
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		for _, key := range signingKeys {
+			expected := SignRequest(key.Key, timestamp, r.Method, r.URL.RequestURI(), body)
+			if !hmac.Equal([]byte(expected), []byte(signature)) {
+				continue
+			}
+			if !key.ExpiresAt.IsZero() && time.Now().After(key.ExpiresAt) {
+				return Principal{}, fmt.Errorf("api key %q expired at %s", key.Name, key.ExpiresAt)
+			}
+			if !seen.Add(signature) {
+				return Principal{}, errors.New("request signature was already used")
+			}
+			return Principal{
+				Name:       key.Name,
+				Method:     "hmac",
+				Scopes:     key.Scopes,
+				Recipients: key.Recipients,
+			}, nil
+		}
+		return Principal{}, errors.New("invalid request signature")
+	}), nil
+}
+
+// seenSignatures remembers signatures within the replay window, as a request
+// can be replayed as is, while its timestamp is still valid.
+type seenSignatures struct {
+	mu         sync.Mutex
+	window     time.Duration
+	signatures map[string]time.Time
+}
+
+func newSeenSignatures(window time.Duration) *seenSignatures {
+	return &seenSignatures{window: window, signatures: make(map[string]time.Time)}
+}
+
+// Add records the signature, returning false if it was already seen.
+func (s *seenSignatures) Add(signature string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for sig, seenAt := range s.signatures {
+		// timestamps can be in the future by the window too
+		if now.Sub(seenAt) > 2*s.window {
+			delete(s.signatures, sig)
+		}
+	}
+	if _, ok := s.signatures[signature]; ok {
+		return false
+	}
+	s.signatures[signature] = now
+	return true
+}
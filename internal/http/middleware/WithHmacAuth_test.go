@@ -0,0 +1,125 @@
+package middleware_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+const signedBody = `{"message":"hello"}`
+
+func makeSignedRequest(secret string, timestamp int64, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set(middleware.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(middleware.SignatureHeader, middleware.SignRequest(secret, timestamp, "POST", "/", []byte(body)))
+	return req
+}
+
+func TestHmacAuth_Success(t *testing.T) {
+	var receivedBody string
+	handler := middleware.WithHmacAuth(validKey, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receivedBody = string(body)
+	}))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, makeSignedRequest(validKey, time.Now().Unix(), signedBody))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, signedBody, receivedBody, "body must still be readable by the handler")
+}
+
+func TestHmacAuth_Rejected(t *testing.T) {
+	now := time.Now().Unix()
+	cases := []struct {
+		name string
+		req  func() *http.Request
+		want int
+	}{
+		{"no signature", func() *http.Request {
+			return httptest.NewRequest("POST", "/", strings.NewReader(signedBody))
+		}, http.StatusUnauthorized},
+		{"wrong secret", func() *http.Request {
+			return makeSignedRequest("wrongKey", now, signedBody)
+		}, http.StatusForbidden},
+		{"tampered body", func() *http.Request {
+			req := makeSignedRequest(validKey, now, signedBody)
+			req.Body = io.NopCloser(strings.NewReader(`{"message":"evil"}`))
+			return req
+		}, http.StatusForbidden},
+		{"tampered timestamp", func() *http.Request {
+			req := makeSignedRequest(validKey, now, signedBody)
+			req.Header.Set(middleware.TimestampHeader, strconv.FormatInt(now+1, 10))
+			return req
+		}, http.StatusForbidden},
+		{"other method", func() *http.Request {
+			req := makeSignedRequest(validKey, now, signedBody)
+			req.Method = http.MethodPut
+			return req
+		}, http.StatusForbidden},
+		{"other path", func() *http.Request {
+			req := makeSignedRequest(validKey, now, signedBody)
+			req.URL.Path = "/batch"
+			return req
+		}, http.StatusForbidden},
+		{"other query", func() *http.Request {
+			req := makeSignedRequest(validKey, now, signedBody)
+			req.URL.RawQuery = "recipients=123"
+			return req
+		}, http.StatusForbidden},
+		{"missing timestamp", func() *http.Request {
+			req := makeSignedRequest(validKey, now, signedBody)
+			req.Header.Del(middleware.TimestampHeader)
+			return req
+		}, http.StatusForbidden},
+		{"stale timestamp", func() *http.Request {
+			return makeSignedRequest(validKey, now-120, signedBody)
+		}, http.StatusForbidden},
+		{"future timestamp", func() *http.Request {
+			return makeSignedRequest(validKey, now+120, signedBody)
+		}, http.StatusForbidden},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.WithHmacAuth(validKey, time.Minute)(testHandler())
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, tt.req())
+
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+}
+
+func TestHmacAuth_ReplayRejected(t *testing.T) {
+	handler := middleware.WithHmacAuth(validKey, time.Minute)(testHandler())
+	now := time.Now().Unix()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, makeSignedRequest(validKey, now, signedBody))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, makeSignedRequest(validKey, now, signedBody))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestHmacAuth_ComposesWithChain(t *testing.T) {
+	handler := middleware.Chain(
+		middleware.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		middleware.WithHmacAuth(validKey, time.Minute),
+	)(testHandler())
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, makeSignedRequest(validKey, time.Now().Unix(), signedBody))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
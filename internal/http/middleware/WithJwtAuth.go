@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/religiosa1/tgnotifier/internal/jwt"
+)
+
+// JwtClaimsMapping names the token claims, mapped onto the [Principal] restrictions.
+type JwtClaimsMapping struct {
+	// claim with the list of allowed recipients, not restricted if empty
+	Recipients string
+	// claim with the list (or space-separated string) of allowed scopes, not restricted if empty
+	Scopes string
+}
+
+func WithJwtAuth(verifier *jwt.Verifier, mapping JwtClaimsMapping) Middleware {
+	return WithAuth(JwtAuthenticator(verifier, mapping))
+}
+
+// JwtAuthenticator verifies the token, passed in "Authorization: Bearer" header.
+func JwtAuthenticator(verifier *jwt.Verifier, mapping JwtClaimsMapping) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (Principal, error) {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return Principal{}, ErrNoCredentials
+		}
+		claims, err := verifier.Verify(r.Context(), strings.TrimSpace(token))
+		if err != nil {
+			return Principal{}, err
+		}
+		principal := Principal{Name: claims.Subject(), Method: "jwt"}
+		// empty restrictions mean unrestricted access, so a token without the
+		// configured claims is rejected altogether
+		if mapping.Recipients != "" {
+			principal.Recipients = claims.Strings(mapping.Recipients, false)
+			if len(principal.Recipients) == 0 {
+				return Principal{}, fmt.Errorf("token has no %q claim", mapping.Recipients)
+			}
+		}
+		if mapping.Scopes != "" {
+			principal.Scopes = claims.Strings(mapping.Scopes, true)
+			if len(principal.Scopes) == 0 {
+				return Principal{}, fmt.Errorf("token has no %q claim", mapping.Scopes)
+			}
+		}
+		return principal, nil
+	})
+}
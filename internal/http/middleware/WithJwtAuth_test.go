@@ -0,0 +1,90 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/religiosa1/tgnotifier/internal/jwt"
+	"github.com/religiosa1/tgnotifier/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJwtAuth(t *testing.T) {
+	signer := test.NewJwtSigner(t, "ES256", "key-1")
+	keys, err := jwt.ParseKeySet(test.Jwks(t, signer))
+	require.NoError(t, err)
+	verifier := &jwt.Verifier{Keys: keys, Audience: "tgnotifier"}
+	mapping := middleware.JwtClaimsMapping{Recipients: "tg_recipients", Scopes: "scope"}
+
+	validClaims := map[string]any{
+		"sub":           "ci-runner",
+		"aud":           "tgnotifier",
+		"exp":           time.Now().Add(time.Hour).Unix(),
+		"tg_recipients": []any{"123", 456},
+		"scope":         "notify",
+	}
+	withoutRecipients := map[string]any{"sub": "ci-runner", "aud": "tgnotifier", "scope": "notify"}
+
+	cases := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"valid", "Bearer " + signer.Sign(t, validClaims), http.StatusOK},
+		{"lowercase scheme", "bearer " + signer.Sign(t, validClaims), http.StatusOK},
+		{"no header", "", http.StatusUnauthorized},
+		{"basic auth", "Basic dXNlcjpwYXNz", http.StatusUnauthorized},
+		{"invalid token", "Bearer garbage", http.StatusForbidden},
+		{"missing mapped claim", "Bearer " + signer.Sign(t, withoutRecipients), http.StatusForbidden},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var principal middleware.Principal
+			handler := middleware.WithJwtAuth(verifier, mapping)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				principal, _ = middleware.GetPrincipal(r.Context())
+			}))
+			req := httptest.NewRequest("POST", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.want, rr.Code)
+			if tt.want == http.StatusOK {
+				assert.Equal(t, "ci-runner", principal.Name)
+				assert.Equal(t, "jwt", principal.Method)
+				assert.Equal(t, []string{"123", "456"}, principal.Recipients)
+				assert.True(t, principal.HasScope(middleware.ScopeNotify))
+				assert.False(t, principal.HasScope(middleware.ScopeAdmin))
+			}
+		})
+	}
+}
+
+func TestJwtAuth_AlongsideApiKey(t *testing.T) {
+	signer := test.NewJwtSigner(t, "EdDSA", "")
+	keys, err := jwt.ParseKeySet(test.Jwks(t, signer))
+	require.NoError(t, err)
+	handler := middleware.WithAuth(
+		middleware.ApiKeyAuthenticator(validKey),
+		middleware.JwtAuthenticator(&jwt.Verifier{Keys: keys}, middleware.JwtClaimsMapping{}),
+	)(testHandler())
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+signer.Sign(t, map[string]any{"sub": "svc", "exp": time.Now().Add(time.Hour).Unix()}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req = httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("x-api-key", validKey)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
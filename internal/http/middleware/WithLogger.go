@@ -28,10 +28,15 @@ func WithLogger(logger *slog.Logger) Middleware {
 			ctx := context.WithValue(r.Context(), loggingContextRequestId, id)
 			ctx = context.WithValue(ctx, loggingContextLogger, newLogger)
 
+			clientIp := ""
+			if ip := GetClientIp(r); ip.IsValid() {
+				clientIp = ip.String()
+			}
 			newLogger.Info("Incoming request",
 				slog.String("method", r.Method),
 				slog.String("path", r.URL.Path),
 				slog.String("remote_addr", r.RemoteAddr),
+				slog.String("client_ip", clientIp),
 				slog.String("user_agent", r.UserAgent()),
 			)
 			t1 := time.Now()
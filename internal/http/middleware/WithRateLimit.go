@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/ratelimit"
+)
+
+// RateLimitFromConfig converts the config value into the limiter's one.
+func RateLimitFromConfig(cfg config.RateLimit) ratelimit.Limit {
+	return ratelimit.Every(cfg.Requests, cfg.Per, cfg.Burst)
+}
+
+// WithIpRateLimit limits requests per client IP, as resolved by [WithClientIp].
+// Requests without a client IP (e.g. through a unix socket) aren't limited.
+func WithIpRateLimit(limiter *ratelimit.Limiter, limit config.RateLimit) Middleware {
+	l := RateLimitFromConfig(limit)
+	if l.IsZero() {
+		return noopHandler
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := GetClientIp(r)
+			if ip.IsValid() {
+				if ok, retryAfter := limiter.Allow("ip:"+ip.String(), l); !ok {
+					writeTooManyRequests(w, r, retryAfter)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithKeyRateLimit limits requests per authenticated caller, with optional
+// overrides for the named API keys. Requests without a [Principal] aren't limited.
+func WithKeyRateLimit(limiter *ratelimit.Limiter, limit config.RateLimit, apiKeys []config.ApiKey) Middleware {
+	keyLimit := KeyRateLimit{Limiter: limiter, Limit: limit, ApiKeys: apiKeys}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if keyLimit.AllowN(w, r, 1) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// KeyRateLimit is the limit per authenticated caller, for the handlers doing
+// several operations in one request on top of [WithKeyRateLimit].
+type KeyRateLimit struct {
+	Limiter *ratelimit.Limiter
+	Limit   config.RateLimit
+	ApiKeys []config.ApiKey
+}
+
+// AllowN takes n tokens from the caller's bucket, responding with 429 error
+// if there's not enough of them. Requests without a [Principal] or with no
+// limiter aren't limited.
+func (k KeyRateLimit) AllowN(w http.ResponseWriter, r *http.Request, n int) bool {
+	principal, ok := GetPrincipal(r.Context())
+	if !ok || k.Limiter == nil {
+		return true
+	}
+	key, l := PrincipalRateLimit(principal, k.Limit, k.ApiKeys)
+	if ok, retryAfter := k.Limiter.AllowN(key, l, n); !ok {
+		writeTooManyRequests(w, r, retryAfter)
+		return false
+	}
+	return true
+}
+
+// Burst returns the caller's burst, the maximum number of tokens that can be
+// taken at once; false if the caller isn't limited.
+func (k KeyRateLimit) Burst(r *http.Request) (int, bool) {
+	principal, ok := GetPrincipal(r.Context())
+	if !ok || k.Limiter == nil {
+		return 0, false
+	}
+	_, l := PrincipalRateLimit(principal, k.Limit, k.ApiKeys)
+	if l.IsZero() {
+		return 0, false
+	}
+	return l.Burst, true
+}
+
+// PrincipalRateLimit returns the limiter key and the limit for the caller.
+func PrincipalRateLimit(principal Principal, limit config.RateLimit, apiKeys []config.ApiKey) (string, ratelimit.Limit) {
+	// key signing requests and passed as is share the same bucket
+	if principal.Method == "api_key" || principal.Method == "hmac" {
+		for _, key := range apiKeys {
+			if key.Name == principal.Name && key.RateLimit != nil {
+				limit = *key.RateLimit
+				break
+			}
+		}
+		return "key:" + principal.Name, RateLimitFromConfig(limit)
+	}
+	return principal.Method + ":" + principal.Name, RateLimitFromConfig(limit)
+}
+
+func writeTooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	GetLogger(r.Context()).Info("Rate limit exceeded", slog.Duration("retry_after", retryAfter))
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	writeErrorResponse(w, r, http.StatusTooManyRequests, "Too many requests")
+}
@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/http/middleware"
+	"github.com/religiosa1/tgnotifier/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIpRateLimit(t *testing.T) {
+	handler := middleware.WithIpRateLimit(ratelimit.NewLimiter(), config.RateLimit{Requests: 2, Per: time.Minute})(testHandler())
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusOK, request("203.0.113.5:1000").Code)
+	assert.Equal(t, http.StatusOK, request("203.0.113.5:1001").Code)
+	rr := request("203.0.113.5:1002")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+	assert.Contains(t, parseResponse(t, rr).Error, "Too many requests")
+
+	assert.Equal(t, http.StatusOK, request("203.0.113.6:1000").Code, "other IPs aren't affected")
+}
+
+func TestKeyRateLimit(t *testing.T) {
+	keys := []config.ApiKey{
+		{Name: "default-limit", Key: "key1"},
+		{Name: "custom-limit", Key: "key2", RateLimit: &config.RateLimit{Requests: 3, Per: time.Minute}},
+	}
+	authenticator, err := middleware.ApiKeysAuthenticator(keys)
+	require.NoError(t, err)
+	handler := middleware.Chain(
+		middleware.WithAuth(authenticator),
+		middleware.WithKeyRateLimit(ratelimit.NewLimiter(), config.RateLimit{Requests: 1, Per: time.Minute}, keys),
+	)(testHandler())
+	request := func(key string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("x-api-key", key)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("key1"))
+	assert.Equal(t, http.StatusTooManyRequests, request("key1"))
+
+	for range 3 {
+		assert.Equal(t, http.StatusOK, request("key2"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, request("key2"))
+}
+
+func TestKeyRateLimit_Disabled(t *testing.T) {
+	authenticator := middleware.ApiKeyAuthenticator(validKey)
+	handler := middleware.Chain(
+		middleware.WithAuth(authenticator),
+		middleware.WithKeyRateLimit(ratelimit.NewLimiter(), config.RateLimit{}, nil),
+	)(testHandler())
+
+	for range 10 {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("x-api-key", validKey)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+// BatchResponsePayload is the response to a batch request, with the results
+// of its items in the same order as in the request.
+type BatchResponsePayload struct {
+	Success bool              `json:"success"`
+	Results []BatchItemResult `json:"results"`
+}
+
+type BatchItemResult struct {
+	Success bool `json:"success"`
+	// HTTP status code, the item would get as a separate request
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// id of the scheduled message, if the item has the send time
+	ScheduledId string `json:"scheduled_id,omitempty"`
+	// the result is of the earlier request with the same idempotency key
+	Replayed bool `json:"replayed,omitempty"`
+}
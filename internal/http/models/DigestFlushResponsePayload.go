@@ -0,0 +1,7 @@
+package models
+
+type DigestFlushResponsePayload struct {
+	ResponsePayload
+	// number of the sent messages, counted per recipient
+	Messages int `json:"messages"`
+}
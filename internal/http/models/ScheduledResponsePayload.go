@@ -0,0 +1,16 @@
+package models
+
+import "github.com/religiosa1/tgnotifier/internal/scheduled"
+
+// ScheduledMessageResponsePayload is the response with a single scheduled
+// message, e.g. the created one.
+type ScheduledMessageResponsePayload struct {
+	ResponsePayload
+	scheduled.Message
+}
+
+type ScheduledListResponsePayload struct {
+	ResponsePayload
+	// pending messages in the order of sending
+	Scheduled []scheduled.Message `json:"scheduled"`
+}
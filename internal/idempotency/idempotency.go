@@ -0,0 +1,116 @@
+// Package idempotency keeps results of the requests by their idempotency keys,
+// so retried requests get the original result instead of being repeated.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrKeyReused is returned if the key was used for a different request.
+	ErrKeyReused = errors.New("Idempotency key was already used for a different request")
+	// ErrAborted is returned to the repeated requests, if the original one
+	// didn't finish, e.g. due to a panic.
+	ErrAborted = errors.New("Request with the same idempotency key was aborted")
+)
+
+// Result of the request, as returned by the handlers.
+type Result struct {
+	StatusCode int
+	Err        error
+	// optional value of the result, e.g. the created resource
+	Value any
+}
+
+// how often expired results are removed from memory
+const sweepInterval = time.Minute
+
+// Cache keeps the results of the requests in memory for the Ttl period.
+type Cache struct {
+	// clock, defaults to time.Now
+	Now func() time.Time
+	Ttl time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	// fingerprint of the request, to detect reuse of the key
+	fingerprint string
+	// closed, when the request is finished
+	done     chan struct{}
+	finished bool
+	result   Result
+	// zero, while the request is in flight
+	expires time.Time
+}
+
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{Now: time.Now, Ttl: ttl, entries: make(map[string]*entry)}
+}
+
+// Do calls fn once per key, returning the kept result for the repeated
+// requests with the same key and fingerprint, waiting for it if the original
+// request is still in flight. The returned flag reports if the result is a
+// replayed one. Results with server errors aren't kept, so the request can be
+// retried.
+func (c *Cache) Do(ctx context.Context, key string, fingerprint string, fn func() Result) (Result, bool, error) {
+	c.mu.Lock()
+	now := c.Now()
+	c.sweep(now)
+	if e, ok := c.entries[key]; ok && !e.expired(now) {
+		c.mu.Unlock()
+		if e.fingerprint != fingerprint {
+			return Result{}, false, ErrKeyReused
+		}
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return Result{}, false, ctx.Err()
+		}
+		if !e.finished {
+			return Result{}, false, ErrAborted
+		}
+		return e.result, true, nil
+	}
+	e := &entry{fingerprint: fingerprint, done: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		if e.finished && e.result.StatusCode < http.StatusInternalServerError {
+			e.expires = c.Now().Add(c.Ttl)
+		} else {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+		close(e.done)
+	}()
+	e.result = fn()
+	e.finished = true
+	return e.result, false, nil
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// sweep removes the expired results
+func (c *Cache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < sweepInterval {
+		return
+	}
+	c.lastSweep = now
+	for key, e := range c.entries {
+		if e.expired(now) {
+			delete(c.entries, key)
+		}
+	}
+}
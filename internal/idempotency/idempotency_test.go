@@ -0,0 +1,116 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/idempotency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestCache() (*idempotency.Cache, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cache := idempotency.NewCache(time.Hour)
+	cache.Now = clock.Now
+	return cache, clock
+}
+
+func TestCache_ReplaysResult(t *testing.T) {
+	cache, clock := newTestCache()
+	calls := 0
+	fn := func() idempotency.Result {
+		calls++
+		return idempotency.Result{StatusCode: http.StatusOK}
+	}
+
+	result, replayed, err := cache.Do(context.Background(), "key", "a", fn)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	clock.Advance(59 * time.Minute)
+	result, replayed, err = cache.Do(context.Background(), "key", "a", fn)
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, 1, calls)
+
+	// expired
+	clock.Advance(time.Minute)
+	_, replayed, err = cache.Do(context.Background(), "key", "a", fn)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCache_KeyReused(t *testing.T) {
+	cache, _ := newTestCache()
+	fn := func() idempotency.Result { return idempotency.Result{StatusCode: http.StatusOK} }
+
+	_, _, err := cache.Do(context.Background(), "key", "a", fn)
+	require.NoError(t, err)
+	_, _, err = cache.Do(context.Background(), "key", "b", fn)
+	assert.ErrorIs(t, err, idempotency.ErrKeyReused)
+}
+
+func TestCache_ServerErrorsAreNotKept(t *testing.T) {
+	cache, _ := newTestCache()
+	calls := 0
+	fn := func() idempotency.Result {
+		calls++
+		return idempotency.Result{StatusCode: http.StatusInternalServerError, Err: errors.New("boom")}
+	}
+
+	for range 2 {
+		_, replayed, err := cache.Do(context.Background(), "key", "a", fn)
+		require.NoError(t, err)
+		assert.False(t, replayed)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestCache_WaitsForInFlight(t *testing.T) {
+	cache, _ := newTestCache()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	calls := 0
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cache.Do(context.Background(), "key", "a", func() idempotency.Result {
+			calls++
+			close(started)
+			<-release
+			return idempotency.Result{StatusCode: http.StatusOK}
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, _, err := cache.Do(ctx, "key", "a", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+	result, replayed, err := cache.Do(context.Background(), "key", "a", nil)
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	wg.Wait()
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,273 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Key is a public key from a JWK set.
+type Key struct {
+	Kid string
+	// algorithm the key is intended for, can be empty
+	Alg    string
+	Public crypto.PublicKey
+}
+
+// KeySource provides verification keys for the token key id. If kid is empty,
+// all of the available keys are returned.
+type KeySource interface {
+	Keys(ctx context.Context, kid string) ([]Key, error)
+}
+
+// KeySet is a static set of keys.
+type KeySet []Key
+
+func (ks KeySet) Keys(ctx context.Context, kid string) ([]Key, error) {
+	if kid == "" {
+		return ks, nil
+	}
+	var keys []Key
+	for _, key := range ks {
+		if key.Kid == kid {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC and OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseKeySet parses JWKS JSON document. Keys not intended for signatures and
+// of unsupported types are skipped.
+//
+// See: https://datatracker.ietf.org/doc/html/rfc7517#section-5
+func ParseKeySet(data []byte) (KeySet, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error decoding JWKS: %w", err)
+	}
+	var ks KeySet
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		public, err := k.publicKey()
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding JWKS key %q: %w", k.Kid, err)
+		}
+		ks = append(ks, Key{Kid: k.Kid, Alg: k.Alg, Public: public})
+	}
+	return ks, nil
+}
+
+var errUnsupportedKey = errors.New("unsupported key type")
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errUnsupportedKey
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errUnsupportedKey
+		}
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, errUnsupportedKey
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := decodeSegment(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// minRefreshInterval limits refetching of JWKS on unknown key ids
+const minRefreshInterval = time.Minute
+
+// JwksSource loads keys from a JWKS file or URL, caching them for the ttl.
+type JwksSource struct {
+	location string
+	ttl      time.Duration
+	client   *http.Client
+
+	mu        sync.Mutex
+	keys      KeySet
+	err       error
+	fetchedAt time.Time
+	// closed, when the in-flight refresh is done; nil if there's none
+	refreshing chan struct{}
+}
+
+// NewJwksSource creates a key source for the JWKS location, which is either
+// a http(s) URL or a file path.
+func NewJwksSource(location string, ttl time.Duration, client *http.Client) *JwksSource {
+	return &JwksSource{location: location, ttl: ttl, client: client}
+}
+
+// Keys returns the cached keys, refreshing them if they're stale or if the
+// key id is unknown (the keys could've been rotated). If refresh fails,
+// previously loaded keys are used.
+//
+// Refresh is done once for the concurrent calls, outside of the lock. Stale
+// keys are served meanwhile, only the calls without the keys for the key id
+// wait for it.
+func (s *JwksSource) Keys(ctx context.Context, kid string) ([]Key, error) {
+	s.mu.Lock()
+	keys, _ := s.keys.Keys(ctx, kid)
+	stale := time.Since(s.fetchedAt) > s.ttl
+	missing := s.keys == nil || (kid != "" && len(keys) == 0)
+	if missing && time.Since(s.fetchedAt) > minRefreshInterval {
+		stale = true
+	}
+	if !stale {
+		keys, err := s.keys, s.err
+		s.mu.Unlock()
+		if keys == nil {
+			return nil, err
+		}
+		return keys.Keys(ctx, kid)
+	}
+	if s.refreshing == nil {
+		s.refreshing = make(chan struct{})
+		go s.refresh(s.refreshing)
+	}
+	refreshing := s.refreshing
+	s.mu.Unlock()
+
+	if !missing {
+		return keys, nil
+	}
+	select {
+	case <-refreshing:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys == nil {
+		return nil, s.err
+	}
+	return s.keys.Keys(ctx, kid)
+}
+
+// refresh fetches the keys, not bound to the context of the request, which
+// started it, as the other ones can wait for it as well.
+func (s *JwksSource) refresh(done chan struct{}) {
+	keys, err := s.fetch(context.Background())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.keys = keys
+	}
+	s.err = err
+	// on error, retrying no sooner than on the next refresh interval
+	s.fetchedAt = time.Now()
+	s.refreshing = nil
+	close(done)
+}
+
+func (s *JwksSource) fetch(ctx context.Context) (KeySet, error) {
+	if !strings.HasPrefix(s.location, "http://") && !strings.HasPrefix(s.location, "https://") {
+		data, err := os.ReadFile(s.location)
+		if err != nil {
+			return nil, fmt.Errorf("error reading JWKS file: %w", err)
+		}
+		return ParseKeySet(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", s.location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating JWKS request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching JWKS: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("error reading JWKS response: %w", err)
+	}
+	return ParseKeySet(data)
+}
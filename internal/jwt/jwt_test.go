@@ -0,0 +1,210 @@
+package jwt_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/jwt"
+	"github.com/religiosa1/tgnotifier/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub": "ci-runner",
+		"iss": "https://idp.example.com",
+		"aud": []string{"tgnotifier", "other"},
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"nbf": time.Now().Add(-time.Minute).Unix(),
+	}
+}
+
+func newVerifier(t *testing.T, signers ...*test.JwtSigner) *jwt.Verifier {
+	t.Helper()
+	keys, err := jwt.ParseKeySet(test.Jwks(t, signers...))
+	require.NoError(t, err)
+	return &jwt.Verifier{Keys: keys, Issuer: "https://idp.example.com", Audience: "tgnotifier"}
+}
+
+func TestVerify_Algorithms(t *testing.T) {
+	for _, alg := range []string{"RS256", "PS256", "ES256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			signer := test.NewJwtSigner(t, alg, "key-1")
+			verifier := newVerifier(t, signer)
+
+			claims, err := verifier.Verify(context.Background(), signer.Sign(t, validClaims()))
+
+			require.NoError(t, err)
+			assert.Equal(t, "ci-runner", claims.Subject())
+		})
+	}
+}
+
+func TestVerify_KeyIdSelection(t *testing.T) {
+	first := test.NewJwtSigner(t, "ES256", "first")
+	second := test.NewJwtSigner(t, "ES256", "second")
+	noKid := test.NewJwtSigner(t, "ES256", "")
+	verifier := newVerifier(t, first, second)
+
+	_, err := verifier.Verify(context.Background(), second.Sign(t, validClaims()))
+	assert.NoError(t, err)
+
+	// token without kid is checked against all keys
+	keys, err := jwt.ParseKeySet(test.Jwks(t, first, noKid))
+	require.NoError(t, err)
+	verifier.Keys = keys
+	_, err = verifier.Verify(context.Background(), noKid.Sign(t, validClaims()))
+	assert.NoError(t, err)
+}
+
+func TestVerify_Rejected(t *testing.T) {
+	signer := test.NewJwtSigner(t, "ES256", "key-1")
+	stranger := test.NewJwtSigner(t, "ES256", "key-1")
+	verifier := newVerifier(t, signer)
+
+	withClaim := func(name string, value any) map[string]any {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	cases := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"garbage", "not-a-token", jwt.ErrMalformed},
+		{"foreign key", stranger.Sign(t, validClaims()), jwt.ErrInvalidSignature},
+		{"alg none", "eyJhbGciOiJub25lIn0.eyJzdWIiOiJ4In0.", jwt.ErrUnsupportedAlg},
+		{"expired", signer.Sign(t, withClaim("exp", time.Now().Add(-time.Hour).Unix())), jwt.ErrExpired},
+		{"not yet valid", signer.Sign(t, withClaim("nbf", time.Now().Add(time.Hour).Unix())), jwt.ErrNotYetValid},
+		{"wrong issuer", signer.Sign(t, withClaim("iss", "https://evil.example.com")), jwt.ErrInvalidIssuer},
+		{"no issuer", signer.Sign(t, withClaim("iss", nil)), jwt.ErrInvalidIssuer},
+		{"wrong audience", signer.Sign(t, withClaim("aud", "other")), jwt.ErrInvalidAudience},
+		{"string exp", signer.Sign(t, withClaim("exp", "tomorrow")), jwt.ErrMalformed},
+		{"no exp", signer.Sign(t, withClaim("exp", nil)), jwt.ErrMissingExp},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), tt.token)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestVerify_TamperedPayload(t *testing.T) {
+	signer := test.NewJwtSigner(t, "RS256", "key-1")
+	verifier := newVerifier(t, signer)
+	token := signer.Sign(t, validClaims())
+	other := signer.Sign(t, map[string]any{"sub": "admin"})
+
+	// replacing the payload segment with the one from the other token
+	parts := strings.Split(token, ".")
+	parts[1] = strings.Split(other, ".")[1]
+	tampered := strings.Join(parts, ".")
+
+	_, err := verifier.Verify(context.Background(), tampered)
+	assert.ErrorIs(t, err, jwt.ErrInvalidSignature)
+}
+
+func TestClaims_Strings(t *testing.T) {
+	signer := test.NewJwtSigner(t, "EdDSA", "")
+	verifier := &jwt.Verifier{Keys: mustKeySet(t, signer)}
+	claims, err := verifier.Verify(context.Background(), signer.Sign(t, map[string]any{
+		"recipients": []any{"123", 1234567890123},
+		"scope":      "notify healthcheck",
+		"exp":        time.Now().Add(time.Hour).Unix(),
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"123", "1234567890123"}, claims.Strings("recipients", false))
+	assert.Equal(t, []string{"notify", "healthcheck"}, claims.Strings("scope", true))
+	assert.Nil(t, claims.Strings("missing", false))
+}
+
+func TestJwksSource_File(t *testing.T) {
+	signer := test.NewJwtSigner(t, "ES256", "key-1")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, test.Jwks(t, signer), 0o600))
+
+	verifier := &jwt.Verifier{Keys: jwt.NewJwksSource(path, time.Hour, http.DefaultClient)}
+	_, err := verifier.Verify(context.Background(), signer.Sign(t, validClaims()))
+	assert.NoError(t, err)
+}
+
+func TestJwksSource_UrlIsCached(t *testing.T) {
+	signer := test.NewJwtSigner(t, "ES256", "key-1")
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write(test.Jwks(t, signer))
+	}))
+	defer server.Close()
+
+	verifier := &jwt.Verifier{Keys: jwt.NewJwksSource(server.URL, time.Hour, server.Client())}
+	for range 3 {
+		_, err := verifier.Verify(context.Background(), signer.Sign(t, validClaims()))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), fetches.Load())
+}
+
+func TestJwksSource_StaleKeysServedDuringRefresh(t *testing.T) {
+	signer := test.NewJwtSigner(t, "ES256", "key-1")
+	var fetches atomic.Int32
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-unblock
+		}
+		w.Write(test.Jwks(t, signer))
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	source := jwt.NewJwksSource(server.URL, time.Millisecond, server.Client())
+	keys, err := source.Keys(context.Background(), "key-1")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	time.Sleep(10 * time.Millisecond)
+	// the refresh is blocked, but the calls don't wait for it
+	for range 3 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		keys, err := source.Keys(ctx, "key-1")
+		cancel()
+		require.NoError(t, err)
+		assert.Len(t, keys, 1)
+	}
+	assert.Eventually(t, func() bool { return fetches.Load() == 2 }, time.Second, 10*time.Millisecond)
+}
+
+func TestJwksSource_UnavailableUrl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	source := jwt.NewJwksSource(server.URL, time.Hour, server.Client())
+	_, err := source.Keys(context.Background(), "key-1")
+	assert.Error(t, err)
+}
+
+func mustKeySet(t *testing.T, signers ...*test.JwtSigner) jwt.KeySet {
+	t.Helper()
+	keys, err := jwt.ParseKeySet(test.Jwks(t, signers...))
+	require.NoError(t, err)
+	return keys
+}
@@ -0,0 +1,252 @@
+// Package jwt verifies JSON Web Tokens, signed with asymmetric keys from
+// a JWK set.
+//
+// Supported algorithms are RS256/384/512, PS256/384/512, ES256/384/512
+// and EdDSA (Ed25519).
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	// registering hash functions used by the algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+var (
+	ErrMalformed        = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrExpired          = errors.New("token is expired")
+	ErrMissingExp       = errors.New("token has no expiration time")
+	ErrNotYetValid      = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+)
+
+// Claims of the verified token payload. Numbers are kept as [json.Number].
+type Claims map[string]any
+
+// Subject returns the "sub" claim.
+func (c Claims) Subject() string {
+	sub, _ := c["sub"].(string)
+	return sub
+}
+
+// Strings returns the claim value as a list of strings. The claim can be a
+// single string, space-separated if split is true, a number or an array of those.
+func (c Claims) Strings(name string, split bool) []string {
+	switch value := c[name].(type) {
+	case string:
+		if split {
+			return strings.Fields(value)
+		}
+		return []string{value}
+	case json.Number:
+		return []string{value.String()}
+	case []any:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			switch item := item.(type) {
+			case string:
+				result = append(result, item)
+			case json.Number:
+				result = append(result, item.String())
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+func (c Claims) time(name string) (time.Time, bool, error) {
+	value, ok := c[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%w: %q claim must be a number", ErrMalformed, name)
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: %q claim must be a number", ErrMalformed, name)
+	}
+	return time.Unix(int64(seconds), 0), true, nil
+}
+
+// Verifier checks token signatures and registered claims.
+type Verifier struct {
+	Keys KeySource
+	// expected "iss" claim, not checked if empty
+	Issuer string
+	// expected "aud" claim value, not checked if empty
+	Audience string
+	// allowed clock difference for "exp" and "nbf" checks
+	Leeway time.Duration
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks the compact serialized token and returns its claims.
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	var h header
+	if err := decodeJsonSegment(parts[0], &h); err != nil {
+		return nil, err
+	}
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	alg, ok := algorithms[h.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlg, h.Alg)
+	}
+
+	keys, err := v.Keys.Keys(ctx, h.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("error getting verification keys: %w", err)
+	}
+	signingInput := []byte(parts[0] + "." + parts[1])
+	verified := slices.ContainsFunc(keys, func(key Key) bool {
+		return (key.Alg == "" || key.Alg == h.Alg) && alg(key.Public, signingInput, signature)
+	})
+	if !verified {
+		return nil, ErrInvalidSignature
+	}
+
+	var claims Claims
+	if err := decodeJsonSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) validateClaims(claims Claims) error {
+	now := time.Now()
+	exp, ok, err := claims.time("exp")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// tokens without expiration can't be revoked
+		return ErrMissingExp
+	}
+	if now.After(exp.Add(v.Leeway)) {
+		return ErrExpired
+	}
+	nbf, ok, err := claims.time("nbf")
+	if err != nil {
+		return err
+	}
+	if ok && now.Before(nbf.Add(-v.Leeway)) {
+		return ErrNotYetValid
+	}
+	if v.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.Issuer {
+			return ErrInvalidIssuer
+		}
+	}
+	if v.Audience != "" && !slices.Contains(claims.Strings("aud", false), v.Audience) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+// algorithm verifies the signature of the input with the public key
+type algorithm func(key crypto.PublicKey, input, signature []byte) bool
+
+var algorithms = map[string]algorithm{
+	"RS256": rsaPkcs1(crypto.SHA256),
+	"RS384": rsaPkcs1(crypto.SHA384),
+	"RS512": rsaPkcs1(crypto.SHA512),
+	"PS256": rsaPss(crypto.SHA256),
+	"PS384": rsaPss(crypto.SHA384),
+	"PS512": rsaPss(crypto.SHA512),
+	"ES256": ecdsaAlg(crypto.SHA256, 256),
+	"ES384": ecdsaAlg(crypto.SHA384, 384),
+	"ES512": ecdsaAlg(crypto.SHA512, 521),
+	"EdDSA": func(key crypto.PublicKey, input, signature []byte) bool {
+		public, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(public, input, signature)
+	},
+}
+
+func digest(hash crypto.Hash, input []byte) []byte {
+	h := hash.New()
+	h.Write(input)
+	return h.Sum(nil)
+}
+
+func rsaPkcs1(hash crypto.Hash) algorithm {
+	return func(key crypto.PublicKey, input, signature []byte) bool {
+		public, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(public, hash, digest(hash, input), signature) == nil
+	}
+}
+
+func rsaPss(hash crypto.Hash) algorithm {
+	return func(key crypto.PublicKey, input, signature []byte) bool {
+		public, ok := key.(*rsa.PublicKey)
+		opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+		return ok && rsa.VerifyPSS(public, hash, digest(hash, input), signature, opts) == nil
+	}
+}
+
+func ecdsaAlg(hash crypto.Hash, curveBits int) algorithm {
+	return func(key crypto.PublicKey, input, signature []byte) bool {
+		public, ok := key.(*ecdsa.PublicKey)
+		if !ok || public.Curve.Params().BitSize != curveBits {
+			return false
+		}
+		// signature is a fixed size concatenation of R and S values
+		size := (curveBits + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(public, digest(hash, input), r, s)
+	}
+}
+
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+}
+
+func decodeJsonSegment(segment string, target any) error {
+	data, err := decodeSegment(segment)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(target); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// Package listener creates network listeners for the HTTP server: TCP, unix
+// domain sockets or sockets passed by systemd socket activation.
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// UnixPrefix marks an address as a unix domain socket path, e.g. "unix:/run/tgnotifier.sock"
+const UnixPrefix = "unix:"
+
+// Options of the created unix domain socket file.
+type Options struct {
+	// file mode of the socket; zero value keeps the mode set by umask
+	SocketMode fs.FileMode
+	// group name or numeric id to own the socket; empty value keeps the default group
+	SocketGroup string
+}
+
+// IsUnix reports whether the address is a unix domain socket address.
+func IsUnix(address string) bool {
+	return strings.HasPrefix(address, UnixPrefix)
+}
+
+// ParseSocketMode parses octal file mode representation, such as "0660".
+// Empty string results in zero mode.
+func ParseSocketMode(mode string) (fs.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > 0o777 {
+		return 0, fmt.Errorf("invalid socket mode %q, octal permissions are expected, e.g. 0660", mode)
+	}
+	return fs.FileMode(value), nil
+}
+
+// Listen creates a listener on the given address. Addresses, starting with
+// [UnixPrefix] are unix domain sockets, anything else is a TCP address.
+func Listen(address string, opts Options) (net.Listener, error) {
+	if !IsUnix(address) {
+		return net.Listen("tcp", address)
+	}
+	path := strings.TrimPrefix(address, UnixPrefix)
+	if path == "" {
+		return nil, errors.New("empty unix socket path")
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySocketOptions(path, opts); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// removeStaleSocket removes a socket file left over from the previous run.
+// Anything which is not a socket is left intact, so listen will fail on it.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode().Type() != fs.ModeSocket {
+		return nil
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is already in use", path)
+	}
+	return os.Remove(path)
+}
+
+func applySocketOptions(path string, opts Options) error {
+	if opts.SocketGroup != "" {
+		gid, err := lookupGroupId(opts.SocketGroup)
+		if err != nil {
+			return err
+		}
+		if err := os.Chown(path, -1, gid); err != nil {
+			return fmt.Errorf("error changing socket group: %w", err)
+		}
+	}
+	if opts.SocketMode != 0 {
+		if err := os.Chmod(path, opts.SocketMode); err != nil {
+			return fmt.Errorf("error changing socket mode: %w", err)
+		}
+	}
+	return nil
+}
+
+func lookupGroupId(group string) (int, error) {
+	if gid, err := strconv.Atoi(group); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return 0, fmt.Errorf("error looking up socket group: %w", err)
+	}
+	return strconv.Atoi(g.Gid)
+}
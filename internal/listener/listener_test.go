@@ -0,0 +1,110 @@
+//go:build unix
+
+package listener_test
+
+import (
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/religiosa1/tgnotifier/internal/listener"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSocketMode(t *testing.T) {
+	cases := []struct {
+		input   string
+		want    fs.FileMode
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0660", 0o660, false},
+		{"600", 0o600, false},
+		{"0999", 0, true},
+		{"rw-rw----", 0, true},
+		{"07777", 0, true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.input, func(t *testing.T) {
+			mode, err := listener.ParseSocketMode(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, mode)
+		})
+	}
+}
+
+func TestListen_Tcp(t *testing.T) {
+	l, err := listener.Listen("127.0.0.1:0", listener.Options{})
+	require.NoError(t, err)
+	defer l.Close()
+	assert.Equal(t, "tcp", l.Addr().Network())
+}
+
+func TestListen_UnixSocketWithMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tgnotifier.sock")
+	l, err := listener.Listen("unix:"+path, listener.Options{
+		SocketMode:  0o640,
+		SocketGroup: strconv.Itoa(os.Getgid()),
+	})
+	require.NoError(t, err)
+	defer l.Close()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, fs.ModeSocket, info.Mode().Type())
+	assert.Equal(t, fs.FileMode(0o640), info.Mode().Perm())
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestListen_RemovesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tgnotifier.sock")
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	// leaving the socket file behind, as a crashed process would
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	l, err := listener.Listen("unix:"+path, listener.Options{})
+	require.NoError(t, err)
+	l.Close()
+}
+
+func TestListen_SocketInUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tgnotifier.sock")
+	l, err := listener.Listen("unix:"+path, listener.Options{})
+	require.NoError(t, err)
+	defer l.Close()
+
+	_, err = listener.Listen("unix:"+path, listener.Options{})
+	assert.ErrorContains(t, err, "already in use")
+}
+
+func TestListen_DoesNotRemoveRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tgnotifier.sock")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+
+	_, err := listener.Listen("unix:"+path, listener.Options{})
+	assert.Error(t, err)
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestSystemdListeners_NotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+
+	listeners, err := listener.SystemdListeners()
+	assert.NoError(t, err)
+	assert.Empty(t, listeners)
+}
@@ -0,0 +1,45 @@
+package listener
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// first file descriptor passed by systemd, see sd_listen_fds(3)
+const listenFdsStart = 3
+
+// SystemdListeners returns the listeners passed by systemd socket activation,
+// or nil if the process wasn't socket activated.
+//
+// See: https://www.freedesktop.org/software/systemd/man/latest/sd_listen_fds.html
+func SystemdListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	nfds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || nfds <= 0 {
+		return nil, nil
+	}
+	// not passing the variables to any child processes
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, nfds)
+	for fd := listenFdsStart; fd < listenFdsStart+nfds; fd++ {
+		file := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		l, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("error using socket activation file descriptor %d: %w", fd, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
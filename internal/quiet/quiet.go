@@ -0,0 +1,272 @@
+// Package quiet applies quiet hours of the recipients to the sent messages.
+package quiet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+)
+
+// Policy of the messages, sent during the quiet hours.
+type Policy = string
+
+const (
+	// PolicySilent sends the messages without sound notification
+	PolicySilent Policy = "silent"
+	// PolicyDefer sends the messages at the end of the quiet hours
+	PolicyDefer Policy = "defer"
+)
+
+// IsValidPolicy reports whether the policy is a known one or empty, which is
+// the same as [PolicySilent].
+func IsValidPolicy(policy string) bool {
+	return policy == "" || policy == PolicySilent || policy == PolicyDefer
+}
+
+// ParseClock parses the time of the day in "HH:MM" format into minutes.
+func ParseClock(value string) (int, error) {
+	hours, minutes, ok := strings.Cut(value, ":")
+	h, hErr := strconv.Atoi(hours)
+	m, mErr := strconv.Atoi(minutes)
+	if !ok || hErr != nil || mErr != nil || len(minutes) != 2 || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return h*60 + m, nil
+}
+
+// Rule is the quiet hours of the recipients, from From to To minutes of the
+// day in the Location; the period can span midnight.
+type Rule struct {
+	// resolved chat ids
+	Recipients []string
+	From       int
+	To         int
+	Location   *time.Location
+	Policy     Policy
+}
+
+// End returns the end of the quiet hours, if they're active at the moment.
+func (r Rule) End(now time.Time) (time.Time, bool) {
+	now = now.In(r.Location)
+	minute := now.Hour()*60 + now.Minute()
+	active := r.From <= minute && minute < r.To
+	if r.From > r.To {
+		active = minute >= r.From || minute < r.To
+	}
+	if !active {
+		return time.Time{}, false
+	}
+	year, month, day := now.Date()
+	end := time.Date(year, month, day, r.To/60, r.To%60, 0, 0, r.Location)
+	if !end.After(now) {
+		end = time.Date(year, month, day+1, r.To/60, r.To%60, 0, 0, r.Location)
+	}
+	return end, true
+}
+
+// ErrDeferred is matched by [DeferredError].
+var ErrDeferred = errors.New("Notification deferred until the end of quiet hours")
+
+// DeferredError is returned, if the message is deferred for some of the
+// recipients, while it's sent to the others.
+type DeferredError struct {
+	// recipients, the message isn't sent to yet
+	Recipients []string
+	// the earliest end of their quiet hours
+	Until time.Time
+}
+
+func (e *DeferredError) Error() string {
+	return ErrDeferred.Error()
+}
+
+func (e *DeferredError) Is(target error) bool {
+	return target == ErrDeferred
+}
+
+type bypassKey struct{}
+
+type keepDeferredKey struct{}
+
+// WithBypass returns the context, messages sent with which ignore the quiet
+// hours, e.g. for critical alerts.
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+func isBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// WithoutDefer returns the context, messages sent with which aren't kept in
+// memory, if they're deferred; the caller gets [DeferredError] and should
+// send them again after its Until time, e.g. to persist them meanwhile.
+func WithoutDefer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keepDeferredKey{}, true)
+}
+
+func isDeferKept(ctx context.Context) bool {
+	kept, _ := ctx.Value(keepDeferredKey{}).(bool)
+	return kept
+}
+
+// Bot wraps the bot, sending messages silently or deferring them for the
+// recipients in their quiet hours. Deferred messages are kept in memory.
+type Bot struct {
+	tgnotifier.BotInterface
+	Rules  []Rule
+	Logger *slog.Logger
+	// clock, defaults to time.Now
+	Now func() time.Time
+
+	mu sync.Mutex
+	// deferred messages per recipient, in the order they were sent
+	deferred map[string][]deferredMessage
+}
+
+type deferredMessage struct {
+	text      string
+	parseMode tgnotifier.ParseMode
+	opts      tgnotifier.SendOptions
+}
+
+func NewBot(bot tgnotifier.BotInterface, rules []Rule, logger *slog.Logger) *Bot {
+	return &Bot{
+		BotInterface: bot,
+		Rules:        rules,
+		Logger:       logger,
+		Now:          time.Now,
+		deferred:     make(map[string][]deferredMessage),
+	}
+}
+
+func (b *Bot) SendMessage(message string, parseMode tgnotifier.ParseMode, recipients []string) error {
+	return b.SendMessageWithOptions(context.Background(), message, parseMode, recipients, tgnotifier.SendOptions{})
+}
+
+func (b *Bot) SendMessageWithContext(ctx context.Context, message string, parseMode tgnotifier.ParseMode, recipients []string) error {
+	return b.SendMessageWithOptions(ctx, message, parseMode, recipients, tgnotifier.SendOptions{})
+}
+
+// SendMessageWithOptions sends the message, silently or deferring it for the
+// recipients in their quiet hours. The message is validated first, so the
+// deferred ones don't fail later on. If it's deferred for any of the
+// recipients, [DeferredError] is returned, unless the send failed.
+func (b *Bot) SendMessageWithOptions(
+	ctx context.Context,
+	message string,
+	parseMode tgnotifier.ParseMode,
+	recipients []string,
+	opts tgnotifier.SendOptions,
+) error {
+	if isBypassed(ctx) {
+		return tgnotifier.SendWithOptions(ctx, b.BotInterface, message, parseMode, recipients, opts)
+	}
+	if err := tgnotifier.ValidateMessage(message, parseMode, recipients); err != nil {
+		return err
+	}
+	now := b.Now()
+	var normal, silent []string
+	var deferred *DeferredError
+	for _, recipient := range recipients {
+		rule, end, ok := b.rule(recipient, now)
+		switch {
+		case !ok:
+			normal = append(normal, recipient)
+		case rule.Policy == PolicyDefer:
+			if deferred == nil {
+				deferred = &DeferredError{Until: end}
+			}
+			deferred.Recipients = append(deferred.Recipients, recipient)
+			if end.Before(deferred.Until) {
+				deferred.Until = end
+			}
+			if !isDeferKept(ctx) {
+				b.deferMessage(recipient, end.Sub(now), deferredMessage{message, parseMode, opts})
+			}
+		default:
+			silent = append(silent, recipient)
+		}
+	}
+
+	var errs []error
+	if len(normal) > 0 {
+		errs = append(errs, tgnotifier.SendWithOptions(ctx, b.BotInterface, message, parseMode, normal, opts))
+	}
+	if len(silent) > 0 {
+		silentOpts := opts
+		silentOpts.DisableNotification = true
+		errs = append(errs, tgnotifier.SendWithOptions(ctx, b.BotInterface, message, parseMode, silent, silentOpts))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if deferred != nil {
+		return deferred
+	}
+	return nil
+}
+
+// rule returns the first of the recipient's rules, active at the moment
+func (b *Bot) rule(recipient string, now time.Time) (Rule, time.Time, bool) {
+	for _, rule := range b.Rules {
+		for _, r := range rule.Recipients {
+			if r != recipient {
+				continue
+			}
+			if end, ok := rule.End(now); ok {
+				return rule, end, true
+			}
+		}
+	}
+	return Rule{}, time.Time{}, false
+}
+
+// deferMessage queues the message, sending the recipient's queue at once in
+// the original order after the delay.
+func (b *Bot) deferMessage(recipient string, delay time.Duration, msg deferredMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.deferred[recipient]) == 0 {
+		time.AfterFunc(delay, func() { b.sendDeferred(recipient) })
+	}
+	b.deferred[recipient] = append(b.deferred[recipient], msg)
+	b.Logger.Info("Notification deferred until the end of quiet hours",
+		slog.String("recipient", recipient),
+		slog.Time("until", b.Now().Add(delay)),
+	)
+}
+
+// Deferred returns the number of deferred messages, counted per recipient.
+func (b *Bot) Deferred() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	count := 0
+	for _, messages := range b.deferred {
+		count += len(messages)
+	}
+	return count
+}
+
+func (b *Bot) sendDeferred(recipient string) {
+	b.mu.Lock()
+	messages := b.deferred[recipient]
+	delete(b.deferred, recipient)
+	b.mu.Unlock()
+
+	for _, msg := range messages {
+		err := tgnotifier.SendWithOptions(context.Background(), b.BotInterface, msg.text, msg.parseMode, []string{recipient}, msg.opts)
+		if err != nil {
+			b.Logger.Error("Error sending the deferred notification", slog.String("recipient", recipient), slog.Any("error", err))
+		}
+	}
+	b.Logger.Info("Deferred notifications sent", slog.String("recipient", recipient), slog.Int("messages", len(messages)))
+}
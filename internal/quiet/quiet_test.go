@@ -0,0 +1,169 @@
+package quiet_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/quiet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentMessage struct {
+	text       string
+	recipients []string
+	silent     bool
+}
+
+type mockBot struct {
+	tgnotifier.BotInterface
+	mu   sync.Mutex
+	sent []sentMessage
+}
+
+func (b *mockBot) SendMessageWithOptions(ctx context.Context, message string, parseMode tgnotifier.ParseMode, recipients []string, opts tgnotifier.SendOptions) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, sentMessage{message, recipients, opts.DisableNotification})
+	return nil
+}
+
+func (b *mockBot) messages() []sentMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]sentMessage(nil), b.sent...)
+}
+
+func TestParseClock(t *testing.T) {
+	minutes, err := quiet.ParseClock("22:30")
+	require.NoError(t, err)
+	assert.Equal(t, 22*60+30, minutes)
+
+	for _, value := range []string{"", "22", "24:00", "12:60", "12:5", "ab:cd"} {
+		_, err := quiet.ParseClock(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRule_End(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	night := quiet.Rule{From: 22 * 60, To: 8 * 60, Location: berlin}
+	day := quiet.Rule{From: 12 * 60, To: 13 * 60, Location: time.UTC}
+
+	cases := []struct {
+		name    string
+		rule    quiet.Rule
+		now     time.Time
+		wantEnd time.Time
+	}{
+		{"before midnight", night, time.Date(2025, 1, 1, 22, 30, 0, 0, berlin), time.Date(2025, 1, 2, 8, 0, 0, 0, berlin)},
+		{"after midnight", night, time.Date(2025, 1, 2, 3, 0, 0, 0, berlin), time.Date(2025, 1, 2, 8, 0, 0, 0, berlin)},
+		// 21:30 UTC is 22:30 in Berlin
+		{"other time zone", night, time.Date(2025, 1, 1, 21, 30, 0, 0, time.UTC), time.Date(2025, 1, 2, 8, 0, 0, 0, berlin)},
+		{"not active", night, time.Date(2025, 1, 2, 8, 0, 0, 0, berlin), time.Time{}},
+		{"day", day, time.Date(2025, 1, 1, 12, 59, 0, 0, time.UTC), time.Date(2025, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{"day not active", day, time.Date(2025, 1, 1, 11, 59, 0, 0, time.UTC), time.Time{}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			end, ok := tt.rule.End(tt.now)
+			assert.Equal(t, !tt.wantEnd.IsZero(), ok)
+			assert.True(t, tt.wantEnd.Equal(end), "got %s", end)
+		})
+	}
+}
+
+func TestBot(t *testing.T) {
+	mock := &mockBot{}
+	bot := quiet.NewBot(mock, []quiet.Rule{
+		{Recipients: []string{"silent"}, From: 22 * 60, To: 8 * 60, Location: time.UTC, Policy: quiet.PolicySilent},
+		{Recipients: []string{"deferred"}, From: 22 * 60, To: 8 * 60, Location: time.UTC, Policy: quiet.PolicyDefer},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	// the quiet hours end in 50ms
+	bot.Now = func() time.Time { return time.Date(2025, 1, 2, 7, 59, 59, 950_000_000, time.UTC) }
+
+	err := bot.SendMessage("one", "", []string{"other", "silent", "deferred"})
+	var deferred *quiet.DeferredError
+	require.ErrorAs(t, err, &deferred)
+	assert.Equal(t, []string{"deferred"}, deferred.Recipients)
+	assert.Equal(t, time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC), deferred.Until)
+	require.ErrorIs(t, bot.SendMessage("two", "", []string{"deferred"}), quiet.ErrDeferred)
+	assert.Equal(t, []sentMessage{
+		{"one", []string{"other"}, false},
+		{"one", []string{"silent"}, true},
+	}, mock.messages())
+	assert.Equal(t, 2, bot.Deferred())
+
+	require.Eventually(t, func() bool { return len(mock.messages()) == 4 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []sentMessage{
+		{"one", []string{"deferred"}, false},
+		{"two", []string{"deferred"}, false},
+	}, mock.messages()[2:])
+	assert.Equal(t, 0, bot.Deferred())
+}
+
+func TestBot_Bypass(t *testing.T) {
+	mock := &mockBot{}
+	bot := quiet.NewBot(mock, []quiet.Rule{
+		{Recipients: []string{"deferred"}, From: 0, To: 8 * 60, Location: time.UTC, Policy: quiet.PolicyDefer},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	bot.Now = func() time.Time { return time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC) }
+
+	ctx := quiet.WithBypass(context.Background())
+	require.NoError(t, bot.SendMessageWithContext(ctx, "critical", "", []string{"deferred"}))
+	assert.Equal(t, []sentMessage{{"critical", []string{"deferred"}, false}}, mock.messages())
+}
+
+func TestBot_DeferredMessageIsValidated(t *testing.T) {
+	mock := &mockBot{}
+	bot := quiet.NewBot(mock, []quiet.Rule{
+		{Recipients: []string{"deferred"}, From: 0, To: 8 * 60, Location: time.UTC, Policy: quiet.PolicyDefer},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	bot.Now = func() time.Time { return time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		name       string
+		message    string
+		parseMode  tgnotifier.ParseMode
+		recipients []string
+		want       error
+	}{
+		{"empty", "", "", []string{"deferred"}, tgnotifier.ErrMessageEmpty},
+		{"too long", strings.Repeat("a", tgnotifier.MaxMsgLen+1), "", []string{"deferred"}, tgnotifier.ErrMessageTooLong},
+		{"invalid parse mode", "hi", "Plain", []string{"deferred"}, tgnotifier.ErrParseModeInvalid},
+		{"no recipients", "hi", "", nil, tgnotifier.ErrRecipientsEmpty},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bot.SendMessage(tt.message, tt.parseMode, tt.recipients)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+	assert.Equal(t, 0, bot.Deferred())
+	assert.Empty(t, mock.messages())
+}
+
+func TestBot_WithoutDefer(t *testing.T) {
+	mock := &mockBot{}
+	bot := quiet.NewBot(mock, []quiet.Rule{
+		{Recipients: []string{"deferred"}, From: 0, To: 8 * 60, Location: time.UTC, Policy: quiet.PolicyDefer},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	bot.Now = func() time.Time { return time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC) }
+
+	ctx := quiet.WithoutDefer(context.Background())
+	err := bot.SendMessageWithContext(ctx, "hi", "", []string{"other", "deferred"})
+	var deferred *quiet.DeferredError
+	require.ErrorAs(t, err, &deferred)
+	assert.Equal(t, []string{"deferred"}, deferred.Recipients)
+	assert.Equal(t, time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC), deferred.Until)
+	// the caller keeps the message
+	assert.Equal(t, 0, bot.Deferred())
+	assert.Equal(t, []sentMessage{{"hi", []string{"other"}, false}}, mock.messages())
+}
@@ -0,0 +1,110 @@
+// Package ratelimit implements keyed token bucket rate limiting.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit of the token bucket: it's refilled with Rate tokens per second up to
+// Burst tokens. Zero Limit means no limiting.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Every returns a limit of n requests per period, with the given burst.
+// If burst is zero, n is used.
+func Every(n int, period time.Duration, burst int) Limit {
+	if n <= 0 || period <= 0 {
+		return Limit{}
+	}
+	if burst <= 0 {
+		burst = n
+	}
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: burst}
+}
+
+// IsZero reports whether the limit is disabled.
+func (l Limit) IsZero() bool {
+	return l.Rate <= 0
+}
+
+// how often idle buckets are removed from memory
+const sweepInterval = time.Minute
+
+// Limiter keeps a token bucket per key.
+type Limiter struct {
+	// clock, defaults to time.Now
+	Now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limit   Limit
+	tokens  float64
+	updated time.Time
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{Now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// Allow wraps [Limiter.AllowN] for a single request.
+func (l *Limiter) Allow(key string, limit Limit) (bool, time.Duration) {
+	return l.AllowN(key, limit, 1)
+}
+
+// AllowN takes n tokens from the key's bucket. If there's not enough of them,
+// nothing is taken and the duration after which the request can be retried
+// is returned.
+func (l *Limiter) AllowN(key string, limit Limit, n int) (bool, time.Duration) {
+	if limit.IsZero() {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{limit: limit, tokens: float64(limit.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	if float64(n) <= b.tokens {
+		b.tokens -= float64(n)
+		return true, 0
+	}
+	missing := math.Min(float64(n), float64(limit.Burst)) - b.tokens
+	return false, time.Duration(math.Ceil(missing / limit.Rate * float64(time.Second)))
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+		b.updated = now
+	}
+}
+
+// sweep removes full buckets, as they're the same as absent ones
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter() (*ratelimit.Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := ratelimit.NewLimiter()
+	limiter.Now = clock.Now
+	return limiter, clock
+}
+
+func TestEvery(t *testing.T) {
+	assert.Equal(t, ratelimit.Limit{Rate: 1, Burst: 60}, ratelimit.Every(60, time.Minute, 0))
+	assert.Equal(t, ratelimit.Limit{Rate: 1, Burst: 5}, ratelimit.Every(60, time.Minute, 5))
+	assert.True(t, ratelimit.Every(0, time.Minute, 5).IsZero())
+	assert.True(t, ratelimit.Every(10, 0, 5).IsZero())
+}
+
+func TestLimiter_BurstThenRefill(t *testing.T) {
+	limiter, clock := newTestLimiter()
+	limit := ratelimit.Every(6, time.Minute, 3) // one token every 10s
+
+	for range 3 {
+		ok, _ := limiter.Allow("key", limit)
+		assert.True(t, ok)
+	}
+	ok, retryAfter := limiter.Allow("key", limit)
+	assert.False(t, ok)
+	assert.Equal(t, 10*time.Second, retryAfter)
+
+	clock.Advance(5 * time.Second)
+	ok, retryAfter = limiter.Allow("key", limit)
+	assert.False(t, ok)
+	assert.Equal(t, 5*time.Second, retryAfter)
+
+	clock.Advance(5 * time.Second)
+	ok, _ = limiter.Allow("key", limit)
+	assert.True(t, ok)
+}
+
+func TestLimiter_KeysAreIndependent(t *testing.T) {
+	limiter, _ := newTestLimiter()
+	limit := ratelimit.Every(1, time.Minute, 1)
+
+	ok, _ := limiter.Allow("first", limit)
+	assert.True(t, ok)
+	ok, _ = limiter.Allow("first", limit)
+	assert.False(t, ok)
+	ok, _ = limiter.Allow("second", limit)
+	assert.True(t, ok)
+}
+
+func TestLimiter_AllowN(t *testing.T) {
+	limiter, _ := newTestLimiter()
+	limit := ratelimit.Every(10, time.Second, 10)
+
+	ok, _ := limiter.AllowN("key", limit, 8)
+	assert.True(t, ok)
+	ok, retryAfter := limiter.AllowN("key", limit, 5)
+	assert.False(t, ok, "no tokens are taken on failure")
+	assert.Equal(t, 300*time.Millisecond, retryAfter)
+	ok, _ = limiter.AllowN("key", limit, 2)
+	assert.True(t, ok)
+}
+
+func TestLimiter_ZeroLimitAllowsEverything(t *testing.T) {
+	limiter, _ := newTestLimiter()
+	for range 100 {
+		ok, _ := limiter.Allow("key", ratelimit.Limit{})
+		assert.True(t, ok)
+	}
+}
@@ -0,0 +1,292 @@
+// Package scheduled keeps the messages, which are sent at a later time,
+// optionally persisting them into a file, so they're delivered after restarts.
+package scheduled
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/atomicfile"
+	"github.com/religiosa1/tgnotifier/internal/quiet"
+)
+
+var (
+	// ErrNotFound is returned for unknown or already sent messages.
+	ErrNotFound = errors.New("Scheduled message not found")
+	// ErrSending is returned on cancel of the message, which is being sent.
+	ErrSending = errors.New("Scheduled message is being sent")
+	// ErrTooMany is returned, if the store has the maximum pending messages.
+	ErrTooMany = errors.New("Too many pending scheduled messages")
+)
+
+const (
+	// maximum number of attempts to send a message on network errors
+	maxAttempts = 5
+	// delay between the attempts
+	retryDelay = time.Minute
+)
+
+// Message is a notification to send at SendAt time.
+type Message struct {
+	ID        string               `json:"id"`
+	SendAt    time.Time            `json:"send_at"`
+	Text      string               `json:"message"`
+	ParseMode tgnotifier.ParseMode `json:"parse_mode,omitempty"`
+	// resolved chat ids
+	Recipients []string `json:"recipients"`
+	Silent     bool     `json:"silent,omitempty"`
+	// the message is sent, ignoring the quiet hours of the recipients
+	Critical bool `json:"critical,omitempty"`
+	// named channel, the message was sent to
+	Channel string `json:"channel,omitempty"`
+	// caller, who scheduled the message, as "method:name"; empty without auth
+	Owner     string    `json:"owner,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type entry struct {
+	msg      Message
+	timer    *time.Timer
+	attempts int
+	// the send is in progress
+	sending bool
+}
+
+// Store sends the messages at their time. With a file, every change is saved
+// into it, and the messages from it are scheduled on [Open].
+type Store struct {
+	bot    tgnotifier.BotInterface
+	file   string
+	logger *slog.Logger
+	// maximum number of pending messages, unlimited if zero; the file is
+	// rewritten on every change, so its size is bounded by it
+	maxPending int
+
+	mu       sync.Mutex
+	messages map[string]*entry
+	closed   bool
+}
+
+// Open creates the store, loading and scheduling the messages from the file,
+// if it's not empty. Overdue messages are sent right away. Messages above
+// maxPending can't be added, unlimited if it's zero; the ones from the file
+// are loaded regardless.
+func Open(bot tgnotifier.BotInterface, file string, maxPending int, logger *slog.Logger) (*Store, error) {
+	s := &Store{bot: bot, file: file, maxPending: maxPending, logger: logger, messages: make(map[string]*entry)}
+	if file == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading the scheduled messages: %w", err)
+	}
+	var messages []Message
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("error decoding the scheduled messages file %s: %w", file, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, msg := range messages {
+		s.schedule(&entry{msg: msg}, time.Until(msg.SendAt))
+	}
+	return s, nil
+}
+
+// Add validates and schedules the message, returning it with the assigned id.
+func (s *Store) Add(msg Message) (Message, error) {
+	if len(msg.Text) == 0 {
+		return msg, tgnotifier.ErrMessageEmpty
+	}
+	if len(msg.Text) > tgnotifier.MaxMsgLen {
+		return msg, tgnotifier.ErrMessageTooLong
+	}
+	if msg.ParseMode != "" && !tgnotifier.IsValidParseMode(msg.ParseMode) {
+		return msg, tgnotifier.ErrParseModeInvalid
+	}
+	if len(msg.Recipients) == 0 {
+		return msg, tgnotifier.ErrRecipientsEmpty
+	}
+	msg.ID = ulid.Make().String()
+	msg.CreatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return msg, errors.New("scheduled messages store is closed")
+	}
+	if s.maxPending > 0 && len(s.messages) >= s.maxPending {
+		return msg, ErrTooMany
+	}
+	e := &entry{msg: msg}
+	s.messages[msg.ID] = e
+	if err := s.save(); err != nil {
+		delete(s.messages, msg.ID)
+		return msg, err
+	}
+	s.schedule(e, time.Until(msg.SendAt))
+	return msg, nil
+}
+
+// List returns the pending messages in the order of sending.
+func (s *Store) List() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := make([]Message, 0, len(s.messages))
+	for _, e := range s.messages {
+		messages = append(messages, e.msg)
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if !messages[i].SendAt.Equal(messages[j].SendAt) {
+			return messages[i].SendAt.Before(messages[j].SendAt)
+		}
+		return messages[i].ID < messages[j].ID
+	})
+	return messages
+}
+
+// Get returns the pending message by its id.
+func (s *Store) Get(id string) (Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.messages[id]
+	if !ok {
+		return Message{}, ErrNotFound
+	}
+	return e.msg, nil
+}
+
+// Cancel removes the pending message, so it's not sent. Message, which is
+// being sent at the moment, can't be canceled.
+func (s *Store) Cancel(id string) (Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.messages[id]
+	if !ok {
+		return Message{}, ErrNotFound
+	}
+	if e.sending {
+		return e.msg, ErrSending
+	}
+	e.timer.Stop()
+	delete(s.messages, id)
+	if err := s.save(); err != nil {
+		s.logger.Error("Error saving the scheduled messages", slog.Any("error", err))
+	}
+	return e.msg, nil
+}
+
+// Len returns the number of pending messages.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.messages)
+}
+
+// Close stops sending the messages. Persisted ones are sent on the next [Open].
+func (s *Store) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for _, e := range s.messages {
+		e.timer.Stop()
+	}
+}
+
+// schedule starts the timer of the entry; must be called with the lock held.
+func (s *Store) schedule(e *entry, delay time.Duration) {
+	s.messages[e.msg.ID] = e
+	e.timer = time.AfterFunc(delay, func() { s.send(e) })
+}
+
+// send sends the message, removing it from the store, unless it failed with a
+// network error and can be retried later, or it's deferred by the quiet hours
+// of some recipients; it's rescheduled for them in the latter case.
+func (s *Store) send(e *entry) {
+	s.mu.Lock()
+	if s.closed || s.messages[e.msg.ID] != e {
+		// closed or canceled meanwhile
+		s.mu.Unlock()
+		return
+	}
+	e.attempts++
+	e.sending = true
+	s.mu.Unlock()
+
+	logger := s.logger.With(slog.String("scheduled_id", e.msg.ID))
+	// deferred messages are kept in the store, so they aren't lost on restart
+	ctx := quiet.WithoutDefer(context.Background())
+	if e.msg.Critical {
+		ctx = quiet.WithBypass(ctx)
+	}
+	options := tgnotifier.SendOptions{DisableNotification: e.msg.Silent}
+	err := tgnotifier.SendWithOptions(ctx, s.bot, e.msg.Text, e.msg.ParseMode, e.msg.Recipients, options)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.sending = false
+	if s.messages[e.msg.ID] != e {
+		return
+	}
+	var deferred *quiet.DeferredError
+	if errors.As(err, &deferred) {
+		logger.Info("Scheduled message deferred until the end of quiet hours",
+			slog.Any("recipients", deferred.Recipients),
+			slog.Time("until", deferred.Until),
+		)
+		e.msg.Recipients, e.msg.SendAt, e.attempts = deferred.Recipients, deferred.Until.UTC(), 0
+		if err := s.save(); err != nil {
+			logger.Error("Error saving the scheduled messages", slog.Any("error", err))
+		}
+		if !s.closed {
+			e.timer.Reset(time.Until(deferred.Until))
+		}
+		return
+	}
+	var apiError tgnotifier.TgApiError
+	if err != nil && !errors.As(err, &apiError) && e.attempts < maxAttempts && !s.closed {
+		logger.Warn("Error sending the scheduled message, retrying", slog.Any("error", err), slog.Int("attempt", e.attempts))
+		e.timer.Reset(retryDelay)
+		return
+	}
+	if err != nil {
+		logger.Error("Error sending the scheduled message", slog.Any("error", err))
+	} else {
+		logger.Info("Scheduled message sent", slog.Any("recipients", e.msg.Recipients))
+	}
+	delete(s.messages, e.msg.ID)
+	if err := s.save(); err != nil {
+		logger.Error("Error saving the scheduled messages", slog.Any("error", err))
+	}
+}
+
+// save writes the messages into the file, replacing it atomically; must be
+// called with the lock held.
+func (s *Store) save() error {
+	if s.file == "" {
+		return nil
+	}
+	messages := make([]Message, 0, len(s.messages))
+	for _, e := range s.messages {
+		messages = append(messages, e.msg)
+	}
+	data, err := json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(s.file, data); err != nil {
+		return fmt.Errorf("error saving the scheduled messages: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package scheduled_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/quiet"
+	"github.com/religiosa1/tgnotifier/internal/scheduled"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBot struct {
+	tgnotifier.BotInterface
+	mu   sync.Mutex
+	sent []string
+	// errors returned by the next calls
+	errs []error
+	// if set, the calls are blocked until it's closed
+	block chan struct{}
+}
+
+func (b *mockBot) SendMessageWithOptions(ctx context.Context, message string, parseMode tgnotifier.ParseMode, recipients []string, opts tgnotifier.SendOptions) error {
+	if b.block != nil {
+		<-b.block
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.errs) > 0 {
+		err := b.errs[0]
+		b.errs = b.errs[1:]
+		return err
+	}
+	b.sent = append(b.sent, message)
+	return nil
+}
+
+func (b *mockBot) messages() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.sent...)
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestStore_Add(t *testing.T) {
+	bot := &mockBot{}
+	store, err := scheduled.Open(bot, "", 0, discardLogger)
+	require.NoError(t, err)
+	defer store.Close()
+
+	msg, err := store.Add(scheduled.Message{Text: "hello", Recipients: []string{"1"}, SendAt: time.Now().Add(50 * time.Millisecond)})
+	require.NoError(t, err)
+	assert.NotEmpty(t, msg.ID)
+	assert.Equal(t, []scheduled.Message{msg}, store.List())
+	assert.Empty(t, bot.messages())
+
+	assert.Eventually(t, func() bool { return len(bot.messages()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"hello"}, bot.messages())
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestStore_AddValidates(t *testing.T) {
+	store, err := scheduled.Open(&mockBot{}, "", 0, discardLogger)
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, err = store.Add(scheduled.Message{Recipients: []string{"1"}})
+	assert.ErrorIs(t, err, tgnotifier.ErrMessageEmpty)
+	_, err = store.Add(scheduled.Message{Text: "hello"})
+	assert.ErrorIs(t, err, tgnotifier.ErrRecipientsEmpty)
+	_, err = store.Add(scheduled.Message{Text: "hello", Recipients: []string{"1"}, ParseMode: "bad"})
+	assert.ErrorIs(t, err, tgnotifier.ErrParseModeInvalid)
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestStore_Cancel(t *testing.T) {
+	bot := &mockBot{}
+	store, err := scheduled.Open(bot, "", 0, discardLogger)
+	require.NoError(t, err)
+	defer store.Close()
+
+	msg, err := store.Add(scheduled.Message{Text: "hello", Recipients: []string{"1"}, SendAt: time.Now().Add(50 * time.Millisecond)})
+	require.NoError(t, err)
+
+	got, err := store.Get(msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, msg, got)
+
+	_, err = store.Cancel(msg.ID)
+	require.NoError(t, err)
+	_, err = store.Get(msg.ID)
+	assert.ErrorIs(t, err, scheduled.ErrNotFound)
+	_, err = store.Cancel(msg.ID)
+	assert.ErrorIs(t, err, scheduled.ErrNotFound)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, bot.messages())
+}
+
+func TestStore_CancelWhileSending(t *testing.T) {
+	bot := &mockBot{block: make(chan struct{})}
+	store, err := scheduled.Open(bot, "", 0, discardLogger)
+	require.NoError(t, err)
+	defer store.Close()
+
+	msg, err := store.Add(scheduled.Message{Text: "hello", Recipients: []string{"1"}})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, err := store.Cancel(msg.ID)
+		return errors.Is(err, scheduled.ErrSending)
+	}, time.Second, 10*time.Millisecond)
+
+	close(bot.block)
+	assert.Eventually(t, func() bool { return len(bot.messages()) == 1 }, time.Second, 10*time.Millisecond)
+	_, err = store.Cancel(msg.ID)
+	assert.ErrorIs(t, err, scheduled.ErrNotFound)
+}
+
+func TestStore_MaxPending(t *testing.T) {
+	store, err := scheduled.Open(&mockBot{}, "", 2, discardLogger)
+	require.NoError(t, err)
+	defer store.Close()
+
+	later := scheduled.Message{Text: "hello", Recipients: []string{"1"}, SendAt: time.Now().Add(time.Hour)}
+	_, err = store.Add(later)
+	require.NoError(t, err)
+	msg, err := store.Add(later)
+	require.NoError(t, err)
+	_, err = store.Add(later)
+	assert.ErrorIs(t, err, scheduled.ErrTooMany)
+
+	// there's room again, after a message is canceled
+	_, err = store.Cancel(msg.ID)
+	require.NoError(t, err)
+	_, err = store.Add(later)
+	assert.NoError(t, err)
+}
+
+func TestStore_Persistence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scheduled.json")
+	bot := &mockBot{}
+	store, err := scheduled.Open(bot, file, 0, discardLogger)
+	require.NoError(t, err)
+	later, err := store.Add(scheduled.Message{Text: "later", Recipients: []string{"1"}, SendAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = store.Add(scheduled.Message{Text: "soon", Recipients: []string{"1"}, SendAt: time.Now().Add(100 * time.Millisecond)})
+	require.NoError(t, err)
+	store.Close()
+
+	// the overdue message is sent right after the restart
+	time.Sleep(150 * time.Millisecond)
+	assert.Empty(t, bot.messages())
+	store, err = scheduled.Open(bot, file, 0, discardLogger)
+	require.NoError(t, err)
+	defer store.Close()
+	assert.Eventually(t, func() bool { return len(bot.messages()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"soon"}, bot.messages())
+
+	list := store.List()
+	require.Len(t, list, 1)
+	assert.Equal(t, later.ID, list[0].ID)
+	assert.True(t, later.SendAt.Equal(list[0].SendAt))
+
+	// sent message is removed from the file as well
+	reopened, err := scheduled.Open(&mockBot{}, file, 0, discardLogger)
+	require.NoError(t, err)
+	defer reopened.Close()
+	assert.Equal(t, 1, reopened.Len())
+}
+
+func TestStore_ApiErrorIsNotRetried(t *testing.T) {
+	bot := &mockBot{errs: []error{tgnotifier.TgApiError{TgCode: 400, Description: "chat not found"}}}
+	store, err := scheduled.Open(bot, "", 0, discardLogger)
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, err = store.Add(scheduled.Message{Text: "hello", Recipients: []string{"1"}})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return store.Len() == 0 }, time.Second, 10*time.Millisecond)
+	assert.Empty(t, bot.messages())
+}
+
+func TestStore_NetworkErrorIsRetried(t *testing.T) {
+	bot := &mockBot{errs: []error{errors.New("connection refused")}}
+	store, err := scheduled.Open(bot, "", 0, discardLogger)
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, err = store.Add(scheduled.Message{Text: "hello", Recipients: []string{"1"}})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		bot.mu.Lock()
+		defer bot.mu.Unlock()
+		return len(bot.errs) == 0
+	}, time.Second, 10*time.Millisecond)
+	// kept to be sent again after the retry delay
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestStore_DeferredIsKept(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scheduled.json")
+	until := time.Now().Add(100 * time.Millisecond)
+	bot := &mockBot{errs: []error{&quiet.DeferredError{Recipients: []string{"2"}, Until: until}}}
+	store, err := scheduled.Open(bot, file, 0, discardLogger)
+	require.NoError(t, err)
+	defer store.Close()
+
+	msg, err := store.Add(scheduled.Message{Text: "hello", Recipients: []string{"1", "2"}})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		list := store.List()
+		return len(list) == 1 && len(list[0].Recipients) == 1
+	}, time.Second, 10*time.Millisecond)
+	list := store.List()
+	assert.Equal(t, []string{"2"}, list[0].Recipients)
+	assert.True(t, until.Equal(list[0].SendAt))
+
+	// deferred message is persisted, so it isn't lost on restart
+	reopened, err := scheduled.Open(&mockBot{block: make(chan struct{})}, file, 0, discardLogger)
+	require.NoError(t, err)
+	reopened.Close()
+	require.Equal(t, 1, reopened.Len())
+	assert.Equal(t, msg.ID, reopened.List()[0].ID)
+
+	assert.Eventually(t, func() bool { return store.Len() == 0 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"hello"}, bot.messages())
+}
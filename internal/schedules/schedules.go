@@ -0,0 +1,158 @@
+// Package schedules sends the recurring messages, defined in the config.
+package schedules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/cron"
+	"github.com/religiosa1/tgnotifier/internal/format"
+	"github.com/religiosa1/tgnotifier/internal/quiet"
+)
+
+// runs, which are late for more than this, e.g. after the system sleep, are skipped
+const missedRunTolerance = time.Minute
+
+// Job is a recurring message.
+type Job struct {
+	Name     string
+	Cron     string
+	Schedule cron.Schedule
+	Location *time.Location
+	// message template, executed with [TemplateData]
+	Template  *template.Template
+	ParseMode tgnotifier.ParseMode
+	Silent    bool
+	// resolved chat ids
+	Recipients []string
+}
+
+// TemplateData is passed to the job's message template.
+type TemplateData struct {
+	Name string
+	// scheduled time of the run, in the job's location
+	Time time.Time
+}
+
+// NewJobs creates jobs from the validated config, resolving their recipients;
+// jobs without recipients are sent to the default ones.
+func NewJobs(schedules []config.Schedule, groups tgnotifier.RecipientGroups, defaultRecipients []string) ([]Job, error) {
+	jobs := make([]Job, 0, len(schedules))
+	for _, cfg := range schedules {
+		schedule, err := cron.Parse(cfg.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: cron: %w", cfg.Name, err)
+		}
+		location, err := cfg.Location()
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: timezone: %w", cfg.Name, err)
+		}
+		tmpl, err := format.ParseTemplate(cfg.Name, cfg.Template, cfg.ParseMode)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: error parsing template: %w", cfg.Name, err)
+		}
+		recipients := []string(cfg.Recipients)
+		if len(recipients) == 0 {
+			recipients = defaultRecipients
+		}
+		if len(recipients) == 0 {
+			return nil, fmt.Errorf("schedule %q: recipients must be set in the schedule or default recipients", cfg.Name)
+		}
+		if recipients, err = groups.Resolve(recipients); err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", cfg.Name, err)
+		}
+		jobs = append(jobs, Job{
+			Name:       cfg.Name,
+			Cron:       cfg.Cron,
+			Schedule:   schedule,
+			Location:   location,
+			Template:   tmpl.Option("missingkey=error"),
+			ParseMode:  cfg.ParseMode,
+			Silent:     cfg.Silent,
+			Recipients: recipients,
+		})
+	}
+	return jobs, nil
+}
+
+// Next returns the next run time of the job after t, in the job's location;
+// zero if there's none.
+func (j Job) Next(t time.Time) time.Time {
+	return j.Schedule.Next(t.In(j.Location))
+}
+
+// Message renders the message of the run at the time.
+func (j Job) Message(t time.Time) (string, error) {
+	var sb strings.Builder
+	if err := j.Template.Execute(&sb, TemplateData{Name: j.Name, Time: t.In(j.Location)}); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// Run sends the job's messages at their times, until the context is done.
+// Missed runs aren't repeated.
+func (j Job) Run(ctx context.Context, bot tgnotifier.BotInterface, logger *slog.Logger) {
+	logger = logger.With(slog.String("schedule", j.Name))
+	var last time.Time
+	for {
+		from := time.Now()
+		if from.Before(last) {
+			// the timer can fire slightly before the wall clock time
+			from = last
+		}
+		next := j.Next(from)
+		if next.IsZero() {
+			logger.Warn("Schedule has no next run time")
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		last = next
+		if late := time.Since(next); late > missedRunTolerance {
+			logger.Warn("Scheduled run is missed, skipping it", slog.Time("run_time", next), slog.Duration("late", late))
+			continue
+		}
+		j.send(ctx, bot, logger, next)
+	}
+}
+
+func (j Job) send(ctx context.Context, bot tgnotifier.BotInterface, logger *slog.Logger, t time.Time) {
+	text, err := j.Message(t)
+	if err != nil {
+		logger.Error("Error rendering the scheduled message", slog.Any("error", err))
+		return
+	}
+	options := tgnotifier.SendOptions{DisableNotification: j.Silent}
+	if err := tgnotifier.SendWithOptions(ctx, bot, text, j.ParseMode, j.Recipients, options); err != nil && !errors.Is(err, quiet.ErrDeferred) {
+		logger.Error("Error sending the scheduled message", slog.Any("error", err))
+		return
+	}
+	logger.Info("Scheduled message sent", slog.Any("recipients", j.Recipients), slog.Time("next_run", j.Next(t)))
+}
+
+// Run runs all of the jobs, until the context is done.
+func Run(ctx context.Context, bot tgnotifier.BotInterface, jobs []Job, logger *slog.Logger) {
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			job.Run(ctx, bot, logger)
+		}(job)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,90 @@
+package schedules_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/religiosa1/tgnotifier"
+	"github.com/religiosa1/tgnotifier/internal/config"
+	"github.com/religiosa1/tgnotifier/internal/schedules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBot struct {
+	tgnotifier.BotInterface
+	mu   sync.Mutex
+	sent []string
+}
+
+func (b *mockBot) SendMessageWithOptions(ctx context.Context, message string, parseMode tgnotifier.ParseMode, recipients []string, opts tgnotifier.SendOptions) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, message)
+	return nil
+}
+
+func (b *mockBot) messages() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.sent...)
+}
+
+func TestNewJobs(t *testing.T) {
+	groups := tgnotifier.RecipientGroups{"ops": {"1", "2"}}
+	jobs, err := schedules.NewJobs([]config.Schedule{
+		{Name: "standup", Cron: "55 9 * * mon-fri", Template: "Stand-up", Recipients: config.StringList{"ops"}},
+		{Name: "handover", Cron: "@weekly", Template: "Handover"},
+	}, groups, []string{"3"})
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, []string{"1", "2"}, jobs[0].Recipients)
+	assert.Equal(t, []string{"3"}, jobs[1].Recipients)
+
+	_, err = schedules.NewJobs([]config.Schedule{{Name: "standup", Cron: "@daily", Template: "Stand-up"}}, groups, nil)
+	assert.ErrorContains(t, err, "recipients")
+}
+
+func TestJob_NextAndMessage(t *testing.T) {
+	jobs, err := schedules.NewJobs([]config.Schedule{{
+		Name:       "standup",
+		Cron:       "55 9 * * mon-fri",
+		Timezone:   "Europe/Berlin",
+		Template:   `{{ .Name }} at {{ .Time.Format "Mon 15:04" }}`,
+		Recipients: config.StringList{"1"},
+	}}, nil, nil)
+	require.NoError(t, err)
+	job := jobs[0]
+
+	// Friday evening UTC, the next run is on Monday morning in Berlin
+	next := job.Next(time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2026, 10, 19, 7, 55, 0, 0, time.UTC), next.UTC())
+
+	text, err := job.Message(next)
+	require.NoError(t, err)
+	assert.Equal(t, "standup at Mon 09:55", text)
+}
+
+func TestRun(t *testing.T) {
+	bot := &mockBot{}
+	jobs, err := schedules.NewJobs([]config.Schedule{
+		{Name: "ping", Cron: "@every 1s", Template: "ping", Recipients: config.StringList{"1"}},
+	}, nil, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		schedules.Run(ctx, bot, jobs, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return len(bot.messages()) > 0 }, 3*time.Second, 50*time.Millisecond)
+	cancel()
+	<-done
+	assert.Equal(t, "ping", bot.messages()[0])
+}
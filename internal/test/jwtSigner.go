@@ -0,0 +1,124 @@
+package test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+// JwtSigner issues tokens with a locally generated key.
+type JwtSigner struct {
+	Alg string
+	Kid string
+	key crypto.Signer
+}
+
+// NewJwtSigner generates a key for the alg: "RS256", "PS256", "ES256" or "EdDSA".
+func NewJwtSigner(t *testing.T, alg string, kid string) *JwtSigner {
+	t.Helper()
+	var key crypto.Signer
+	var err error
+	switch alg {
+	case "RS256", "PS256":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EdDSA":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("unsupported test alg %s", alg)
+	}
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	return &JwtSigner{Alg: alg, Kid: kid, key: key}
+}
+
+// Sign returns a compact serialized token with the claims.
+func (s *JwtSigner) Sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header := map[string]any{"alg": s.Alg, "typ": "JWT"}
+	if s.Kid != "" {
+		header["kid"] = s.Kid
+	}
+	input := encodeJsonSegment(t, header) + "." + encodeJsonSegment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	var err error
+	switch key := s.key.(type) {
+	case *rsa.PrivateKey:
+		if s.Alg == "PS256" {
+			signature, err = rsa.SignPSS(rand.Reader, key, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key, digest[:])
+		if err == nil {
+			signature = make([]byte, 64)
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+		}
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(key, []byte(input))
+	}
+	if err != nil {
+		t.Fatalf("error signing token: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// Jwk returns the public key in JWK format.
+func (s *JwtSigner) Jwk() map[string]any {
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwk := map[string]any{"kid": s.Kid, "use": "sig"}
+	switch public := s.key.Public().(type) {
+	case *rsa.PublicKey:
+		jwk["kty"] = "RSA"
+		jwk["n"] = b64(public.N.Bytes())
+		jwk["e"] = b64(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		jwk["kty"] = "EC"
+		jwk["crv"] = "P-256"
+		jwk["x"] = b64(public.X.FillBytes(make([]byte, 32)))
+		jwk["y"] = b64(public.Y.FillBytes(make([]byte, 32)))
+	case ed25519.PublicKey:
+		jwk["kty"] = "OKP"
+		jwk["crv"] = "Ed25519"
+		jwk["x"] = b64(public)
+	}
+	return jwk
+}
+
+// Jwks returns JWKS document with the public keys of the signers.
+func Jwks(t *testing.T, signers ...*JwtSigner) []byte {
+	t.Helper()
+	keys := make([]map[string]any, 0, len(signers))
+	for _, s := range signers {
+		keys = append(keys, s.Jwk())
+	}
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatalf("error encoding JWKS: %v", err)
+	}
+	return data
+}
+
+func encodeJsonSegment(t *testing.T, value any) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("error encoding token segment: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
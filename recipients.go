@@ -0,0 +1,63 @@
+package tgnotifier
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrRecipientGroupCycle = errors.New("recipient groups cycle")
+
+// RecipientGroups maps group names (or aliases) to their members: chat ids or
+// names of other groups.
+type RecipientGroups map[string][]string
+
+// Resolve replaces group names in the recipients list with their members,
+// recursively. Anything which is not a group name is treated as a chat id.
+// Result is deduplicated, keeping the order of the first occurrence.
+func (g RecipientGroups) Resolve(recipients []string) ([]string, error) {
+	result := make([]string, 0, len(recipients))
+	seen := make(map[string]bool, len(recipients))
+
+	var resolve func(recipient string, path []string) error
+	resolve = func(recipient string, path []string) error {
+		members, isGroup := g[recipient]
+		if !isGroup {
+			if !seen[recipient] {
+				seen[recipient] = true
+				result = append(result, recipient)
+			}
+			return nil
+		}
+		if slices.Contains(path, recipient) {
+			return fmt.Errorf("%w: %s", ErrRecipientGroupCycle, strings.Join(append(path, recipient), " -> "))
+		}
+		path = append(path, recipient)
+		for _, member := range members {
+			if err := resolve(member, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, recipient := range recipients {
+		if err := resolve(recipient, nil); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Validate checks the groups for cycles.
+func (g RecipientGroups) Validate() error {
+	names := make([]string, 0, len(g))
+	for name := range g {
+		names = append(names, name)
+	}
+	// sorting, so the reported cycle is always the same
+	slices.Sort(names)
+	_, err := g.Resolve(names)
+	return err
+}
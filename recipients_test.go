@@ -0,0 +1,76 @@
+package tgnotifier_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/religiosa1/tgnotifier"
+)
+
+func TestRecipientGroups_Resolve(t *testing.T) {
+	groups := tgnotifier.RecipientGroups{
+		"alice": {"1001"},
+		"bob":   {"1002"},
+		"ops":   {"alice", "bob"},
+		"dev":   {"bob", "1003"},
+		"all":   {"ops", "dev", "@announcements"},
+	}
+
+	cases := []struct {
+		name       string
+		recipients []string
+		want       []string
+	}{
+		{"raw ids", []string{"1", "2"}, []string{"1", "2"}},
+		{"alias", []string{"alice"}, []string{"1001"}},
+		{"group", []string{"ops"}, []string{"1001", "1002"}},
+		{"nested with dedup", []string{"all"}, []string{"1001", "1002", "1003", "@announcements"}},
+		{"mixed", []string{"42", "dev", "1002", "42"}, []string{"42", "1002", "1003"}},
+		{"empty", []string{}, []string{}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := groups.Resolve(tt.recipients)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resolved)
+		})
+	}
+}
+
+func TestRecipientGroups_Cycle(t *testing.T) {
+	groups := tgnotifier.RecipientGroups{
+		"a":    {"b"},
+		"b":    {"c", "1"},
+		"c":    {"a"},
+		"self": {"self"},
+		"ok":   {"1", "2"},
+	}
+
+	_, err := groups.Resolve([]string{"a"})
+	assert.ErrorIs(t, err, tgnotifier.ErrRecipientGroupCycle)
+	assert.ErrorContains(t, err, "a -> b -> c -> a")
+
+	_, err = groups.Resolve([]string{"self"})
+	assert.ErrorIs(t, err, tgnotifier.ErrRecipientGroupCycle)
+
+	_, err = groups.Resolve([]string{"ok"})
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, groups.Validate(), tgnotifier.ErrRecipientGroupCycle)
+}
+
+func TestRecipientGroups_DiamondIsNotACycle(t *testing.T) {
+	groups := tgnotifier.RecipientGroups{
+		"top":   {"left", "right"},
+		"left":  {"base"},
+		"right": {"base"},
+		"base":  {"1"},
+	}
+
+	assert.NoError(t, groups.Validate())
+	resolved, err := groups.Resolve([]string{"top"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, resolved)
+}
@@ -63,6 +63,12 @@ func (e TgApiError) Error() string {
 // (the one created with [New], not [NewWithClient])
 const DefaultTimeout time.Duration = 30 * time.Second
 
+// SendOptions are the optional parameters of the sent message.
+type SendOptions struct {
+	// send the message silently, users will receive a notification with no sound
+	DisableNotification bool
+}
+
 type BotInterface interface {
 	SendMessage(message string, parseMode ParseMode, recipients []string) error
 	SendMessageWithContext(ctx context.Context, message string, parseMode ParseMode, recipients []string) error
@@ -70,6 +76,28 @@ type BotInterface interface {
 	GetMeWithContext(ctx context.Context) (GetMeResponse, error)
 }
 
+// OptionsSender is implemented by the bots, which can send messages with
+// [SendOptions], like [Bot].
+type OptionsSender interface {
+	SendMessageWithOptions(ctx context.Context, message string, parseMode ParseMode, recipients []string, opts SendOptions) error
+}
+
+// SendWithOptions sends the message with the options, if the bot implements
+// [OptionsSender], or with the default ones otherwise.
+func SendWithOptions(
+	ctx context.Context,
+	bot BotInterface,
+	message string,
+	parseMode ParseMode,
+	recipients []string,
+	opts SendOptions,
+) error {
+	if sender, ok := bot.(OptionsSender); ok {
+		return sender.SendMessageWithOptions(ctx, message, parseMode, recipients, opts)
+	}
+	return bot.SendMessageWithContext(ctx, message, parseMode, recipients)
+}
+
 // Bot is a Telegram notification bot.
 type Bot struct {
 	token      string
@@ -97,15 +125,19 @@ func (bot *Bot) SendMessage(message string, parseMode ParseMode, recipients []st
 	return bot.SendMessageWithContext(context.Background(), message, parseMode, recipients)
 }
 
-// SendMessage sends TG message in a given parseMode to one or more recipients
-//
-// See: https://core.telegram.org/bots/api#sendmessage
+// SendMessageWithContext wraps [SendMessageWithOptions] with the default options.
 func (bot *Bot) SendMessageWithContext(
 	ctx context.Context,
 	message string,
 	parseMode ParseMode,
 	recipients []string,
 ) error {
+	return bot.SendMessageWithOptions(ctx, message, parseMode, recipients, SendOptions{})
+}
+
+// ValidateMessage checks the message length, parse mode and recipients, the
+// same way [Bot.SendMessageWithOptions] does before sending it.
+func ValidateMessage(message string, parseMode ParseMode, recipients []string) error {
 	l := len(message)
 	if l > MaxMsgLen {
 		return ErrMessageTooLong
@@ -119,6 +151,22 @@ func (bot *Bot) SendMessageWithContext(
 	if len(recipients) == 0 {
 		return ErrRecipientsEmpty
 	}
+	return nil
+}
+
+// SendMessageWithOptions sends TG message in a given parseMode to one or more recipients
+//
+// See: https://core.telegram.org/bots/api#sendmessage
+func (bot *Bot) SendMessageWithOptions(
+	ctx context.Context,
+	message string,
+	parseMode ParseMode,
+	recipients []string,
+	opts SendOptions,
+) error {
+	if err := ValidateMessage(message, parseMode, recipients); err != nil {
+		return err
+	}
 
 	if ctx.Err() != nil {
 		return ctx.Err()
@@ -140,9 +188,10 @@ func (bot *Bot) SendMessageWithContext(
 			}
 
 			payload := sendMessagePayload{
-				ChatId:    chatId,
-				Text:      message,
-				ParseMode: parseMode,
+				ChatId:              chatId,
+				Text:                message,
+				ParseMode:           parseMode,
+				DisableNotification: opts.DisableNotification,
 			}
 			if err := bot.sendMessage(ctx, payload); err != nil {
 				errCh <- err
@@ -168,9 +217,10 @@ type botResponse[T any] struct {
 
 // https://core.telegram.org/bots/api#sendmessage
 type sendMessagePayload struct {
-	ChatId    string `json:"chat_id"`
-	Text      string `json:"text"`
-	ParseMode string `json:"parse_mode,omitempty"`
+	ChatId              string `json:"chat_id"`
+	Text                string `json:"text"`
+	ParseMode           string `json:"parse_mode,omitempty"`
+	DisableNotification bool   `json:"disable_notification,omitempty"`
 }
 
 func (bot *Bot) sendMessage(ctx context.Context, payload sendMessagePayload) error {
@@ -2,6 +2,7 @@ package tgnotifier_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -34,6 +35,62 @@ func TestSendMessageWithContext_Success(t *testing.T) {
 	assert.Equal(t, 1, info["POST "+url])
 }
 
+func TestSendMessageWithOptions_DisableNotification(t *testing.T) {
+	bot := newTestBot(t)
+
+	var payload map[string]any
+	httpmock.RegisterResponder("POST", getMockEndpoint("sendMessage"),
+		func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+				return nil, err
+			}
+			return httpmock.NewJsonResponse(200, map[string]interface{}{"ok": true})
+		},
+	)
+
+	opts := tgnotifier.SendOptions{DisableNotification: true}
+	err := bot.SendMessageWithOptions(context.Background(), "hello", "", []string{"123"}, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, true, payload["disable_notification"])
+	assert.Equal(t, "123", payload["chat_id"])
+}
+
+// basicBot implements only the BotInterface methods, like external implementations
+type basicBot struct {
+	tgnotifier.BotInterface
+	message string
+}
+
+func (b *basicBot) SendMessageWithContext(ctx context.Context, message string, parseMode tgnotifier.ParseMode, recipients []string) error {
+	b.message = message
+	return nil
+}
+
+func TestSendWithOptions(t *testing.T) {
+	bot := newTestBot(t)
+	var payload map[string]any
+	httpmock.RegisterResponder("POST", getMockEndpoint("sendMessage"),
+		func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+				return nil, err
+			}
+			return httpmock.NewJsonResponse(200, map[string]interface{}{"ok": true})
+		},
+	)
+	opts := tgnotifier.SendOptions{DisableNotification: true}
+
+	err := tgnotifier.SendWithOptions(context.Background(), bot, "hello", "", []string{"123"}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, true, payload["disable_notification"])
+
+	// options are dropped for the bots, which don't support them
+	fallback := &basicBot{}
+	err = tgnotifier.SendWithOptions(context.Background(), fallback, "hello", "", []string{"123"}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", fallback.message)
+}
+
 func TestSendMessageWithContext_InvalidInputs(t *testing.T) {
 	bot := newTestBot(t)
 